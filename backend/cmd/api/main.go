@@ -4,12 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
 	"time"
+	// the time zone database, for hosts without one
+	_ "time/tzdata"
 
 	"github.com/go-chi/httplog/v3"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -17,9 +20,16 @@ import (
 	v1 "github.com/linuxunsw/vote/backend/internal/api/v1"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/handlers"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
+	"github.com/linuxunsw/vote/backend/internal/broker"
 	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/jwtkeys"
 	"github.com/linuxunsw/vote/backend/internal/logger"
 	"github.com/linuxunsw/vote/backend/internal/mailer"
+	"github.com/linuxunsw/vote/backend/internal/outbox"
+	"github.com/linuxunsw/vote/backend/internal/receipt"
+	"github.com/linuxunsw/vote/backend/internal/scheduler"
+	"github.com/linuxunsw/vote/backend/internal/store"
 	"github.com/linuxunsw/vote/backend/internal/store/migrations"
 	"github.com/linuxunsw/vote/backend/internal/store/pg"
 	"github.com/pressly/goose/v3"
@@ -82,6 +92,11 @@ func main() {
 			In:   "cookie",
 			Name: cfg.JWT.CookieName,
 		},
+		// session JWTs, or API tokens
+		"bearerAuth": {
+			Type:   "http",
+			Scheme: "bearer",
+		},
 	}
 	api := humago.New(router, humaCfg)
 
@@ -111,32 +126,113 @@ func main() {
 		os.Exit(1)
 	}
 
-	// init mailer
-	var mail mailer.Mailer
-	if cfg.Mailer.ConsoleMailer {
-		mail = mailer.NewConsoleMailer(logger)
-	} else {
-		mail = mailer.NewResendMailer(cfg)
+	// init mailer, with templates branded for the society
+	templates, err := mailer.NewTemplates(cfg.Mailer.Branding)
+	if err != nil {
+		logger.Error("Unable to parse email templates", "error", err)
+		os.Exit(1)
+	}
+	mail, err := mailer.New(cfg, logger, templates)
+	if err != nil {
+		logger.Error("Unable to create mailer", "error", err)
+		os.Exit(1)
+	}
+
+	// init receipt signer. receipts signed by a generated key can't be verified after a
+	// restart, so it is only allowed in development
+	if cfg.Receipt.SigningKey == "" {
+		if !cfg.API.Development {
+			logger.Error("RECEIPT_SIGNING_KEY must be set unless DEVELOPMENT is true")
+			os.Exit(1)
+		}
+		logger.Warn("RECEIPT_SIGNING_KEY is not set, vote receipts will not verify after a restart")
+	}
+	receiptSigner, err := receipt.NewSigner(cfg.Receipt.SigningKey)
+	if err != nil {
+		logger.Error("Unable to create receipt signer", "error", err)
+		os.Exit(1)
+	}
+
+	// init session token keys. a generated key is only known to this process, so outside
+	// development tokens would be rejected by other replicas and after a restart
+	if cfg.JWT.SigningKeyFile == "" {
+		if !cfg.API.Development {
+			logger.Error("JWT_SIGNING_KEY_FILE must be set unless DEVELOPMENT is true")
+			os.Exit(1)
+		}
+		logger.Warn("JWT_SIGNING_KEY_FILE is not set, sessions will end when the server restarts")
+	}
+	sessionKeys, err := jwtkeys.NewKeySet(cfg.JWT.SigningKeyFile, cfg.JWT.VerificationKeyFiles)
+	if err != nil {
+		logger.Error("Unable to load session token keys", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Loaded session token keys", "kid", sessionKeys.SigningKeyID(), "verification_keys", sessionKeys.PublicKeys().Len())
+
+	// decides what a zID looks like and where members are emailed
+	identities, err := identity.New(cfg.Identity)
+	if err != nil {
+		logger.Error("Unable to create identity provider", "error", err)
+		os.Exit(1)
+	}
+
+	// dates without a time zone are in the society's
+	location, err := time.LoadLocation(cfg.Election.TimeZone)
+	if err != nil {
+		logger.Error("Unable to load time zone", "time_zone", cfg.Election.TimeZone, "error", err)
+		os.Exit(1)
 	}
 
 	// setup stores
 	otpStore := pg.NewPgOTPStore(pool, cfg.OTP)
-	electionStore := pg.NewPgElectionStore(pool)
+	electionStore := pg.NewPgElectionStore(pool, identities)
 	nominationStore := pg.NewPgNominationStore(pool)
 	ballotStore := pg.NewPgBallotStore(pool)
+	resultsStore := pg.NewPgResultsStore(pool)
+	positionStore := pg.NewPgPositionStore(pool)
+	auditStore := pg.NewPgAuditStore(pool)
+	scheduleStore := pg.NewPgScheduleStore(pool)
+	dashboardStore := pg.NewPgDashboardStore(pool, cfg.Dashboard)
+	adminStore := pg.NewPgAdminStore(pool)
+	sessionStore := pg.NewPgSessionStore(pool)
+	apiTokenStore := pg.NewPgAPITokenStore(pool)
+	emailStore := pg.NewPgEmailOutboxStore(pool)
+
+	// sends emails, retrying those that fail
+	emailOutbox := outbox.New(logger, emailStore, mail, templates, dashboardStore, electionStore, identities, cfg.Outbox)
+
+	// fans state changes out to stream clients
+	stateBroker := broker.New(logger, pg.NewPgStateListener(pool), electionStore)
 
 	deps := v1.HandlerDependencies{
 		Logger:          logger,
 		Cfg:             cfg,
-		Mailer:          mail,
 		Checker:         health,
+		Outbox:          emailOutbox,
+		ReceiptSigner:   receiptSigner,
+		SessionKeys:     sessionKeys,
+		StateBroker:     stateBroker,
+		Identity:        identities,
+		Location:        location,
 		OtpStore:        otpStore,
 		ElectionStore:   electionStore,
 		NominationStore: nominationStore,
 		BallotStore:     ballotStore,
+		ResultsStore:    resultsStore,
+		PositionStore:   positionStore,
+		AuditStore:      auditStore,
+		ScheduleStore:   scheduleStore,
+		DashboardStore:  dashboardStore,
+		AdminStore:      adminStore,
+		SessionStore:    sessionStore,
+		APITokenStore:   apiTokenStore,
+		EmailStore:      emailStore,
 	}
 	v1.Register(api, deps)
 
+	// runs scheduled state transitions while the server is up
+	sched := scheduler.New(logger, scheduleStore, electionStore, auditStore, emailOutbox, cfg.Scheduler.Interval)
+
 	// cli & env parsing for high level config and commands
 	cli := humacli.New(func(hooks humacli.Hooks, opts *Options) {
 		server := http.Server{
@@ -144,7 +240,21 @@ func main() {
 			Handler: router,
 		}
 
+		// scheduler, broker and outbox run until the server stops
+		backgroundCtx, stopBackground := context.WithCancel(context.Background())
+
 		hooks.OnStart(func() {
+			// ADMIN_ZIDS bootstrap the first returning officers, who can grant other roles.
+			// ignored once there are admins, so revoked roles stay revoked
+			if err := adminStore.EnsureAdmins(backgroundCtx, cfg.Admin.AdminZIds, store.RoleReturningOfficer); err != nil {
+				logger.Error("Unable to add admins from ADMIN_ZIDS", "error", err)
+				os.Exit(1)
+			}
+
+			go sched.Run(backgroundCtx)
+			go stateBroker.Run(backgroundCtx)
+			go emailOutbox.Run(backgroundCtx)
+
 			logger.Info("Starting server", "Port", opts.Port)
 			if err := server.ListenAndServe(); err != http.ErrServerClosed {
 				logger.Error("Server error", "error", err)
@@ -153,12 +263,21 @@ func main() {
 		})
 
 		hooks.OnStop(func() {
+			stopBackground()
+
 			// Graceful shutdown :)
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 			if err := server.Shutdown(ctx); err != nil {
 				logger.Error("Graceful shutdown failed", "error", err)
 			}
+
+			// the smtp mailer holds a connection open to the relay between emails
+			if closer, ok := mail.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					logger.Error("Unable to close mailer", "error", err)
+				}
+			}
 		})
 	})
 
@@ -170,6 +289,7 @@ func main() {
 	// Add commands to cli
 	cmd.AddCommand(createOpenAPICommand(api))
 	cmd.AddCommand(createMigrateCommand(logger, cfg))
+	cmd.AddCommand(createAuditCommand(logger, auditStore))
 
 	// TODO: register more commands
 	// i.e running tests(?), (de)registering admins(?)
@@ -224,3 +344,46 @@ func createMigrateCommand(log *slog.Logger, cfg config.Config) *cobra.Command {
 		},
 	}
 }
+
+func createAuditCommand(log *slog.Logger, auditStore store.AuditStore) *cobra.Command {
+	audit := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the audit log",
+	}
+
+	audit.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Short: "Check the audit log's hash chain is intact",
+		Run: func(cmd *cobra.Command, args []string) {
+			const pageSize = 1000
+
+			var prevSeq int64
+			prevHash := ""
+			verified := 0
+			for {
+				entries, err := auditStore.List(context.Background(), prevSeq, pageSize)
+				if err != nil {
+					log.Error("Failed to read audit log", "error", err)
+					os.Exit(1)
+				}
+
+				if err := store.VerifyAuditChain(prevSeq, prevHash, entries); err != nil {
+					log.Error("Audit log verification failed", "error", err)
+					os.Exit(1)
+				}
+				verified += len(entries)
+				if len(entries) > 0 {
+					prevSeq = entries[len(entries)-1].Seq
+					prevHash = entries[len(entries)-1].Hash
+				}
+				if len(entries) < pageSize {
+					break
+				}
+			}
+
+			fmt.Printf("audit log verified: %d entries, head %s\n", verified, prevHash)
+		},
+	})
+
+	return audit
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func ListAdmins(log *slog.Logger, ad store.AdminStore) func(ctx context.Context, input *struct{}) (*models.ListAdminsResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*models.ListAdminsResponse, error) {
+		admins, err := ad.ListAdmins(ctx)
+		if err != nil {
+			log.Error("failed to list admins", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		if admins == nil {
+			admins = []store.Admin{}
+		}
+
+		return &models.ListAdminsResponse{
+			Body: models.ListAdminsResponseBody{
+				Admins: admins,
+			},
+		}, nil
+	}
+}
+
+func GrantAdminRole(log *slog.Logger, ad store.AdminStore, au store.AuditStore, ids identity.Provider) func(ctx context.Context, input *models.GrantAdminRoleInput) (*models.GrantAdminRoleResponse, error) {
+	return func(ctx context.Context, input *models.GrantAdminRoleInput) (*models.GrantAdminRoleResponse, error) {
+		claims, valid := middleware.GetUser(ctx)
+		if !valid {
+			return nil, huma.Error401Unauthorized("invalid user")
+		}
+		if err := validateZid(ids, input.Zid, "path.zid"); err != nil {
+			return nil, err
+		}
+
+		var admin *store.Admin
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			var err error
+			admin, err = ad.GrantRole(ctx, input.Zid, input.Body.Role, claims.ZID)
+			return store.NewAuditEntry{
+				Action:  store.AuditAdminGranted,
+				Details: map[string]any{"zid": input.Zid, "role": input.Body.Role},
+			}, err
+		})
+		if errors.Is(err, store.ErrAdminLastReturningOfficer) {
+			return nil, huma.Error409Conflict(err.Error())
+		} else if err != nil {
+			log.Error("failed to grant admin role", "error", err, "zid", input.Zid, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &models.GrantAdminRoleResponse{Body: *admin}, nil
+	}
+}
+
+func RevokeAdminRole(log *slog.Logger, ad store.AdminStore, au store.AuditStore) func(ctx context.Context, input *models.RevokeAdminRoleInput) (*struct{}, error) {
+	return func(ctx context.Context, input *models.RevokeAdminRoleInput) (*struct{}, error) {
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			return store.NewAuditEntry{
+				Action:  store.AuditAdminRevoked,
+				Details: map[string]any{"zid": input.Zid},
+			}, ad.RevokeRole(ctx, input.Zid)
+		})
+		if errors.Is(err, store.ErrAdminNotFound) {
+			return nil, huma.Error404NotFound("admin not found")
+		} else if errors.Is(err, store.ErrAdminLastReturningOfficer) {
+			return nil, huma.Error409Conflict(err.Error())
+		} else if err != nil {
+			log.Error("failed to revoke admin role", "error", err, "zid", input.Zid, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &struct{}{}, nil
+	}
+}
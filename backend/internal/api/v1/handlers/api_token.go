@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func CreateAPIToken(log *slog.Logger, tk store.APITokenStore, ad store.AdminStore, au store.AuditStore) func(ctx context.Context, input *models.CreateAPITokenInput) (*models.CreateAPITokenResponse, error) {
+	return func(ctx context.Context, input *models.CreateAPITokenInput) (*models.CreateAPITokenResponse, error) {
+		claims, valid := middleware.GetUser(ctx)
+		if !valid {
+			return nil, huma.Error401Unauthorized("invalid user")
+		}
+
+		if input.Body.ExpiresAt != nil && !input.Body.ExpiresAt.After(time.Now()) {
+			return nil, huma.Error422UnprocessableEntity("expires_at must be in the future")
+		}
+
+		// tokens can't do anything their creator can't
+		admin, err := ad.GetAdmin(ctx, claims.ZID)
+		if err != nil {
+			log.Error("failed to get admin", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		for _, scope := range input.Body.Scopes {
+			if admin == nil || !admin.Role.Can(scope) {
+				return nil, huma.Error403Forbidden("your role doesn't grant " + string(scope))
+			}
+		}
+
+		secret, hash, err := store.GenerateAPIToken()
+		if err != nil {
+			log.Error("failed to generate API token", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		var token *store.APIToken
+		err = audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			var err error
+			token, err = tk.CreateAPIToken(ctx, store.NewAPIToken{
+				Name:      input.Body.Name,
+				Scopes:    input.Body.Scopes,
+				CreatedBy: claims.ZID,
+				ExpiresAt: input.Body.ExpiresAt,
+			}, hash)
+			if err != nil {
+				return store.NewAuditEntry{}, err
+			}
+			return store.NewAuditEntry{
+				Action:  store.AuditAPITokenCreated,
+				Details: map[string]any{"token_id": token.TokenID, "name": token.Name, "scopes": token.Scopes},
+			}, nil
+		})
+		if err != nil {
+			log.Error("failed to create API token", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &models.CreateAPITokenResponse{
+			Body: models.CreateAPITokenResponseBody{
+				APIToken: *token,
+				Token:    secret,
+			},
+		}, nil
+	}
+}
+
+func ListAPITokens(log *slog.Logger, tk store.APITokenStore) func(ctx context.Context, input *struct{}) (*models.ListAPITokensResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*models.ListAPITokensResponse, error) {
+		tokens, err := tk.ListAPITokens(ctx)
+		if err != nil {
+			log.Error("failed to list API tokens", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &models.ListAPITokensResponse{
+			Body: models.ListAPITokensResponseBody{
+				Tokens: tokens,
+			},
+		}, nil
+	}
+}
+
+func RevokeAPIToken(log *slog.Logger, tk store.APITokenStore, au store.AuditStore) func(ctx context.Context, input *models.RevokeAPITokenInput) (*struct{}, error) {
+	return func(ctx context.Context, input *models.RevokeAPITokenInput) (*struct{}, error) {
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			return store.NewAuditEntry{
+				Action:  store.AuditAPITokenRevoked,
+				Details: map[string]any{"token_id": input.TokenID},
+			}, tk.RevokeAPIToken(ctx, input.TokenID)
+		})
+		if errors.Is(err, store.ErrAPITokenNotFound) {
+			return nil, huma.Error404NotFound("api token not found")
+		} else if err != nil {
+			log.Error("failed to revoke API token", "error", err, "token_id", input.TokenID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &struct{}{}, nil
+	}
+}
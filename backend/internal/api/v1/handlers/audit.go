@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func GetAuditLog(log *slog.Logger, au store.AuditStore) func(ctx context.Context, input *models.GetAuditLogInput) (*models.GetAuditLogResponse, error) {
+	return func(ctx context.Context, input *models.GetAuditLogInput) (*models.GetAuditLogResponse, error) {
+		entries, err := au.List(ctx, input.After, input.Limit)
+		if err != nil {
+			log.Error("failed to list audit log", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		return &models.GetAuditLogResponse{
+			Body: models.GetAuditLogResponseBody{
+				Entries: entries,
+			},
+		}, nil
+	}
+}
+
+// runs action and appends the entry it returns to the audit log in one transaction, so
+// the action is undone if it can't be recorded. stores must be called with the context
+// action is given to take part in the transaction. the entry is performed by the current
+// user unless entry.Actor is set. errors from action are returned as they are
+func audited(ctx context.Context, au store.AuditStore, action func(ctx context.Context) (store.NewAuditEntry, error)) error {
+	return au.Record(ctx, func(ctx context.Context) (store.NewAuditEntry, error) {
+		entry, err := action(ctx)
+		if err != nil {
+			return entry, err
+		}
+
+		if claims, valid := middleware.GetUser(ctx); valid && entry.Actor == "" {
+			entry.Actor = claims.ZID
+
+			// the actor is the token's creator, so record which of their tokens was used
+			if claims.IsAPIToken() {
+				details := map[string]any{"api_token_id": claims.APITokenID}
+				for k, v := range entry.Details {
+					details[k] = v
+				}
+				entry.Details = details
+			}
+		}
+		return entry, nil
+	})
+}
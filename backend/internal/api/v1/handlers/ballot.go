@@ -13,7 +13,7 @@ import (
 
 // Note that "ballots" as refered to in the backend/database actually mean "votes" in the frontend/handlers.
 // This GetBallot is actually just returning the user's submitted vote.
-func GetBallot(log *slog.Logger, st store.BallotStore, el store.ElectionStore, nom store.NominationStore) func(ctx context.Context, input *struct{}) (*models.GetBallotResponse, error) {
+func GetBallot(log *slog.Logger, st store.BallotStore, el store.ElectionStore, nom store.NominationStore, pos store.PositionStore) func(ctx context.Context, input *struct{}) (*models.GetBallotResponse, error) {
 	return func(ctx context.Context, input *struct{}) (*models.GetBallotResponse, error) {
 		claims, valid := middleware.GetUser(ctx)
 		if !valid {
@@ -39,8 +39,17 @@ func GetBallot(log *slog.Logger, st store.BallotStore, el store.ElectionStore, n
 		// this is all we use GetBallot for
 		hasVoted := ballot != nil
 
-		// executive role -> candidates running for that role
-		candidates := make(map[string][]models.PublicNomination)
+		positions, err := pos.GetPositions(ctx, election.ElectionID)
+		if err != nil {
+			log.Error("failed to get positions", "error", err, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		// position -> candidates running for that position
+		candidates := make(map[string][]models.PublicNomination, len(positions))
+		for _, position := range positions {
+			candidates[position.Key] = []models.PublicNomination{}
+		}
 
 		nominations, err := nom.GetElectionNominations(ctx, election.ElectionID)
 		if err != nil {
@@ -49,11 +58,17 @@ func GetBallot(log *slog.Logger, st store.BallotStore, el store.ElectionStore, n
 		}
 		for _, nomination := range nominations {
 			for _, role := range nomination.ExecutiveRoles {
+				if _, ok := candidates[role]; !ok {
+					// nominations are validated against positions when they are submitted
+					continue
+				}
 				candidates[role] = append(candidates[role], models.FromStoreNomination(nomination))
 			}
 		}
 		response := models.PublicBallot{
 			ElectionID: election.ElectionID,
+			Positions:  positions,
+			Options:    models.BallotOptions,
 			HasVoted:   hasVoted,
 			Candidates: candidates,
 		}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/sse"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func DashboardStream(log *slog.Logger, el store.ElectionStore, db store.DashboardStore, ss store.SessionStore, cfg config.DashboardConfig) func(ctx context.Context, input *struct{}, send sse.Sender) {
+	return func(ctx context.Context, input *struct{}, send sse.Sender) {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			event, err := dashboardEvent(ctx, el, db, cfg)
+			if err != nil {
+				log.Error("failed to get dashboard statistics", "error", err, "request_id", requestid.Get(ctx))
+				return
+			}
+			if err := send.Data(event); err != nil {
+				log.Warn("unable to send DashboardEvent", "error", err, "request_id", requestid.Get(ctx))
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// closed once the admin's session is revoked or expires
+			if sessionEnded(ctx, log, ss) {
+				return
+			}
+		}
+	}
+}
+
+func dashboardEvent(ctx context.Context, el store.ElectionStore, db store.DashboardStore, cfg config.DashboardConfig) (*models.DashboardEvent, error) {
+	now := time.Now()
+	event := &models.DashboardEvent{
+		State:            "NO_ELECTION",
+		OTPWindowSeconds: int(cfg.OTPWindow.Seconds()),
+		GeneratedAt:      now,
+	}
+
+	election, err := el.CurrentElection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if election == nil {
+		return event, nil
+	}
+
+	stats, err := db.GetStats(ctx, election.ElectionID, now.Add(-cfg.OTPWindow), now)
+	if err != nil {
+		return nil, err
+	}
+
+	event.State = string(election.State)
+	event.ElectionId = election.ElectionID
+	event.Stats = stats
+	return event, nil
+}
@@ -3,29 +3,130 @@ package handlers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/memberlist"
+	"github.com/linuxunsw/vote/backend/internal/outbox"
 	"github.com/linuxunsw/vote/backend/internal/store"
 )
 
-func ElectionMemberListSet(log *slog.Logger, st store.ElectionStore) func(ctx context.Context, input *models.ElectionMemberListSetInput) (*models.ElectionMemberListSetResponse, error) {
+func ElectionMemberListSet(log *slog.Logger, st store.ElectionStore, au store.AuditStore) func(ctx context.Context, input *models.ElectionMemberListSetInput) (*models.ElectionMemberListSetResponse, error) {
 	return func(ctx context.Context, input *models.ElectionMemberListSetInput) (*models.ElectionMemberListSetResponse, error) {
-		err := st.SetMembers(ctx, input.ElectionId, input.Body.Zids)
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			err := st.SetMembers(ctx, input.ElectionId, input.Body.Zids)
+			return store.NewAuditEntry{
+				ElectionID: input.ElectionId,
+				Action:     store.AuditMembersSet,
+				Details:    map[string]any{"zids": input.Body.Zids},
+			}, err
+		})
 		if err != nil {
 			log.Error("failed to set election members", "error", err, "request_id", requestid.Get(ctx))
 			return nil, huma.Error500InternalServerError("internal error")
 		}
+
 		return &models.ElectionMemberListSetResponse{}, nil
 	}
 }
 
-func CreateElection(log *slog.Logger, st store.ElectionStore) func(ctx context.Context, input *models.CreateElectionInput) (*models.CreateElectionResponse, error) {
+func UploadMembers(log *slog.Logger, st store.ElectionStore, au store.AuditStore, ids identity.Provider, loc *time.Location) func(ctx context.Context, input *models.UploadMembersInput) (*models.UploadMembersResponse, error) {
+	return func(ctx context.Context, input *models.UploadMembersInput) (*models.UploadMembersResponse, error) {
+		file := input.RawBody.Data().File
+
+		result, err := memberlist.Parse(file, time.Now(), loc, ids)
+		if errors.Is(err, memberlist.ErrNoZIDColumn) {
+			return nil, huma.Error422UnprocessableEntity("no zID column found in the CSV header")
+		} else if err != nil {
+			log.Warn("failed to parse member CSV", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error422UnprocessableEntity("invalid CSV: " + err.Error())
+		}
+
+		if len(result.Members) == 0 {
+			// don't empty the member list because of a bad export
+			details := make([]error, 0, len(result.Errors))
+			for _, rowErr := range result.Errors {
+				details = append(details, &huma.ErrorDetail{
+					Message:  rowErr.Message,
+					Location: fmt.Sprintf("file.row[%d]", rowErr.Row),
+					Value:    rowErr.Value,
+				})
+			}
+			return nil, huma.Error422UnprocessableEntity("no valid members found", details...)
+		}
+
+		var summary *store.MemberImportSummary
+		if input.DryRun {
+			summary, err = st.ImportMembers(ctx, input.ElectionId, result.Members, true)
+		} else {
+			zids := make([]string, 0, len(result.Members))
+			for _, member := range result.Members {
+				zids = append(zids, member.Zid)
+			}
+			err = audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+				var err error
+				summary, err = st.ImportMembers(ctx, input.ElectionId, result.Members, false)
+				return store.NewAuditEntry{
+					ElectionID: input.ElectionId,
+					Action:     store.AuditMembersSet,
+					Details:    map[string]any{"zids": zids, "source": "csv"},
+				}, err
+			})
+		}
+		if errors.Is(err, store.ErrElectionNotFound) {
+			return nil, huma.Error404NotFound("election not found")
+		} else if err != nil {
+			log.Error("failed to import election members", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		resp := &models.UploadMembersResponse{
+			Body: models.UploadMembersResponseBody{
+				DryRun:   input.DryRun,
+				Rows:     result.Rows,
+				Imported: len(result.Members),
+				Summary:  *summary,
+				Errors:   result.Errors,
+			},
+		}
+		if resp.Body.Errors == nil {
+			resp.Body.Errors = []memberlist.RowError{}
+		}
+
+		if input.DryRun {
+			resp.Body.Members = result.Members
+		}
+		return resp, nil
+	}
+}
+
+func CreateElection(log *slog.Logger, st store.ElectionStore, au store.AuditStore, cfg config.ElectionConfig) func(ctx context.Context, input *models.CreateElectionInput) (*models.CreateElectionResponse, error) {
 	return func(ctx context.Context, input *models.CreateElectionInput) (*models.CreateElectionResponse, error) {
-		electionId, err := st.CreateElection(ctx, input.Body.Name)
+		positions := input.Body.Positions
+		if len(positions) == 0 {
+			positions = store.DefaultPositions(cfg.PositionSeats)
+		}
+
+		var electionId string
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			var err error
+			electionId, err = st.CreateElection(ctx, input.Body.Name, positions, input.Body.SecretBallot)
+			return store.NewAuditEntry{
+				ElectionID: electionId,
+				Action:     store.AuditElectionCreated,
+				Details: map[string]any{
+					"name":          input.Body.Name,
+					"positions":     positions,
+					"secret_ballot": input.Body.SecretBallot,
+				},
+			}, err
+		})
 		if errors.Is(err, store.ErrElectionCreateAlreadyRunning) {
 			log.Warn("attempted to create election while one is already running", "request_id", requestid.Get(ctx))
 			return nil, huma.Error400BadRequest("an election is already running")
@@ -33,6 +134,7 @@ func CreateElection(log *slog.Logger, st store.ElectionStore) func(ctx context.C
 			log.Error("failed to create election", "error", err, "request_id", requestid.Get(ctx))
 			return nil, huma.Error500InternalServerError("internal error")
 		}
+
 		return &models.CreateElectionResponse{
 			Body: models.CreateElectionResponseBody{
 				ElectionId: electionId,
@@ -65,9 +167,30 @@ func GetElectionState(log *slog.Logger, st store.ElectionStore) func(ctx context
 	}
 }
 
-func TransitionElectionState(log *slog.Logger, st store.ElectionStore) func(ctx context.Context, input *models.TransitionElectionStateInput) (*models.TransitionElectionStateResponse, error) {
+func TransitionElectionState(log *slog.Logger, st store.ElectionStore, au store.AuditStore, ob *outbox.Outbox) func(ctx context.Context, input *models.TransitionElectionStateInput) (*models.TransitionElectionStateResponse, error) {
 	return func(ctx context.Context, input *models.TransitionElectionStateInput) (*models.TransitionElectionStateResponse, error) {
-		err := st.CurrentElectionSetState(ctx, string(input.Body.State))
+		var election *store.Election
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			// for the audit log
+			var err error
+			election, err = st.CurrentElection(ctx)
+			if err != nil {
+				return store.NewAuditEntry{}, err
+			}
+
+			if err := st.CurrentElectionSetState(ctx, string(input.Body.State)); err != nil {
+				return store.NewAuditEntry{}, err
+			}
+			if election.State == input.Body.State {
+				// transitioning to the current state changes nothing
+				return store.NewAuditEntry{}, nil
+			}
+			return store.NewAuditEntry{
+				ElectionID: election.ElectionID,
+				Action:     store.AuditStateTransitioned,
+				Details:    map[string]any{"from": election.State, "to": input.Body.State},
+			}, nil
+		})
 		if errors.Is(err, store.ErrElectionNotFound) {
 			log.Warn("attempted to transition state with no election running", "request_id", requestid.Get(ctx))
 			return nil, huma.Error400BadRequest("no election is currently running")
@@ -86,6 +209,82 @@ func TransitionElectionState(log *slog.Logger, st store.ElectionStore) func(ctx
 			return nil, huma.Error500InternalServerError("internal error")
 		}
 
+		if election.State != input.Body.State {
+			ob.ElectionTransitioned(ctx, election.ElectionID, input.Body.State)
+		}
 		return &models.TransitionElectionStateResponse{}, nil
 	}
 }
+
+func ListElections(log *slog.Logger, st store.ElectionStore) func(ctx context.Context, input *models.ListElectionsInput) (*models.ListElectionsResponse, error) {
+	return func(ctx context.Context, input *models.ListElectionsInput) (*models.ListElectionsResponse, error) {
+		elections, err := st.ListElections(ctx, input.Before, input.Limit)
+		if errors.Is(err, store.ErrElectionNotFound) {
+			return nil, huma.Error422UnprocessableEntity("election not found", &huma.ErrorDetail{
+				Message:  "election not found",
+				Location: "query.before",
+				Value:    input.Before,
+			})
+		} else if err != nil {
+			log.Error("failed to list elections", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		resp := &models.ListElectionsResponse{
+			Body: models.ListElectionsResponseBody{
+				Elections: make([]models.ElectionDetails, 0, len(elections)),
+			},
+		}
+		for _, election := range elections {
+			resp.Body.Elections = append(resp.Body.Elections, models.FromStoreElection(election))
+		}
+		// a full page might not be the last one
+		if len(elections) == input.Limit {
+			resp.Body.NextBefore = elections[len(elections)-1].ElectionID
+		}
+		return resp, nil
+	}
+}
+
+func GetElection(log *slog.Logger, st store.ElectionStore) func(ctx context.Context, input *models.GetElectionInput) (*models.GetElectionResponse, error) {
+	return func(ctx context.Context, input *models.GetElectionInput) (*models.GetElectionResponse, error) {
+		election, err := st.GetElection(ctx, input.ElectionId)
+		if err != nil {
+			log.Error("failed to get election", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		if election == nil {
+			return nil, huma.Error404NotFound("election not found")
+		}
+
+		return &models.GetElectionResponse{Body: models.FromStoreElection(*election)}, nil
+	}
+}
+
+func GetElectionNominations(log *slog.Logger, st store.ElectionStore, nom store.NominationStore) func(ctx context.Context, input *models.GetElectionInput) (*models.GetElectionNominationsResponse, error) {
+	return func(ctx context.Context, input *models.GetElectionInput) (*models.GetElectionNominationsResponse, error) {
+		election, err := st.GetElection(ctx, input.ElectionId)
+		if err != nil {
+			log.Error("failed to get election", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		if election == nil {
+			return nil, huma.Error404NotFound("election not found")
+		}
+
+		nominations, err := nom.GetElectionNominations(ctx, election.ElectionID)
+		if err != nil {
+			log.Error("failed to get election nominations", "error", err, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		if nominations == nil {
+			nominations = []store.Nomination{}
+		}
+
+		return &models.GetElectionNominationsResponse{
+			Body: models.GetElectionNominationsResponseBody{
+				Nominations: nominations,
+			},
+		}, nil
+	}
+}
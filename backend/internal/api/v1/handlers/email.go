@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func ListEmails(log *slog.Logger, em store.EmailOutboxStore) func(ctx context.Context, input *models.ListEmailsInput) (*models.ListEmailsResponse, error) {
+	return func(ctx context.Context, input *models.ListEmailsInput) (*models.ListEmailsResponse, error) {
+		emails, err := em.ListEmails(ctx, input.Status, input.Limit)
+		if err != nil {
+			log.Error("failed to list emails", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &models.ListEmailsResponse{
+			Body: models.ListEmailsResponseBody{
+				Emails: emails,
+			},
+		}, nil
+	}
+}
+
+func RetryEmail(log *slog.Logger, em store.EmailOutboxStore, au store.AuditStore) func(ctx context.Context, input *models.RetryEmailInput) (*models.RetryEmailResponse, error) {
+	return func(ctx context.Context, input *models.RetryEmailInput) (*models.RetryEmailResponse, error) {
+		var email *store.OutboxEmail
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			var err error
+			email, err = em.RetryEmail(ctx, input.EmailID, time.Now())
+			if err != nil {
+				return store.NewAuditEntry{}, err
+			}
+			return store.NewAuditEntry{
+				Action:  store.AuditEmailRetried,
+				Details: map[string]any{"email_id": email.EmailID, "kind": email.Kind, "recipient": email.Recipient},
+			}, nil
+		})
+		if errors.Is(err, store.ErrEmailNotFound) {
+			return nil, huma.Error404NotFound("email not found")
+		} else if errors.Is(err, store.ErrEmailNotRetryable) {
+			return nil, huma.Error409Conflict(err.Error())
+		} else if err != nil {
+			log.Error("failed to retry email", "error", err, "email_id", input.EmailID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &models.RetryEmailResponse{Body: *email}, nil
+	}
+}
@@ -0,0 +1,131 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func TestAdminRoles(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	officerCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	resp := api.Get("/api/v1/admins", officerCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	list := models.ListAdminsResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(list.Admins) != 1 || list.Admins[0].Role != store.RoleReturningOfficer || list.Admins[0].GrantedBy != store.AdminGrantedByConfig {
+		t.Fatalf("expected only the bootstrapped returning officer, got %+v", list.Admins)
+	}
+
+	scrutineer, manager := "z2222222", "z3333333"
+	for zid, role := range map[string]store.AdminRole{scrutineer: store.RoleScrutineer, manager: store.RoleElectionManager} {
+		resp = api.Put("/api/v1/admins/"+zid, officerCookie, map[string]any{"role": role})
+		if resp.Code != 200 {
+			t.Fatalf("expected 200 OK, got %d", resp.Code)
+		}
+		admin := store.Admin{}
+		if err := json.Unmarshal(resp.Body.Bytes(), &admin); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if admin.Role != role || admin.GrantedBy != TestingDummyAdminZID {
+			t.Fatalf("expected %s granted by %s, got %+v", role, TestingDummyAdminZID, admin)
+		}
+	}
+
+	// admins don't need to be members to sign in
+	resp = generateOTPSubmit(t, api, mailer, scrutineer)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	login := models.SubmitOTPResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &login); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !login.IsAdmin || login.Role == nil || *login.Role != store.RoleScrutineer {
+		t.Fatalf("expected to sign in as a scrutineer, got %+v", login)
+	}
+	scrutineerCookie := extractCookieHeader(resp.Header())
+	managerHeaders := generateOTPSubmit(t, api, mailer, manager).Header()
+	managerCookie := extractCookieHeader(managerHeaders)
+
+	// managers run the election, scrutineers can only watch
+	resp = api.Post("/api/v1/elections", scrutineerCookie, map[string]any{"name": "Test Election"})
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+	_ = createElection(t, api, cfg.JWT, extractJWT(managerHeaders), []string{"z0000000"})
+
+	resp = api.Put("/api/v1/state", scrutineerCookie, map[string]any{"state": "NOMINATIONS_OPEN"})
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+	resp = api.Get("/api/v1/audit", scrutineerCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	// only returning officers can manage admins
+	resp = api.Put("/api/v1/admins/z4444444", managerCookie, map[string]any{"role": store.RoleScrutineer})
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+
+	// revoking takes effect straight away, even with a signed in session
+	resp = api.Delete("/api/v1/admins/"+scrutineer, officerCookie)
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+	resp = api.Get("/api/v1/audit", scrutineerCookie)
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+	resp = api.Delete("/api/v1/admins/"+scrutineer, officerCookie)
+	if resp.Code != 404 {
+		t.Fatalf("expected 404 Not Found, got %d", resp.Code)
+	}
+
+	// the last returning officer can't be removed
+	resp = api.Put("/api/v1/admins/"+TestingDummyAdminZID, officerCookie, map[string]any{"role": store.RoleScrutineer})
+	if resp.Code != 409 {
+		t.Fatalf("expected 409 Conflict, got %d", resp.Code)
+	}
+	resp = api.Delete("/api/v1/admins/"+TestingDummyAdminZID, officerCookie)
+	if resp.Code != 409 {
+		t.Fatalf("expected 409 Conflict, got %d", resp.Code)
+	}
+
+	resp = api.Get("/api/v1/audit", officerCookie)
+	audit := models.GetAuditLogResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &audit); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	counts := map[store.AuditAction]int{}
+	for _, entry := range audit.Entries {
+		counts[entry.Action]++
+	}
+	if counts[store.AuditAdminGranted] != 2 || counts[store.AuditAdminRevoked] != 1 {
+		t.Fatalf("expected 2 grants and 1 revoke in the audit log, got %v", counts)
+	}
+}
+
+func TestAdminRoleUnknown(t *testing.T) {
+	cfg := config.Load()
+	api, _ := NewAPI(t)
+
+	officerCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+	resp := api.Put("/api/v1/admins/z2222222", officerCookie, map[string]any{"role": "SUPERUSER"})
+	if resp.Code != 422 {
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func TestAPITokens(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	// members can't create tokens
+	memberCookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Header())
+	resp := api.Post("/api/v1/api-tokens", memberCookie, map[string]any{
+		"name":   "nope",
+		"scopes": []string{"VIEW_ELECTIONS"},
+	})
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+
+	resp = api.Post("/api/v1/api-tokens", adminCookie, map[string]any{
+		"name":       "email-nominations",
+		"scopes":     []string{"VIEW_ELECTIONS"},
+		"expires_at": time.Now().Add(time.Hour),
+	})
+	if resp.Code != 201 {
+		t.Fatalf("expected 201 Created, got %d: %s", resp.Code, resp.Body.String())
+	}
+	created := models.CreateAPITokenResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !store.IsAPIToken(created.Token) || created.CreatedBy != TestingDummyAdminZID || created.ExpiresAt == nil {
+		t.Fatalf("expected a token created by %s, got %+v", TestingDummyAdminZID, created)
+	}
+	bearer := "Authorization: Bearer " + created.Token
+
+	// it can use its scope
+	resp = api.Get("/api/v1/elections/"+electionId, bearer)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	// but nothing else
+	resp = api.Post("/api/v1/elections/"+electionId+"/members", bearer, map[string]any{"zid": "z0000001"})
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+	resp = api.Get("/api/v1/state", bearer)
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+	resp = api.Post("/api/v1/api-tokens", bearer, map[string]any{
+		"name":   "another",
+		"scopes": []string{"VIEW_ELECTIONS"},
+	})
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+
+	// the token itself isn't listed, but its use is
+	resp = api.Get("/api/v1/api-tokens", adminCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	list := models.ListAPITokensResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(list.Tokens) != 1 || list.Tokens[0].TokenID != created.TokenID || list.Tokens[0].LastUsedAt == nil {
+		t.Fatalf("expected the used token, got %+v", list.Tokens)
+	}
+
+	resp = api.Delete("/api/v1/api-tokens/"+created.TokenID, adminCookie)
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+	resp = api.Delete("/api/v1/api-tokens/"+created.TokenID, adminCookie)
+	if resp.Code != 404 {
+		t.Fatalf("expected 404 Not Found, got %d", resp.Code)
+	}
+	resp = api.Get("/api/v1/elections/"+electionId, bearer)
+	if resp.Code != 401 {
+		t.Fatalf("expected 401 Unauthorized, got %d", resp.Code)
+	}
+}
+
+func TestAPITokenCreatorRevoked(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	officerCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+	other := "z2222222"
+	resp := api.Put("/api/v1/admins/"+other, officerCookie, map[string]any{"role": store.RoleReturningOfficer})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	otherCookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, other).Header())
+
+	resp = api.Post("/api/v1/api-tokens", otherCookie, map[string]any{
+		"name":   "script",
+		"scopes": []string{"VIEW_ELECTIONS"},
+	})
+	if resp.Code != 201 {
+		t.Fatalf("expected 201 Created, got %d", resp.Code)
+	}
+	created := models.CreateAPITokenResponseBody{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &created)
+	bearer := "Authorization: Bearer " + created.Token
+
+	resp = api.Get("/api/v1/elections", bearer)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	// tokens are limited to what their creator can currently do
+	resp = api.Delete("/api/v1/admins/"+other, officerCookie)
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+	resp = api.Get("/api/v1/elections", bearer)
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+}
+
+func TestBearerSession(t *testing.T) {
+	api, _ := NewAPI(t)
+
+	// session JWTs work in the Authorization header as well as the cookie
+	resp := api.Get("/api/v1/admins", "Authorization: Bearer "+TestingDummyJWTAdmin)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	resp = api.Get("/api/v1/admins", "Authorization: Basic dXNlcjpwYXNz")
+	if resp.Code != 401 {
+		t.Fatalf("expected 401 Unauthorized, got %d", resp.Code)
+	}
+	resp = api.Get("/api/v1/admins", "Authorization: Bearer vote_notarealtoken")
+	if resp.Code != 401 {
+		t.Fatalf("expected 401 Unauthorized, got %d", resp.Code)
+	}
+}
@@ -0,0 +1,121 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func TestAuditLog(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+	// transitioning to the current state changes nothing, so isn't recorded
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+
+	cookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Result().Header)
+	resp := api.Put("/api/v1/nomination", cookie, map[string]any{
+		"candidate_name":      "John Doe",
+		"contact_email":       "john@example.com",
+		"discord_username":    "johndoe#1234",
+		"executive_roles":     []string{"president"},
+		"candidate_statement": "Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50",
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	// members can't read the audit log
+	resp = api.Get("/api/v1/audit", cookie)
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+	resp = api.Get("/api/v1/audit", adminCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	body := models.GetAuditLogResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	expected := []store.AuditAction{
+		store.AuditElectionCreated,
+		store.AuditMembersSet,
+		store.AuditStateTransitioned,
+		store.AuditNominationSubmitted,
+	}
+	if len(body.Entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(expected), len(body.Entries), body.Entries)
+	}
+	for i, entry := range body.Entries {
+		if entry.Action != expected[i] {
+			t.Fatalf("expected entry %d to be %s, got %s", i+1, expected[i], entry.Action)
+		}
+		if entry.ElectionID == nil || *entry.ElectionID != electionId {
+			t.Fatalf("expected entry %d to be for election %q, got %v", i+1, electionId, entry.ElectionID)
+		}
+	}
+	if body.Entries[3].Actor != zid {
+		t.Fatalf("expected the nomination to be recorded against %q, got %q", zid, body.Entries[3].Actor)
+	}
+	if err := store.VerifyAuditChain(0, "", body.Entries); err != nil {
+		t.Fatalf("expected the audit log to verify, got %v", err)
+	}
+
+	// paging continues the chain
+	resp = api.Get("/api/v1/audit?after=2&limit=1", adminCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	page := models.GetAuditLogResponseBody{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &page)
+	if len(page.Entries) != 1 || page.Entries[0].Seq != 3 {
+		t.Fatalf("expected only entry 3, got %+v", page.Entries)
+	}
+	if err := store.VerifyAuditChain(2, body.Entries[1].Hash, page.Entries); err != nil {
+		t.Fatalf("expected the page to verify, got %v", err)
+	}
+}
+
+func TestAuditLogUppercaseElectionId(t *testing.T) {
+	cfg := config.Load()
+	api, _ := NewAPI(t)
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{"z0000000"})
+
+	// postgres reads the election back in lowercase, so the entry must be hashed that way
+	resp := api.Put("/api/v1/elections/"+strings.ToUpper(electionId)+"/members", adminCookie, map[string]any{
+		"zids": []string{"z0000000", "z0000001"},
+	})
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+
+	resp = api.Get("/api/v1/audit", adminCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	body := models.GetAuditLogResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if err := store.VerifyAuditChain(0, "", body.Entries); err != nil {
+		t.Fatalf("expected the audit log to verify, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package handlers_test
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/danielgtaylor/huma/v2/humatest"
@@ -60,6 +61,9 @@ func TestBallotGet(t *testing.T) {
 		"secretary":         {"John Doe": false},
 		"grievance_officer": {"grieve man": false},
 		"treasurer":         {"grieve man": false},
+		// every position is on the ballot, even with no candidates
+		"arc_delegate": {},
+		"edi_officer":  {},
 	}
 
 	resp := generateOTPSubmit(t, api, mailer, "z0000003")
@@ -76,6 +80,9 @@ func TestBallotGet(t *testing.T) {
 	if ballotResp.HasVoted {
 		t.Fatalf("expected HasVoted to be false, got true")
 	}
+	if !reflect.DeepEqual(ballotResp.Options, models.BallotOptions) {
+		t.Fatalf("expected options %+v, got %+v", models.BallotOptions, ballotResp.Options)
+	}
 	for role, noms := range ballotResp.Candidates {
 		expNoms, ok := expectedMap[role]
 		if !ok {
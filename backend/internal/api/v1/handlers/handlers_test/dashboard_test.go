@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+)
+
+func TestDashboardStream(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid, "z0000001"})
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+
+	cookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Result().Header)
+	resp := api.Put("/api/v1/nomination", cookie, map[string]any{
+		"candidate_name":      "John Doe",
+		"contact_email":       "john@example.com",
+		"discord_username":    "johndoe#1234",
+		"executive_roles":     []string{"president", "secretary"},
+		"candidate_statement": "Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50",
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	// a wrong code
+	resp = api.Post("/api/v1/otp/submit", map[string]any{
+		"zid": "z0000001",
+		"otp": "000000",
+	})
+	if resp.Code != 400 {
+		t.Fatalf("expected 400 Bad Request, got %d", resp.Code)
+	}
+
+	// codes requested too often are counted apart from ones that failed to send
+	for range cfg.OTP.RatelimitCount {
+		resp = api.Post("/api/v1/otp/generate", map[string]any{"zid": "z0000001"})
+		if resp.Code != 204 {
+			t.Fatalf("expected 204 No Content, got %d", resp.Code)
+		}
+	}
+	resp = api.Post("/api/v1/otp/generate", map[string]any{"zid": "z0000001"})
+	if resp.Code != 429 {
+		t.Fatalf("expected 429 Too Many Requests, got %d", resp.Code)
+	}
+
+	// members can't watch the dashboard
+	resp = api.Get("/api/v1/dashboard/stream", cookie)
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+
+	// the first event is sent straight away
+	ctx, cancel := context.WithTimeout(t.Context(), 500*time.Millisecond)
+	defer cancel()
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+	resp = api.GetCtx(ctx, "/api/v1/dashboard/stream", adminCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	var event models.DashboardEvent
+	for _, line := range strings.Split(resp.Body.String(), "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatalf("failed to unmarshal event: %v", err)
+			}
+			break
+		}
+	}
+
+	if event.State != "NOMINATIONS_OPEN" || event.ElectionId != electionId || event.Stats == nil {
+		t.Fatalf("expected statistics for election %q, got %+v", electionId, event)
+	}
+	stats := event.Stats
+	if stats.Turnout.EligibleVoters != 2 || stats.Turnout.BallotsCast != 0 {
+		t.Fatalf("expected no ballots from 2 eligible voters, got %+v", stats.Turnout)
+	}
+	if stats.Nominations["president"] != 1 || stats.Nominations["secretary"] != 1 || stats.Nominations["treasurer"] != 0 {
+		t.Fatalf("expected one nomination for president and secretary, got %+v", stats.Nominations)
+	}
+	if _, ok := stats.Nominations["treasurer"]; !ok {
+		t.Fatalf("expected positions without nominations to be included, got %+v", stats.Nominations)
+	}
+	if stats.OTP.Sent != 1+cfg.OTP.RatelimitCount || stats.OTP.Verified != 1 || stats.OTP.Rejected != 1 {
+		t.Fatalf("expected %d sent codes and one verified and rejected code, got %+v", 1+cfg.OTP.RatelimitCount, stats.OTP)
+	}
+	if stats.OTP.RateLimited != 1 || stats.OTP.SendFailed != 0 {
+		t.Fatalf("expected one rate limited code and none that failed to send, got %+v", stats.OTP)
+	}
+	// the member and the admin watching the dashboard
+	if stats.ActiveSessions != 2 {
+		t.Fatalf("expected 2 active sessions, got %d", stats.ActiveSessions)
+	}
+}
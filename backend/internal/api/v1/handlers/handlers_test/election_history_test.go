@@ -0,0 +1,123 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func TestElectionHistory(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	// run last year's election through to the end
+	zid := "z0000000"
+	pastId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+	cookie := cookiePer(t, api, mailer, zid, "John Doe", []string{"president"})
+	for _, state := range []string{"NOMINATIONS_CLOSED", "VOTING_OPEN", "VOTING_CLOSED", "RESULTS", "END"} {
+		if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, state); code != 204 {
+			t.Fatalf("expected 204 No Content, got %d", code)
+		}
+	}
+
+	currentId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+
+	// page through the elections one at a time, newest first
+	var ids []string
+	before := ""
+	for page := 0; ; page++ {
+		if page > 2 {
+			t.Fatal("expected at most 2 pages")
+		}
+		resp := api.Get("/api/v1/elections?limit=1&before="+before, adminCookie)
+		if resp.Code != 200 {
+			t.Fatalf("expected 200 OK, got %d", resp.Code)
+		}
+		body := models.ListElectionsResponseBody{}
+		if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		for _, election := range body.Elections {
+			ids = append(ids, election.ElectionId)
+		}
+		if body.NextBefore == "" {
+			break
+		}
+		before = body.NextBefore
+	}
+	if len(ids) != 2 || ids[0] != currentId || ids[1] != pastId {
+		t.Fatalf("expected [%s %s], got %v", currentId, pastId, ids)
+	}
+
+	resp := api.Get("/api/v1/elections?before=missing", adminCookie)
+	if resp.Code != 422 {
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	resp = api.Get("/api/v1/elections/"+pastId, adminCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	election := models.ElectionDetails{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &election); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if election.State != store.StateEnd || election.EndedAt == nil {
+		t.Fatalf("expected the past election to have ended, got %+v", election)
+	}
+
+	resp = api.Get("/api/v1/elections/00000000-0000-0000-0000-000000000000", adminCookie)
+	if resp.Code != 404 {
+		t.Fatalf("expected 404 Not Found, got %d", resp.Code)
+	}
+	resp = api.Get("/api/v1/elections/missing", adminCookie)
+	if resp.Code != 422 {
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	// who ran last year
+	resp = api.Get("/api/v1/elections/"+pastId+"/nominations", adminCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	nominations := models.GetElectionNominationsResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &nominations); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(nominations.Nominations) != 1 || nominations.Nominations[0].CandidateZID != zid {
+		t.Fatalf("expected %s's nomination, got %+v", zid, nominations.Nominations)
+	}
+
+	// archived results are available to members, unpublished ones aren't
+	resp = api.Get("/api/v1/elections/"+pastId+"/results", cookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	results := store.ElectionResults{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &results); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if results.ElectionID != pastId {
+		t.Fatalf("expected results for %s, got %s", pastId, results.ElectionID)
+	}
+
+	resp = api.Get("/api/v1/elections/"+currentId+"/results", cookie)
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+
+	// members can't list elections
+	resp = api.Get("/api/v1/elections", cookie)
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func TestEmailOutbox(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	zid := "z0000000"
+	_ = createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+
+	// a mailer outage doesn't fail the request, the email is retried later
+	mailer.MockSetError(errors.New("provider unavailable"))
+	resp := api.Post("/api/v1/otp/generate", map[string]any{"zid": zid})
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+	if mailer.MockRetrieveOTP(zid+"@unsw.edu.au") != "" {
+		t.Fatalf("expected no OTP to be sent")
+	}
+
+	listEmails := func(status store.EmailStatus) []store.OutboxEmail {
+		t.Helper()
+		resp := api.Get("/api/v1/emails?status="+string(status), adminCookie)
+		if resp.Code != 200 {
+			t.Fatalf("expected 200 OK, got %d", resp.Code)
+		}
+		if strings.Contains(resp.Body.String(), "payload") {
+			t.Fatalf("expected email payloads to be hidden, got %s", resp.Body.String())
+		}
+		body := models.ListEmailsResponseBody{}
+		if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		return body.Emails
+	}
+
+	pending := listEmails(store.EmailPending)
+	if len(pending) != 1 {
+		t.Fatalf("expected 1 pending email, got %d", len(pending))
+	}
+	if pending[0].Attempts != 1 || pending[0].LastError == nil || *pending[0].LastError != "provider unavailable" {
+		t.Fatalf("expected 1 failed attempt, got %+v", pending[0])
+	}
+	if pending[0].Recipient != zid+"@unsw.edu.au" || pending[0].Kind != store.EmailKindOTP {
+		t.Fatalf("expected an OTP email to %s, got %+v", zid, pending[0])
+	}
+
+	// only dead emails can be retried
+	resp = api.Post("/api/v1/emails/"+pending[0].EmailID+"/retry", adminCookie)
+	if resp.Code != 409 {
+		t.Fatalf("expected 409 Conflict, got %d", resp.Code)
+	}
+	resp = api.Post("/api/v1/emails/00000000-0000-0000-0000-000000000000/retry", adminCookie)
+	if resp.Code != 404 {
+		t.Fatalf("expected 404 Not Found, got %d", resp.Code)
+	}
+
+	// a new code is sent straight away once the mailer recovers
+	mailer.MockSetError(nil)
+	resp = generateOTPSubmit(t, api, mailer, zid)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	if sent := listEmails(store.EmailSent); len(sent) != 1 || sent[0].SentAt == nil {
+		t.Fatalf("expected 1 sent email, got %+v", sent)
+	}
+	if dead := listEmails(store.EmailDead); len(dead) != 0 {
+		t.Fatalf("expected no dead emails, got %d", len(dead))
+	}
+
+	// members can't see the outbox
+	memberCookie := extractCookieHeader(resp.Header())
+	resp = api.Get("/api/v1/emails", memberCookie)
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+}
@@ -0,0 +1,36 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+)
+
+func TestGetJWKS(t *testing.T) {
+	api, _ := NewAPI(t)
+
+	// anyone can fetch the keys, without a session
+	resp := api.Get("/.well-known/jwks.json")
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	if cc := resp.Header().Get("Cache-Control"); cc == "" {
+		t.Fatalf("expected the key set to be cacheable")
+	}
+
+	body := models.JWKS{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(body.Keys) != 1 {
+		t.Fatalf("expected 1 key, got %d: %+v", len(body.Keys), body.Keys)
+	}
+	key := body.Keys[0]
+	if key.Kid != TestingSessionKeyID || key.Kty != "OKP" || key.Crv != "Ed25519" || key.Alg != "EdDSA" || key.Use != "sig" {
+		t.Fatalf("expected the ed25519 signing key, got %+v", key)
+	}
+	if key.X == "" {
+		t.Fatalf("expected the public key to be set")
+	}
+}
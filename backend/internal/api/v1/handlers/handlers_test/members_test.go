@@ -0,0 +1,244 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func uploadMembers(t *testing.T, api humatest.TestAPI, cookie string, electionId string, csv string, dryRun bool) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "members.csv")
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	if _, err := part.Write([]byte(csv)); err != nil {
+		t.Fatalf("failed to write form file: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close multipart writer: %v", err)
+	}
+
+	path := "/api/v1/elections/" + electionId + "/members/upload"
+	if dryRun {
+		path += "?dry_run=true"
+	}
+	return api.Post(path, cookie, "Content-Type: "+writer.FormDataContentType(), body)
+}
+
+func TestUploadMembers(t *testing.T) {
+	cfg := config.Load()
+	api, _ := NewAPI(t)
+
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{"z1111111", "z2222222"})
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	csv := "zID,Name,Email\n" +
+		"z2222222,Jane Roe,jane@example.com\n" +
+		"3333333,John Doe,\n" +
+		"z123,Bad Zid,\n"
+
+	// dry run reports the changes without making them
+	resp := uploadMembers(t, api, adminCookie, electionId, csv, true)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var body models.UploadMembersResponseBody
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !body.DryRun || body.Rows != 3 || body.Imported != 2 || len(body.Members) != 2 {
+		t.Fatalf("unexpected dry run response %+v", body)
+	}
+	if body.Summary.Added != 1 || body.Summary.Removed != 1 || body.Summary.Kept != 1 {
+		t.Fatalf("unexpected dry run summary %+v", body.Summary)
+	}
+	if len(body.Errors) != 1 || body.Errors[0].Row != 4 || body.Errors[0].Message != "invalid zID" {
+		t.Fatalf("unexpected errors %+v", body.Errors)
+	}
+
+	// same file again, nothing should have changed
+	resp = uploadMembers(t, api, adminCookie, electionId, csv, false)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d: %s", resp.Code, resp.Body.String())
+	}
+	body = models.UploadMembersResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if body.DryRun || body.Members != nil {
+		t.Fatalf("unexpected response %+v", body)
+	}
+	if body.Summary.Added != 1 || body.Summary.Removed != 1 || body.Summary.Kept != 1 {
+		t.Fatalf("expected the dry run to leave the member list unchanged, got %+v", body.Summary)
+	}
+
+	// the import replaced the member list
+	resp = uploadMembers(t, api, adminCookie, electionId, csv, true)
+	body = models.UploadMembersResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if body.Summary.Added != 0 || body.Summary.Removed != 0 || body.Summary.Kept != 2 {
+		t.Fatalf("expected the member list to be replaced, got %+v", body.Summary)
+	}
+}
+
+func TestUploadMembersInvalid(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z1111111"
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	// no zID column
+	resp := uploadMembers(t, api, adminCookie, electionId, "Name,Email\nJohn Doe,john@example.com\n", false)
+	if resp.Code != 422 {
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	// no valid rows, the member list is left alone
+	resp = uploadMembers(t, api, adminCookie, electionId, "zID\nz123\n", false)
+	if resp.Code != 422 {
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	resp = uploadMembers(t, api, adminCookie, "missing", "zID\nz1111111\n", false)
+	if resp.Code != 404 {
+		t.Fatalf("expected 404 Not Found, got %d", resp.Code)
+	}
+
+	// members can't upload
+	resp = generateOTPSubmit(t, api, mailer, zid)
+	memberCookie := extractCookieHeader(resp.Header())
+	resp = uploadMembers(t, api, memberCookie, electionId, "zID\nz1111111\n", false)
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+}
+
+func TestMembersAddRemoveList(t *testing.T) {
+	cfg := config.Load()
+	api, _ := NewAPI(t)
+
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{"z1111111", "z2222222", "z3333333"})
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+	membersPath := "/api/v1/elections/" + electionId + "/members"
+
+	resp := api.Post(membersPath, adminCookie, map[string]any{
+		"zid":   "z4444444",
+		"name":  "Door Signup",
+		"email": "door@example.com",
+	})
+	if resp.Code != 201 {
+		t.Fatalf("expected 201 Created, got %d: %s", resp.Code, resp.Body.String())
+	}
+	added := store.ListedMember{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &added); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if added.Zid != "z4444444" || added.AddedBy == nil || *added.AddedBy != "z1234567" || added.AddedAt == nil {
+		t.Fatalf("expected the member to be recorded against the admin, got %+v", added)
+	}
+
+	resp = api.Post(membersPath, adminCookie, map[string]any{"zid": "z4444444"})
+	if resp.Code != 409 {
+		t.Fatalf("expected 409 Conflict, got %d", resp.Code)
+	}
+	// normalised like the CSV import, so this is the same member
+	resp = api.Post(membersPath, adminCookie, map[string]any{"zid": "4444444"})
+	if resp.Code != 409 {
+		t.Fatalf("expected 409 Conflict, got %d", resp.Code)
+	}
+	resp = api.Post(membersPath, adminCookie, map[string]any{"zid": "z44444"})
+	if resp.Code != 422 {
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	// normalised like adding, so this removes z2222222
+	resp = api.Delete(membersPath+"/2222222", adminCookie)
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+	resp = api.Delete(membersPath+"/z2222222", adminCookie)
+	if resp.Code != 404 {
+		t.Fatalf("expected 404 Not Found, got %d", resp.Code)
+	}
+
+	// page through the list two at a time
+	var zids []string
+	after := ""
+	for page := 0; ; page++ {
+		if page > 2 {
+			t.Fatal("expected at most 2 pages")
+		}
+		resp = api.Get(membersPath+"?limit=2&after="+after, adminCookie)
+		if resp.Code != 200 {
+			t.Fatalf("expected 200 OK, got %d", resp.Code)
+		}
+		body := models.ListMembersResponseBody{}
+		if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		for _, member := range body.Members {
+			zids = append(zids, member.Zid)
+		}
+		if body.NextAfter == "" {
+			break
+		}
+		after = body.NextAfter
+	}
+	if !reflect.DeepEqual(zids, []string{"z1111111", "z3333333", "z4444444"}) {
+		t.Fatalf("unexpected members %v", zids)
+	}
+
+	resp = api.Get(membersPath+"?search=DOOR", adminCookie)
+	body := models.ListMembersResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(body.Members) != 1 || body.Members[0].Zid != "z4444444" {
+		t.Fatalf("expected to find the door signup, got %+v", body.Members)
+	}
+
+	for search, expected := range map[string]int{"": 3, "z333": 1, "example.com": 1, "nobody": 0} {
+		resp = api.Get(membersPath+"/count?search="+search, adminCookie)
+		if resp.Code != 200 {
+			t.Fatalf("expected 200 OK, got %d", resp.Code)
+		}
+		count := models.CountMembersResponseBody{}
+		if err := json.Unmarshal(resp.Body.Bytes(), &count); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if count.Count != expected {
+			t.Fatalf("expected %d members matching %q, got %d", expected, search, count.Count)
+		}
+	}
+
+	// both changes are recorded against the admin
+	resp = api.Get("/api/v1/audit", adminCookie)
+	audit := models.GetAuditLogResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &audit); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	var changes []store.AuditEntry
+	for _, entry := range audit.Entries {
+		if entry.Action == store.AuditMemberAdded || entry.Action == store.AuditMemberRemoved {
+			changes = append(changes, entry)
+		}
+	}
+	if len(changes) != 2 || changes[0].Actor != "z1234567" || changes[1].Actor != "z1234567" {
+		t.Fatalf("expected both changes to be recorded against the admin, got %+v", changes)
+	}
+}
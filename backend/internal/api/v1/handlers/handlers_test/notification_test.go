@@ -0,0 +1,128 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func TestNotifications(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{"z0000000", "z0000001"})
+
+	// notifications are left for the outbox worker, so they're still pending here
+	pendingNotifications := func() map[string][]store.OutboxEmail {
+		t.Helper()
+		resp := api.Get("/api/v1/emails?status=PENDING", adminCookie)
+		if resp.Code != 200 {
+			t.Fatalf("expected 200 OK, got %d", resp.Code)
+		}
+		body := models.ListEmailsResponseBody{}
+		if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+
+		byTemplate := map[string][]store.OutboxEmail{}
+		for _, email := range body.Emails {
+			if email.Kind != store.EmailKindNotification {
+				t.Fatalf("expected only notifications to be pending, got %+v", email)
+			}
+			template, _, _ := strings.Cut(email.IdempotencyKey, ":")
+			byTemplate[template] = append(byTemplate[template], email)
+		}
+		return byTemplate
+	}
+
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+	cookie := cookiePer(t, api, mailer, "z0000000", "John Doe", []string{"president"})
+	_ = cookiePer(t, api, mailer, "z0000000", "John Doe", []string{"president", "secretary"})
+
+	resp := api.Delete("/api/v1/nomination", cookie)
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+	// withdrawing again doesn't notify, there's nothing to withdraw
+	resp = api.Delete("/api/v1/nomination", cookie)
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+
+	pending := pendingNotifications()
+	for template, count := range map[string]int{"nomination_received": 1, "nomination_updated": 1, "nomination_withdrawn": 1} {
+		if len(pending[template]) != count {
+			t.Fatalf("expected %d %s notification, got %d", count, template, len(pending[template]))
+		}
+		if pending[template][0].Recipient != "john@example.com" {
+			t.Fatalf("expected %s to be sent to the contact email, got %s", template, pending[template][0].Recipient)
+		}
+	}
+
+	nominationId := func() string {
+		_ = cookiePer(t, api, mailer, "z0000000", "John Doe", []string{"president"})
+		resp := api.Get("/api/v1/nomination", cookie)
+		nomination := store.Nomination{}
+		_ = json.Unmarshal(resp.Body.Bytes(), &nomination)
+		return nomination.NominationId
+	}()
+
+	// every member is told voting has opened
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_CLOSED"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "VOTING_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+	// staying in the same state doesn't notify again
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "VOTING_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+
+	votingOpen := pendingNotifications()["voting_open"]
+	if len(votingOpen) != 2 {
+		t.Fatalf("expected 2 voting_open notifications, got %d", len(votingOpen))
+	}
+	for _, email := range votingOpen {
+		if !strings.Contains(email.IdempotencyKey, electionId) {
+			t.Fatalf("expected the idempotency key to include the election, got %s", email.IdempotencyKey)
+		}
+	}
+
+	// voters get their receipt
+	voterCookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, "z0000001").Header())
+	resp = api.Put("/api/v1/vote", voterCookie, map[string]any{
+		"positions": map[string][]string{"president": {nominationId}},
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	receipt := models.VoteReceipt{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &receipt)
+
+	voteRecorded := pendingNotifications()["vote_recorded"]
+	if len(voteRecorded) != 1 || voteRecorded[0].Recipient != "z0000001@unsw.edu.au" {
+		t.Fatalf("expected 1 vote_recorded notification to z0000001, got %+v", voteRecorded)
+	}
+	if !strings.HasSuffix(voteRecorded[0].IdempotencyKey, receipt.ReceiptHash) {
+		t.Fatalf("expected the idempotency key to include the receipt, got %s", voteRecorded[0].IdempotencyKey)
+	}
+
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "VOTING_CLOSED"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "RESULTS"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+	if resultsPublished := pendingNotifications()["results_published"]; len(resultsPublished) != 2 {
+		t.Fatalf("expected 2 results_published notifications, got %d", len(resultsPublished))
+	}
+}
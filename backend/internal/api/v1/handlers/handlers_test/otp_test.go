@@ -2,11 +2,15 @@ package handlers_test
 
 import (
 	"encoding/json"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/danielgtaylor/huma/v2/humatest"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
 	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/mailer/mock_mailer"
 )
 
 func TestOTPConsumeMember(t *testing.T) {
@@ -58,6 +62,17 @@ func TestOTPConsumeNonMember(t *testing.T) {
 	}
 }
 
+func TestOTPInvalidZid(t *testing.T) {
+	api, _ := NewAPI(t)
+
+	for _, zid := range []string{"5555555", "Z5555555", "z555555", "someone@example.com"} {
+		resp := api.Post("/api/v1/otp/generate", map[string]any{"zid": zid})
+		if resp.Code != 422 {
+			t.Fatalf("expected 422 Unprocessable Entity for %q, got %d", zid, resp.Code)
+		}
+	}
+}
+
 func TestOTPAdmins(t *testing.T) {
 	t.Setenv("ADMIN_ZIDS", "z0000000,z0000001,z0000002")
 
@@ -75,3 +90,133 @@ func TestOTPAdmins(t *testing.T) {
 	jwt := extractJWT(res.Header)
 	_ = createElection(t, api, cfg.JWT, jwt, []string{})
 }
+
+// generates an OTP for zid, returning the token from the emailed link
+func generateOTPLinkToken(t *testing.T, api humatest.TestAPI, mailer *mock_mailer.MockMailer, zid string) string {
+	resp := api.Post("/api/v1/otp/generate", map[string]any{
+		"zid": zid,
+	})
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 OK, got %d", resp.Code)
+	}
+
+	link, err := url.Parse(mailer.MockRetrieveLink(zid + "@unsw.edu.au"))
+	if err != nil {
+		t.Fatalf("expected link, got error %v", err)
+	}
+	token := link.Query().Get("token")
+	if token == "" {
+		t.Fatalf("expected link with a token, got %q", link)
+	}
+	return token
+}
+
+func TestOTPLink(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	_ = createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{
+		zid,
+	})
+
+	token := generateOTPLinkToken(t, api, mailer, zid)
+	resp := api.Post("/api/v1/otp/link", map[string]any{
+		"token": token,
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	body := models.SubmitOTPResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected body, got error %v", err)
+	}
+	if body.Zid != zid {
+		t.Fatalf("expected zid %s, got %s", zid, body.Zid)
+	}
+	if resp.Result().Header.Get("Set-Cookie") == "" {
+		t.Fatalf("expected Set-Cookie header, got none")
+	}
+
+	// links are single use
+	resp = api.Post("/api/v1/otp/link", map[string]any{
+		"token": token,
+	})
+	if resp.Code != 400 {
+		t.Fatalf("expected 400 Bad Request reusing a link, got %d", resp.Code)
+	}
+}
+
+func TestOTPLinkSharesCode(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	_ = createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{
+		zid,
+	})
+
+	// using the code uses up the link sent with it
+	token := generateOTPLinkToken(t, api, mailer, zid)
+	resp := api.Post("/api/v1/otp/submit", map[string]any{
+		"zid": zid,
+		"otp": mailer.MockRetrieveOTP(zid + "@unsw.edu.au"),
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	resp = api.Post("/api/v1/otp/link", map[string]any{
+		"token": token,
+	})
+	if resp.Code != 400 {
+		t.Fatalf("expected 400 Bad Request using a link after its code, got %d", resp.Code)
+	}
+
+	// a new code replaces the previous link
+	previous := generateOTPLinkToken(t, api, mailer, zid)
+	current := generateOTPLinkToken(t, api, mailer, zid)
+	resp = api.Post("/api/v1/otp/link", map[string]any{
+		"token": previous,
+	})
+	if resp.Code != 400 {
+		t.Fatalf("expected 400 Bad Request using a replaced link, got %d", resp.Code)
+	}
+	resp = api.Post("/api/v1/otp/link", map[string]any{
+		"token": current,
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+}
+
+func TestOTPLinkTampered(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	other := "z0000001"
+	_ = createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{
+		zid, other,
+	})
+
+	token := generateOTPLinkToken(t, api, mailer, zid)
+	_ = generateOTPLinkToken(t, api, mailer, other)
+
+	// a signature for one zid can't sign in another
+	_, signature, _ := strings.Cut(token, ".")
+	for _, tampered := range []string{other + "." + signature, zid + ".AAAA", "garbage"} {
+		resp := api.Post("/api/v1/otp/link", map[string]any{
+			"token": tampered,
+		})
+		if resp.Code != 400 {
+			t.Fatalf("expected 400 Bad Request for %q, got %d", tampered, resp.Code)
+		}
+	}
+
+	resp := api.Post("/api/v1/otp/link", map[string]any{
+		"token": token,
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK after tampered attempts, got %d", resp.Code)
+	}
+}
@@ -0,0 +1,178 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func TestPositionsDefault(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{
+		zid,
+	})
+
+	res := generateOTPSubmit(t, api, mailer, zid).Result()
+	cookie := extractCookieHeader(res.Header)
+
+	resp := api.Get("/api/v1/positions", cookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	body := models.GetPositionsResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if body.ElectionID != electionId {
+		t.Fatalf("expected election %q, got %q", electionId, body.ElectionID)
+	}
+	if !reflect.DeepEqual(store.DefaultPositions(cfg.Election.PositionSeats), body.Positions) {
+		t.Fatalf("expected positions %+v, got %+v", store.DefaultPositions(cfg.Election.PositionSeats), body.Positions)
+	}
+}
+
+func TestPositionsSet(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{
+		zid,
+	})
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	positions := []store.Position{
+		{Key: "chair", DisplayName: "Chair", Seats: 1, Description: "Chairs the meeting."},
+		{Key: "general_executive", DisplayName: "General Executive", Seats: 3},
+	}
+
+	resp := api.Put("/api/v1/elections/"+electionId+"/positions", adminCookie, map[string]any{
+		"positions": positions,
+	})
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+
+	resp = api.Put("/api/v1/elections/"+electionId+"/positions", adminCookie, map[string]any{
+		"positions": []store.Position{positions[0], positions[0]},
+	})
+	if resp.Code != 422 {
+		// duplicate key
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	resp = api.Put("/api/v1/elections/"+electionId+"/positions", adminCookie, map[string]any{
+		"positions": []store.Position{{Key: "Not A Key", DisplayName: "Bogus", Seats: 1}},
+	})
+	if resp.Code != 422 {
+		// invalid key
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	res := generateOTPSubmit(t, api, mailer, zid).Result()
+	cookie := extractCookieHeader(res.Header)
+
+	resp = api.Get("/api/v1/positions", cookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	body := models.GetPositionsResponseBody{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &body)
+	if !reflect.DeepEqual(positions, body.Positions) {
+		t.Fatalf("expected positions %+v, got %+v", positions, body.Positions)
+	}
+
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+
+	// positions are fixed once nominations open
+	resp = api.Put("/api/v1/elections/"+electionId+"/positions", adminCookie, map[string]any{
+		"positions": positions,
+	})
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+
+	nomination := map[string]any{
+		"candidate_name":      "John Doe",
+		"contact_email":       "john@example.com",
+		"discord_username":    "johndoe#1234",
+		"executive_roles":     []string{"president"},
+		"candidate_statement": "Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50",
+	}
+	resp = api.Put("/api/v1/nomination", cookie, nomination)
+	if resp.Code != 422 {
+		// president isn't a position in this election
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	nomination["executive_roles"] = []string{"general_executive"}
+	resp = api.Put("/api/v1/nomination", cookie, nomination)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	resp = api.Get("/api/v1/ballot", cookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	ballot := models.PublicBallot{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &ballot)
+	if !reflect.DeepEqual(positions, ballot.Positions) {
+		t.Fatalf("expected positions %+v, got %+v", positions, ballot.Positions)
+	}
+	if len(ballot.Candidates["chair"]) != 0 || len(ballot.Candidates["general_executive"]) != 1 {
+		t.Fatalf("expected no candidates for chair and one for general_executive, got %+v", ballot.Candidates)
+	}
+}
+
+func TestPositionsCreateElection(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+	positions := []store.Position{
+		{Key: "delegate", DisplayName: "Delegate", Seats: 2},
+	}
+
+	resp := api.Post("/api/v1/elections", adminCookie, map[string]any{
+		"name":      "By-election",
+		"positions": positions,
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	electionResp := models.CreateElectionResponseBody{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &electionResp)
+
+	zid := "z0000000"
+	resp = api.Put("/api/v1/elections/"+electionResp.ElectionId+"/members", adminCookie, map[string]any{
+		"zids": []string{zid},
+	})
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+
+	res := generateOTPSubmit(t, api, mailer, zid).Result()
+	cookie := extractCookieHeader(res.Header)
+
+	resp = api.Get("/api/v1/positions", cookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	body := models.GetPositionsResponseBody{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &body)
+	if !reflect.DeepEqual(positions, body.Positions) {
+		t.Fatalf("expected positions %+v, got %+v", positions, body.Positions)
+	}
+}
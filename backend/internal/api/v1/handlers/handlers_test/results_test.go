@@ -0,0 +1,263 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/receipt"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func TestResultsGet(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	_ = createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{
+		"z0000000",
+		"z0000001",
+		"z0000002",
+		"z0000003",
+		"z0000004",
+	})
+
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+	cookie0 := cookiePer(t, api, mailer, "z0000000", "John Doe", []string{"president", "secretary"})
+	cookie1 := cookiePer(t, api, mailer, "z0000001", "Joe Blow", []string{"president"})
+	cookie2 := cookiePer(t, api, mailer, "z0000002", "Jane Roe", []string{"president"})
+
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_CLOSED"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "VOTING_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+
+	resp := api.Get("/api/v1/ballot", cookie0)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	ballot := models.PublicBallot{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &ballot)
+
+	nominationIds := map[string]string{}
+	for _, nom := range ballot.Candidates["president"] {
+		nominationIds[nom.CandidateName] = nom.NominationId
+	}
+	john, joe, jane := nominationIds["John Doe"], nominationIds["Joe Blow"], nominationIds["Jane Roe"]
+
+	res3 := generateOTPSubmit(t, api, mailer, "z0000003").Result()
+	cookie3 := extractCookieHeader(res3.Header)
+	res4 := generateOTPSubmit(t, api, mailer, "z0000004").Result()
+	cookie4 := extractCookieHeader(res4.Header)
+
+	// first preferences for president are tied between John and Joe, until
+	// Jane is eliminated and her ballot transfers to Joe
+	votes := map[string]map[string][]string{
+		cookie0: {"president": {john}, "secretary": {john}},
+		cookie1: {"president": {joe}},
+		cookie2: {"president": {jane, joe}},
+		cookie3: {"president": {john}},
+		cookie4: {"president": {joe, john}},
+	}
+	receipts := []models.VoteReceipt{}
+	for cookie, positions := range votes {
+		resp = api.Put("/api/v1/vote", cookie, map[string]any{
+			"positions": positions,
+		})
+		if resp.Code != 200 {
+			t.Fatalf("expected 200 OK, got %d", resp.Code)
+		}
+		voteReceipt := models.VoteReceipt{}
+		_ = json.Unmarshal(resp.Body.Bytes(), &voteReceipt)
+		receipts = append(receipts, voteReceipt)
+	}
+
+	// the bulletin isn't available until results are published
+	resp = api.Get("/api/v1/results/bulletin")
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+
+	// results aren't available until they are published
+	resp = api.Get("/api/v1/results", cookie0)
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "VOTING_CLOSED"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "RESULTS"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+
+	resp = api.Get("/api/v1/results", cookie0)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	results := store.ElectionResults{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &results); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if results.Turnout.BallotsCast != 5 || results.Turnout.EligibleVoters != 5 {
+		t.Fatalf("expected turnout of 5/5, got %d/%d", results.Turnout.BallotsCast, results.Turnout.EligibleVoters)
+	}
+
+	expectedWinners := map[string]string{
+		"president": joe,
+		"secretary": john,
+	}
+	expectedRounds := map[string]int{
+		"president": 2,
+		"secretary": 1,
+	}
+	if len(results.Positions) != 6 {
+		t.Fatalf("expected all 6 positions in results, got %d", len(results.Positions))
+	}
+	for _, position := range results.Positions {
+		expected, ok := expectedWinners[position.Position]
+		if !ok {
+			// nobody ran for the remaining positions
+			if len(position.Winners) != 0 {
+				t.Fatalf("expected no winners for %q, got %v", position.Position, position.Winners)
+			}
+			continue
+		}
+		if len(position.Winners) != 1 || position.Winners[0] != expected {
+			t.Fatalf("expected winner %q for %q, got %v", expected, position.Position, position.Winners)
+		}
+		if len(position.Rounds) != expectedRounds[position.Position] {
+			t.Fatalf("expected %d rounds for %q, got %d", expectedRounds[position.Position], position.Position, len(position.Rounds))
+		}
+		delete(expectedWinners, position.Position)
+	}
+	if len(expectedWinners) != 0 {
+		t.Fatalf("not all expected positions found, missing: %v", expectedWinners)
+	}
+
+	resp = api.Get("/api/v1/results/bulletin")
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	bulletin := models.Bulletin{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &bulletin)
+	if len(bulletin.Ballots) != 5 {
+		t.Fatalf("expected 5 ballots on the bulletin, got %d", len(bulletin.Ballots))
+	}
+	listed := make(map[string]struct{}, len(bulletin.Ballots))
+	for _, ballot := range bulletin.Ballots {
+		listed[ballot.ReceiptHash] = struct{}{}
+	}
+	for _, voteReceipt := range receipts {
+		if _, ok := listed[voteReceipt.ReceiptHash]; !ok {
+			t.Fatalf("expected receipt %q on the bulletin", voteReceipt.ReceiptHash)
+		}
+	}
+}
+
+func TestResultsBulletin(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+	for _, state := range []string{"NOMINATIONS_OPEN", "NOMINATIONS_CLOSED", "VOTING_OPEN"} {
+		if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, state); code != 204 {
+			t.Fatalf("expected 204 No Content, got %d", code)
+		}
+	}
+
+	cookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Result().Header)
+	positions := map[string][]string{"president": {"RON"}}
+	resp := api.Put("/api/v1/vote", cookie, map[string]any{
+		"positions": positions,
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	voteReceipt := models.VoteReceipt{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &voteReceipt)
+
+	// the receipt is signed by the published key, and hashes the submitted ballot
+	resp = api.Get("/api/v1/receipts/key")
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	key := models.ReceiptKey{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &key)
+
+	publicKey, _ := hex.DecodeString(key.PublicKey)
+	hash, _ := hex.DecodeString(voteReceipt.ReceiptHash)
+	nonce, _ := hex.DecodeString(voteReceipt.Nonce)
+	signature, _ := hex.DecodeString(voteReceipt.Signature)
+	if !receipt.Verify(publicKey, receipt.Receipt{ElectionID: voteReceipt.ElectionID, Hash: hash, Signature: signature}) {
+		t.Fatalf("expected the receipt signature to verify")
+	}
+	if expected, _ := receipt.Hash(nonce, positions); !bytes.Equal(expected, hash) {
+		t.Fatalf("expected the receipt hash to match the submitted ballot")
+	}
+
+	// the election's key is the one its receipt was signed with
+	resp = api.Get("/api/v1/elections/" + electionId + "/receipts/key")
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	electionKey := models.ReceiptKey{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &electionKey)
+	if electionKey.PublicKey != key.PublicKey {
+		t.Fatalf("expected election public key %q, got %q", key.PublicKey, electionKey.PublicKey)
+	}
+
+	resp = api.Get("/api/v1/elections/" + electionId + "/bulletin")
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden before results are published, got %d", resp.Code)
+	}
+
+	for _, state := range []string{"VOTING_CLOSED", "RESULTS"} {
+		if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, state); code != 204 {
+			t.Fatalf("expected 204 No Content, got %d", code)
+		}
+	}
+
+	// public, no session needed
+	resp = api.Get("/api/v1/results/bulletin")
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	bulletin := models.Bulletin{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &bulletin)
+	if bulletin.PublicKey != key.PublicKey {
+		t.Fatalf("expected bulletin public key %q, got %q", key.PublicKey, bulletin.PublicKey)
+	}
+	if len(bulletin.Ballots) != 1 || bulletin.Ballots[0].ReceiptHash != voteReceipt.ReceiptHash || !reflect.DeepEqual(bulletin.Ballots[0].Positions, positions) {
+		t.Fatalf("expected the ballot on the bulletin unchanged, got %+v", bulletin.Ballots)
+	}
+	// still served by ID once the election is no longer current
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "END"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+	_ = createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+
+	resp = api.Get("/api/v1/elections/" + electionId + "/bulletin")
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	electionBulletin := models.Bulletin{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &electionBulletin)
+	if !reflect.DeepEqual(electionBulletin, bulletin) {
+		t.Fatalf("expected the same bulletin by ID, got %+v", electionBulletin)
+	}
+
+	resp = api.Get("/api/v1/elections/00000000-0000-0000-0000-000000000000/bulletin")
+	if resp.Code != 404 {
+		t.Fatalf("expected 404 Not Found, got %d", resp.Code)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func TestElectionSchedule(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	opensAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	closesAt := opensAt.Add(7 * 24 * time.Hour)
+
+	resp := api.Put("/api/v1/elections/"+electionId+"/schedule", adminCookie, map[string]any{
+		"transitions": []store.ScheduleEntry{
+			{State: store.StateNominationsClosed, RunAt: closesAt},
+			{State: store.StateNominationsOpen, RunAt: opensAt},
+		},
+	})
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+
+	resp = api.Get("/api/v1/elections/"+electionId+"/schedule", adminCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	body := models.GetElectionScheduleResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(body.Transitions) != 2 {
+		t.Fatalf("expected 2 transitions, got %+v", body.Transitions)
+	}
+	// ordered by run time
+	if body.Transitions[0].State != store.StateNominationsOpen || !body.Transitions[0].RunAt.Equal(opensAt) {
+		t.Fatalf("expected nominations to open first at %v, got %+v", opensAt, body.Transitions[0])
+	}
+	if body.Transitions[0].CreatedBy != "z1234567" {
+		t.Fatalf("expected the transition to be scheduled by z1234567, got %q", body.Transitions[0].CreatedBy)
+	}
+	if body.Transitions[0].RanAt != nil {
+		t.Fatalf("expected the transition to be pending, got %+v", body.Transitions[0])
+	}
+
+	// replaces the pending transitions
+	resp = api.Put("/api/v1/elections/"+electionId+"/schedule", adminCookie, map[string]any{
+		"transitions": []store.ScheduleEntry{
+			{State: store.StateNominationsOpen, RunAt: opensAt},
+		},
+	})
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+	resp = api.Get("/api/v1/elections/"+electionId+"/schedule", adminCookie)
+	body = models.GetElectionScheduleResponseBody{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &body)
+	if len(body.Transitions) != 1 {
+		t.Fatalf("expected 1 transition, got %+v", body.Transitions)
+	}
+
+	resp = api.Put("/api/v1/elections/"+electionId+"/schedule", adminCookie, map[string]any{
+		"transitions": []store.ScheduleEntry{
+			{State: store.StateNominationsOpen, RunAt: opensAt},
+			{State: store.StateNominationsOpen, RunAt: closesAt},
+		},
+	})
+	if resp.Code != 422 {
+		// state scheduled twice
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	resp = api.Put("/api/v1/elections/"+electionId+"/schedule", adminCookie, map[string]any{
+		"transitions": []map[string]any{
+			{"state": "NOT_A_STATE", "run_at": opensAt},
+		},
+	})
+	if resp.Code != 422 {
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	resp = api.Get("/api/v1/elections/00000000-0000-0000-0000-000000000000/schedule", adminCookie)
+	if resp.Code != 404 {
+		t.Fatalf("expected 404 Not Found, got %d", resp.Code)
+	}
+
+	// members can't schedule transitions
+	cookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Result().Header)
+	resp = api.Put("/api/v1/elections/"+electionId+"/schedule", cookie, map[string]any{
+		"transitions": []store.ScheduleEntry{},
+	})
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+}
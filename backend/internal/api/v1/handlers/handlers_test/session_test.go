@@ -0,0 +1,193 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+)
+
+func TestLogout(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	_ = createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+
+	cookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Header())
+	otherCookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Header())
+
+	resp := api.Get("/api/v1/state", cookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	resp = api.Post("/api/v1/logout", cookie)
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+	if sc := resp.Header().Get("Set-Cookie"); !strings.HasPrefix(sc, cfg.JWT.CookieName+"=;") {
+		t.Fatalf("expected the session cookie to be cleared, got %q", sc)
+	}
+
+	// the token hasn't expired, but its session has ended
+	resp = api.Get("/api/v1/state", cookie)
+	if resp.Code != 401 {
+		t.Fatalf("expected 401 Unauthorized, got %d", resp.Code)
+	}
+
+	// other sessions are unaffected
+	resp = api.Get("/api/v1/state", otherCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid, other := "z0000000", "z0000001"
+	_ = createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid, other})
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	cookies := []string{
+		extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Header()),
+		extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Header()),
+	}
+	otherCookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, other).Header())
+
+	// members can't revoke sessions
+	resp := api.Delete("/api/v1/sessions/"+other, cookies[0])
+	if resp.Code != 403 {
+		t.Fatalf("expected 403 Forbidden, got %d", resp.Code)
+	}
+
+	resp = api.Delete("/api/v1/sessions/"+zid, adminCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	body := models.RevokeSessionsResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if body.Revoked != 2 {
+		t.Fatalf("expected 2 sessions to be revoked, got %d", body.Revoked)
+	}
+
+	for _, cookie := range cookies {
+		resp = api.Get("/api/v1/state", cookie)
+		if resp.Code != 401 {
+			t.Fatalf("expected 401 Unauthorized, got %d", resp.Code)
+		}
+	}
+	resp = api.Get("/api/v1/state", otherCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	// they can sign in again
+	cookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Header())
+	resp = api.Get("/api/v1/state", cookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	// normalised like signing in, so this revokes z0000000's session
+	resp = api.Delete("/api/v1/sessions/0000000", adminCookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	body = models.RevokeSessionsResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if body.Revoked != 1 {
+		t.Fatalf("expected 1 session to be revoked, got %d", body.Revoked)
+	}
+	resp = api.Get("/api/v1/state", cookie)
+	if resp.Code != 401 {
+		t.Fatalf("expected 401 Unauthorized, got %d", resp.Code)
+	}
+}
+
+func TestRefreshSession(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	_ = createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+
+	submitResp := generateOTPSubmit(t, api, mailer, zid)
+	submitBody := models.SubmitOTPResponseBody{}
+	if err := json.Unmarshal(submitResp.Body.Bytes(), &submitBody); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	cookie := extractCookieHeader(submitResp.Header())
+
+	resp := api.Post("/api/v1/refresh", cookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	body := models.RefreshSessionResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if body.Zid != zid || body.IsAdmin || body.Role != nil {
+		t.Fatalf("expected a member session for %q, got %+v", zid, body)
+	}
+	if !body.Expiry.After(submitBody.Expiry) {
+		t.Fatalf("expected the expiry to move past %s, got %s", submitBody.Expiry, body.Expiry)
+	}
+	if !body.MaxExpiry.After(body.Expiry) {
+		t.Fatalf("expected the max expiry %s to be after the expiry %s", body.MaxExpiry, body.Expiry)
+	}
+
+	// both tokens belong to the same session, so logging out with the new one ends the old one
+	refreshed := extractCookieHeader(resp.Header())
+	resp = api.Get("/api/v1/state", refreshed)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	resp = api.Post("/api/v1/logout", refreshed)
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+	resp = api.Post("/api/v1/refresh", cookie)
+	if resp.Code != 401 {
+		t.Fatalf("expected 401 Unauthorized, got %d", resp.Code)
+	}
+}
+
+func TestRefreshSessionMaxLifetime(t *testing.T) {
+	cfg := config.Load()
+
+	// sessions are created as if they started almost MaxLifetime ago
+	remaining := 10 * time.Minute
+	api, mailer := NewAPIWithNowProvider(t, func() time.Time {
+		return time.Now().Add(remaining - cfg.JWT.MaxLifetime)
+	})
+
+	zid := "z0000000"
+	_ = createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+	cookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Header())
+
+	resp := api.Post("/api/v1/refresh", cookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	body := models.RefreshSessionResponseBody{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !body.Expiry.Equal(body.MaxExpiry) {
+		t.Fatalf("expected the expiry to be capped at %s, got %s", body.MaxExpiry, body.Expiry)
+	}
+	if until := time.Until(body.Expiry); until > remaining {
+		t.Fatalf("expected the session to expire within %s, got %s", remaining, until)
+	}
+}
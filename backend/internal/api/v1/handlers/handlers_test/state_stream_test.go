@@ -0,0 +1,114 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+)
+
+// decodes the data lines of an SSE response body
+func stateChangeEvents(t *testing.T, body string) []models.StateChangeEvent {
+	t.Helper()
+
+	var events []models.StateChangeEvent
+	for _, line := range strings.Split(body, "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		event := models.StateChangeEvent{}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("failed to unmarshal event %q: %v", data, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestStateStream(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	electionId := createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+	cookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Result().Header)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	go func() {
+		// give the stream time to send the current state
+		time.Sleep(500 * time.Millisecond)
+		transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_OPEN")
+		transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_CLOSED")
+
+		// give the notifications time to arrive
+		time.Sleep(500 * time.Millisecond)
+		cancel()
+	}()
+
+	resp := api.GetCtx(ctx, "/api/v1/state/stream", cookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	expected := []string{"CLOSED", "NOMINATIONS_OPEN", "NOMINATIONS_CLOSED"}
+	events := stateChangeEvents(t, resp.Body.String())
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+	for i, event := range events {
+		if event.NewState != expected[i] || event.ElectionId != electionId {
+			t.Fatalf("expected event %d to be %s for election %q, got %+v", i, expected[i], electionId, event)
+		}
+	}
+}
+
+func TestStateStreamSessionRevoked(t *testing.T) {
+	t.Setenv("SESSION_CHECK_INTERVAL_SECONDS", "1")
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	zid := "z0000000"
+	_ = createElection(t, api, cfg.JWT, TestingDummyJWTAdmin, []string{zid})
+	cookie := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid).Result().Header)
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	go func() {
+		// give the stream time to send the current state
+		time.Sleep(500 * time.Millisecond)
+		api.Delete("/api/v1/sessions/"+zid, adminCookie)
+		transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_OPEN")
+	}()
+
+	start := time.Now()
+	resp := api.GetCtx(ctx, "/api/v1/state/stream", cookie)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	// the stream is closed rather than left open until the client disconnects, without
+	// sending changes after the session was revoked
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("expected the stream to close once the session was revoked, took %s", elapsed)
+	}
+	events := stateChangeEvents(t, resp.Body.String())
+	if len(events) != 1 || events[0].NewState != "CLOSED" {
+		t.Fatalf("expected only the state before the session was revoked, got %+v", events)
+	}
+}
+
+func TestStateStreamUnauthenticated(t *testing.T) {
+	api, _ := NewAPI(t)
+
+	resp := api.Get("/api/v1/state/stream")
+	if resp.Code != 401 {
+		t.Fatalf("expected 401 Unauthorized, got %d", resp.Code)
+	}
+}
@@ -2,11 +2,14 @@ package handlers_test
 
 import (
 	"encoding/json"
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
 	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
 )
 
 func TestVoteSubmit(t *testing.T) {
@@ -60,23 +63,25 @@ func TestVoteSubmit(t *testing.T) {
 	}
 
 	resp = api.Put("/api/v1/vote", cookie1, map[string]any{
-		"positions": map[string]string{
-			"president": nominationId0,
-			"secretary": nominationId0,
+		"positions": map[string][]string{
+			"president": {nominationId0},
+			"secretary": {nominationId0},
 		},
 	})
-	if resp.Code != 204 {
-		t.Fatalf("expected 204 OK, got %d", resp.Code)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
 	}
+	receipt0 := models.VoteReceipt{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &receipt0)
 
 	// test the time not being updated
 	now1 := time.Now().Truncate(time.Second)
 	*nowProvider = func() time.Time { return now1 }
 
 	resp = api.Put("/api/v1/vote", cookie1, map[string]any{
-		"positions": map[string]string{
-			"president": nominationId0,
-			"bogus":     nominationId0,
+		"positions": map[string][]string{
+			"president": {nominationId0},
+			"bogus":     {nominationId0},
 		},
 	})
 	// HTTP/1.1 422 Unprocessable Entity
@@ -86,9 +91,9 @@ func TestVoteSubmit(t *testing.T) {
 	}
 
 	resp = api.Put("/api/v1/vote", cookie1, map[string]any{
-		"positions": map[string]string{
-			"president":         nominationId0,
-			"grievance_officer": nominationId0,
+		"positions": map[string][]string{
+			"president":         {nominationId0},
+			"grievance_officer": {nominationId0},
 		},
 	})
 	// HTTP/1.1 400 Bad Request
@@ -97,6 +102,39 @@ func TestVoteSubmit(t *testing.T) {
 		t.Fatalf("expected 400 Bad Request, got %d", resp.Code)
 	}
 
+	resp = api.Put("/api/v1/vote", cookie1, map[string]any{
+		"positions": map[string][]string{
+			"president": {nominationId0, nominationId0},
+		},
+	})
+	// HTTP/1.1 422 Unprocessable Entity
+	if resp.Code != 422 {
+		// ranked the same candidate twice
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	resp = api.Put("/api/v1/vote", cookie1, map[string]any{
+		"positions": map[string][]string{
+			"president": {},
+		},
+	})
+	// HTTP/1.1 422 Unprocessable Entity
+	if resp.Code != 422 {
+		// no preferences
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
+	resp = api.Put("/api/v1/vote", cookie1, map[string]any{
+		"positions": map[string][]string{
+			"president": {"ABSTAIN", nominationId0},
+		},
+	})
+	// HTTP/1.1 422 Unprocessable Entity
+	if resp.Code != 422 {
+		// abstained and ranked a candidate
+		t.Fatalf("expected 422 Unprocessable Entity, got %d", resp.Code)
+	}
+
 	resp = api.Get("/api/v1/vote", cookie1)
 	if resp.Code != 200 {
 		t.Fatalf("expected 200 OK, got %d", resp.Code)
@@ -111,12 +149,13 @@ func TestVoteSubmit(t *testing.T) {
 	body.UpdatedAt = normaliseTime(body.UpdatedAt)
 
 	unchangedResp := models.Vote{
-		Positions: map[string]string{
-			"president": nominationId0,
-			"secretary": nominationId0,
+		Positions: map[string][]string{
+			"president": {nominationId0},
+			"secretary": {nominationId0},
 		},
-		CreatedAt: normaliseTime(now0),
-		UpdatedAt: normaliseTime(now0),
+		ReceiptHash: receipt0.ReceiptHash,
+		CreatedAt:   normaliseTime(now0),
+		UpdatedAt:   normaliseTime(now0),
 	}
 	if err := compareStructs(unchangedResp, body); err != nil {
 		t.Fatal(err)
@@ -124,13 +163,18 @@ func TestVoteSubmit(t *testing.T) {
 
 	// now change the vote
 	resp = api.Put("/api/v1/vote", cookie1, map[string]any{
-		"positions": map[string]string{
-			"president": nominationId0,
+		"positions": map[string][]string{
+			"president": {nominationId0},
 			// allow putting no vote
 		},
 	})
-	if resp.Code != 204 {
-		t.Fatalf("expected 204 OK, got %d", resp.Code)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	receipt1 := models.VoteReceipt{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &receipt1)
+	if receipt1.ReceiptHash == receipt0.ReceiptHash {
+		t.Fatalf("expected a new receipt for the changed vote")
 	}
 	resp = api.Get("/api/v1/vote", cookie1)
 	if resp.Code != 200 {
@@ -142,16 +186,29 @@ func TestVoteSubmit(t *testing.T) {
 		t.Fatalf("expected body, got error %v", err)
 	}
 	changedResp := models.Vote{
-		Positions: map[string]string{
-			"president": nominationId0,
+		Positions: map[string][]string{
+			"president": {nominationId0},
 		},
-		CreatedAt: now0,
-		UpdatedAt: now1,
+		ReceiptHash: receipt1.ReceiptHash,
+		CreatedAt:   now0,
+		UpdatedAt:   now1,
 	}
 	if err := compareStructs(changedResp, body); err != nil {
 		t.Fatal(err)
 	}
 
+	// RON and abstain are choices for every position
+	resp = api.Put("/api/v1/vote", cookie1, map[string]any{
+		"positions": map[string][]string{
+			"president":    {"RON", nominationId0},
+			"arc_delegate": {"RON"},
+			"secretary":    {"ABSTAIN"},
+		},
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
 	// delete vote
 	resp = api.Delete("/api/v1/vote", cookie1)
 	if resp.Code != 204 {
@@ -162,3 +219,134 @@ func TestVoteSubmit(t *testing.T) {
 		t.Fatalf("expected 404 Not Found, got %d", resp.Code)
 	}
 }
+
+func TestVoteSecretBallot(t *testing.T) {
+	cfg := config.Load()
+	api, mailer := NewAPI(t)
+
+	adminCookie := fmt.Sprintf("Cookie: %s=%s", cfg.JWT.CookieName, TestingDummyJWTAdmin)
+	resp := api.Post("/api/v1/elections", adminCookie, map[string]any{
+		"name":          "Secret Election",
+		"secret_ballot": true,
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	electionResp := models.CreateElectionResponseBody{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &electionResp)
+
+	zid0 := "z0000000"
+	zid1 := "z0000001"
+	resp = api.Put("/api/v1/elections/"+electionResp.ElectionId+"/members", adminCookie, map[string]any{
+		"zids": []string{zid0, zid1},
+	})
+	if resp.Code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", resp.Code)
+	}
+
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "NOMINATIONS_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+
+	cookie0 := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid0).Result().Header)
+	resp = api.Put("/api/v1/nomination", cookie0, map[string]any{
+		"candidate_name":      "John Doe",
+		"contact_email":       "john@example.com",
+		"discord_username":    "johndoe#1234",
+		"executive_roles":     []string{"president"},
+		"candidate_statement": "Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50Deez50",
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	nomination := models.SubmitNominationResponseBody{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &nomination)
+
+	for _, state := range []string{"NOMINATIONS_CLOSED", "VOTING_OPEN"} {
+		if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, state); code != 204 {
+			t.Fatalf("expected 204 No Content, got %d", code)
+		}
+	}
+
+	cookie1 := extractCookieHeader(generateOTPSubmit(t, api, mailer, zid1).Result().Header)
+	resp = api.Put("/api/v1/vote", cookie1, map[string]any{
+		"positions": map[string][]string{"president": {"RON"}},
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	// replacing the vote while voting is open
+	resp = api.Put("/api/v1/vote", cookie1, map[string]any{
+		"positions": map[string][]string{"president": {nomination.NominationID}},
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	resp = api.Get("/api/v1/vote", cookie1)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	vote := models.Vote{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &vote)
+	if !reflect.DeepEqual(vote.Positions, map[string][]string{"president": {nomination.NominationID}}) {
+		t.Fatalf("expected the replaced vote, got %+v", vote.Positions)
+	}
+
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "VOTING_CLOSED"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+
+	// the vote can no longer be linked back to the voter
+	resp = api.Get("/api/v1/vote", cookie1)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	vote = models.Vote{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &vote)
+	if vote.Positions != nil {
+		t.Fatalf("expected no positions once voting closed, got %+v", vote.Positions)
+	}
+
+	if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, "VOTING_OPEN"); code != 204 {
+		t.Fatalf("expected 204 No Content, got %d", code)
+	}
+
+	// a vote from before voting was reopened can't be replaced, as it can't be found
+	resp = api.Put("/api/v1/vote", cookie1, map[string]any{
+		"positions": map[string][]string{"president": {"RON"}},
+	})
+	if resp.Code != 409 {
+		t.Fatalf("expected 409 Conflict, got %d", resp.Code)
+	}
+	resp = api.Put("/api/v1/vote", cookie0, map[string]any{
+		"positions": map[string][]string{"president": {"ABSTAIN"}},
+	})
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+
+	for _, state := range []string{"VOTING_CLOSED", "RESULTS"} {
+		if code := transitionElectionState(t, api, cfg.JWT, TestingDummyJWTAdmin, state); code != 204 {
+			t.Fatalf("expected 204 No Content, got %d", code)
+		}
+	}
+
+	resp = api.Get("/api/v1/results", cookie0)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200 OK, got %d", resp.Code)
+	}
+	results := store.ElectionResults{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &results)
+	if results.Turnout.BallotsCast != 2 {
+		t.Fatalf("expected 2 ballots cast, got %d", results.Turnout.BallotsCast)
+	}
+	for _, position := range results.Positions {
+		if position.Position != "president" {
+			continue
+		}
+		if !reflect.DeepEqual(position.Winners, []string{nomination.NominationID}) || position.Abstentions != 1 {
+			t.Fatalf("expected one vote for the nomination and one abstention, got %+v", position)
+		}
+	}
+}
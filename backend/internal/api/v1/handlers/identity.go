@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+// rejects a zID that doesn't match the identity provider's pattern. the pattern is
+// configured, so it can't be a struct tag. endpoints that only remove things accept any
+// zID, so entries from before the pattern changed can still be removed
+func validateZid(ids identity.Provider, zid string, location string) error {
+	if ids.Valid(zid) {
+		return nil
+	}
+	return huma.Error422UnprocessableEntity("validation failed", &huma.ErrorDetail{
+		Message:  "expected string to match pattern " + ids.Pattern(),
+		Location: location,
+		Value:    zid,
+	})
+}
+
+// the address to email zid at, using their email from the current election's member list
+// if they have one there.
+// Returns error identity.ErrNoAddress if there is nowhere to send it.
+func memberAddress(ctx context.Context, el store.ElectionStore, ids identity.Provider, zid string) (string, error) {
+	election, err := el.CurrentElection(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var memberEmail *string
+	if election != nil {
+		member, err := el.GetMember(ctx, election.ElectionID, zid)
+		if err != nil {
+			return "", err
+		}
+		if member != nil {
+			memberEmail = member.Email
+		}
+	}
+
+	return ids.Address(zid, memberEmail)
+}
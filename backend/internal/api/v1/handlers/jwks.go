@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/jwtkeys"
+)
+
+func GetJWKS(log *slog.Logger, keys *jwtkeys.KeySet) func(ctx context.Context, input *struct{}) (*models.GetJWKSResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*models.GetJWKSResponse, error) {
+		// the key set marshals to the standard JWKS format, which the model documents
+		encoded, err := json.Marshal(keys.PublicKeys())
+		if err != nil {
+			log.Error("failed to marshal JWKS", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		resp := &models.GetJWKSResponse{
+			// keys only change on restart, but verifiers should notice a rotation soon after
+			CacheControl: "public, max-age=300",
+		}
+		if err := json.Unmarshal(encoded, &resp.Body); err != nil {
+			log.Error("failed to unmarshal JWKS", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		return resp, nil
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func AddMember(log *slog.Logger, st store.ElectionStore, au store.AuditStore, ids identity.Provider) func(ctx context.Context, input *models.AddMemberInput) (*models.AddMemberResponse, error) {
+	return func(ctx context.Context, input *models.AddMemberInput) (*models.AddMemberResponse, error) {
+		claims, valid := middleware.GetUser(ctx)
+		if !valid {
+			return nil, huma.Error401Unauthorized("invalid user")
+		}
+		// the same as the CSV import, so "5555555" and "z5555555" are the same member
+		zid := ids.Normalise(input.Body.Zid)
+		if err := validateZid(ids, zid, "body.zid"); err != nil {
+			return nil, err
+		}
+
+		member := store.ElectionMember{
+			Zid:                 zid,
+			Name:                input.Body.Name,
+			Email:               input.Body.Email,
+			MembershipExpiresAt: input.Body.MembershipExpiresAt,
+		}
+		var listed *store.ListedMember
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			var err error
+			listed, err = st.AddMember(ctx, input.ElectionId, member, claims.ZID)
+			return store.NewAuditEntry{
+				ElectionID: input.ElectionId,
+				Action:     store.AuditMemberAdded,
+				Details:    map[string]any{"zid": zid},
+			}, err
+		})
+		if errors.Is(err, store.ErrElectionNotFound) {
+			return nil, huma.Error404NotFound("election not found")
+		} else if errors.Is(err, store.ErrMemberAlreadyExists) {
+			return nil, huma.Error409Conflict("already a member")
+		} else if err != nil {
+			log.Error("failed to add election member", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &models.AddMemberResponse{Body: *listed}, nil
+	}
+}
+
+func RemoveMember(log *slog.Logger, st store.ElectionStore, au store.AuditStore, ids identity.Provider) func(ctx context.Context, input *models.RemoveMemberInput) (*struct{}, error) {
+	return func(ctx context.Context, input *models.RemoveMemberInput) (*struct{}, error) {
+		// not validated, so members added before the pattern changed can still be removed
+		zid := ids.Normalise(input.Zid)
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			return store.NewAuditEntry{
+				ElectionID: input.ElectionId,
+				Action:     store.AuditMemberRemoved,
+				Details:    map[string]any{"zid": zid},
+			}, st.RemoveMember(ctx, input.ElectionId, zid)
+		})
+		if errors.Is(err, store.ErrElectionNotFound) {
+			return nil, huma.Error404NotFound("election not found")
+		} else if errors.Is(err, store.ErrMemberNotFound) {
+			return nil, huma.Error404NotFound("member not found")
+		} else if err != nil {
+			log.Error("failed to remove election member", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &struct{}{}, nil
+	}
+}
+
+func ListMembers(log *slog.Logger, st store.ElectionStore) func(ctx context.Context, input *models.ListMembersInput) (*models.ListMembersResponse, error) {
+	return func(ctx context.Context, input *models.ListMembersInput) (*models.ListMembersResponse, error) {
+		members, err := st.ListMembers(ctx, input.ElectionId, input.Search, input.After, input.Limit)
+		if errors.Is(err, store.ErrElectionNotFound) {
+			return nil, huma.Error404NotFound("election not found")
+		} else if err != nil {
+			log.Error("failed to list election members", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		resp := &models.ListMembersResponse{
+			Body: models.ListMembersResponseBody{
+				Members: members,
+			},
+		}
+		if resp.Body.Members == nil {
+			resp.Body.Members = []store.ListedMember{}
+		}
+		// a full page might not be the last one
+		if len(members) == input.Limit {
+			resp.Body.NextAfter = members[len(members)-1].Zid
+		}
+		return resp, nil
+	}
+}
+
+func CountMembers(log *slog.Logger, st store.ElectionStore) func(ctx context.Context, input *models.CountMembersInput) (*models.CountMembersResponse, error) {
+	return func(ctx context.Context, input *models.CountMembersInput) (*models.CountMembersResponse, error) {
+		count, err := st.CountMembers(ctx, input.ElectionId, input.Search)
+		if errors.Is(err, store.ErrElectionNotFound) {
+			return nil, huma.Error404NotFound("election not found")
+		} else if err != nil {
+			log.Error("failed to count election members", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &models.CountMembersResponse{
+			Body: models.CountMembersResponseBody{
+				Count: count,
+			},
+		}, nil
+	}
+}
@@ -2,16 +2,19 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/mailer"
+	"github.com/linuxunsw/vote/backend/internal/outbox"
 	"github.com/linuxunsw/vote/backend/internal/store"
 )
 
-func SubmitNomination(logger *slog.Logger, st store.NominationStore, el store.ElectionStore) func(ctx context.Context, input *models.SubmitNominationRequest) (*models.SubmitNominationResponse, error) {
+func SubmitNomination(logger *slog.Logger, st store.NominationStore, el store.ElectionStore, pos store.PositionStore, au store.AuditStore, ob *outbox.Outbox) func(ctx context.Context, input *models.SubmitNominationRequest) (*models.SubmitNominationResponse, error) {
 	return func(ctx context.Context, input *models.SubmitNominationRequest) (*models.SubmitNominationResponse, error) {
 		claims, valid := middleware.GetUser(ctx)
 		if !valid {
@@ -30,12 +33,58 @@ func SubmitNomination(logger *slog.Logger, st store.NominationStore, el store.El
 			return nil, huma.Error400BadRequest("no election is currently running")
 		}
 
+		positions, err := positionsByKey(ctx, logger, pos, election.ElectionID)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range input.Body.ExecutiveRoles {
+			if _, ok := positions[role]; !ok {
+				logger.Warn("attempted to nominate for invalid position", "position", role, "zid", candidateZid, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+				return nil, huma.Error422UnprocessableEntity("validation failed", &huma.ErrorDetail{
+					Message:  "invalid position",
+					Location: "body.executive_roles",
+					Value:    role,
+				})
+			}
+		}
+
+		// to tell the candidate whether their nomination was received or updated
+		existing, err := st.GetNomination(ctx, election.ElectionID, candidateZid)
+		if err != nil {
+			logger.Error("failed to get nomination", "error", err, "zid", candidateZid, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
 		// nomination ids are for the public to see, the user should probably ignore it
-		nominationId, err := st.SubmitOrReplaceNomination(ctx, election.ElectionID, candidateZid, input.Body)
+		var nominationId string
+		err = audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			var err error
+			nominationId, err = st.SubmitOrReplaceNomination(ctx, election.ElectionID, candidateZid, input.Body)
+			return store.NewAuditEntry{
+				ElectionID: election.ElectionID,
+				Action:     store.AuditNominationSubmitted,
+				Details: map[string]any{
+					"nomination_id":   nominationId,
+					"executive_roles": input.Body.ExecutiveRoles,
+				},
+			}, err
+		})
 		if err != nil {
 			logger.Error("failed to submit or replace nomination", "error", err, "zid", candidateZid, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
 			return nil, huma.Error500InternalServerError("internal error")
 		}
+
+		template := mailer.NotificationNominationReceived
+		if existing != nil {
+			template = mailer.NotificationNominationUpdated
+		}
+		nomination, err := st.GetNomination(ctx, election.ElectionID, candidateZid)
+		if err != nil || nomination == nil {
+			logger.Error("failed to get submitted nomination to notify candidate", "error", err, "zid", candidateZid, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+		} else {
+			notifyNomination(ctx, logger, ob, template, election, *nomination, positions)
+		}
+
 		return &models.SubmitNominationResponse{
 			Body: models.SubmitNominationResponseBody{
 				NominationID: nominationId,
@@ -75,7 +124,7 @@ func GetNomination(logger *slog.Logger, st store.NominationStore, el store.Elect
 	}
 }
 
-func DeleteNomination(logger *slog.Logger, st store.NominationStore, el store.ElectionStore) func(ctx context.Context, input *struct{}) (*struct{}, error) {
+func DeleteNomination(logger *slog.Logger, st store.NominationStore, el store.ElectionStore, au store.AuditStore, ob *outbox.Outbox) func(ctx context.Context, input *struct{}) (*struct{}, error) {
 	return func(ctx context.Context, input *struct{}) (*struct{}, error) {
 		claims, valid := middleware.GetUser(ctx)
 		if !valid {
@@ -94,11 +143,28 @@ func DeleteNomination(logger *slog.Logger, st store.NominationStore, el store.El
 			return nil, huma.Error400BadRequest("no election is currently running")
 		}
 
-		err = st.TryDeleteNomination(ctx, election.ElectionID, candidateZid)
+		// for who to tell it was withdrawn
+		nomination, err := st.GetNomination(ctx, election.ElectionID, candidateZid)
+		if err != nil {
+			logger.Error("failed to get nomination", "error", err, "zid", candidateZid, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		err = audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			return store.NewAuditEntry{
+				ElectionID: election.ElectionID,
+				Action:     store.AuditNominationDeleted,
+			}, st.TryDeleteNomination(ctx, election.ElectionID, candidateZid)
+		})
 		if err != nil {
 			logger.Error("failed to delete nomination", "error", err, "zid", candidateZid, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
 			return nil, huma.Error500InternalServerError("internal error")
 		}
+
+		if nomination != nil {
+			notifyNomination(ctx, logger, ob, mailer.NotificationNominationWithdrawn, election, *nomination, nil)
+		}
+
 		return &struct{}{}, nil
 	}
 }
@@ -117,4 +183,25 @@ func GetPublicNomination(logger *slog.Logger, st store.NominationStore) func(ctx
 		}
 		return &models.GetPublicNominationResponse{Body: models.FromStoreNomination(*nomination)}, nil
 	}
-}
\ No newline at end of file
+}
+
+// Queues a nomination notification to the candidate's contact email. Each version of a
+// nomination is only notified once. The nomination has already changed, so a failure is
+// logged.
+func notifyNomination(ctx context.Context, logger *slog.Logger, ob *outbox.Outbox, template string, election *store.Election, nomination store.Nomination, positions map[string]store.Position) {
+	data := mailer.NotificationData{
+		ElectionName:  election.Name,
+		CandidateName: nomination.CandidateName,
+		NominationID:  nomination.NominationId,
+	}
+	for _, role := range nomination.ExecutiveRoles {
+		if position, ok := positions[role]; ok {
+			data.Positions = append(data.Positions, position.DisplayName)
+		}
+	}
+
+	key := fmt.Sprintf("%s:%s:%d", template, nomination.NominationId, nomination.UpdatedAt.UnixNano())
+	if err := ob.Notify(ctx, key, nomination.ContactEmail, template, data); err != nil {
+		logger.Error("failed to queue nomination notification", "error", err, "template", template, "nomination_id", nomination.NominationId, "request_id", requestid.Get(ctx))
+	}
+}
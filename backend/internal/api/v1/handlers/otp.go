@@ -3,21 +3,25 @@ package handlers
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
-	"github.com/lestrrat-go/jwx/v3/jwa"
-	"github.com/lestrrat-go/jwx/v3/jwt"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
 	"github.com/linuxunsw/vote/backend/internal/config"
-	"github.com/linuxunsw/vote/backend/internal/mailer"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/jwtkeys"
+	"github.com/linuxunsw/vote/backend/internal/outbox"
 	"github.com/linuxunsw/vote/backend/internal/store"
 )
 
@@ -42,13 +46,25 @@ func NewCode() (string, error) {
 	return otpString, nil
 }
 
-func EmailFromZid(zid string) string {
-	return zid + "@unsw.edu.au"
-}
-
 // Huma generate OTP handler
-func GenerateOTP(log *slog.Logger, st store.OTPStore, mailer mailer.Mailer) func(ctx context.Context, input *models.GenerateOTPInput) (*models.GenerateOTPResponse, error) {
+func GenerateOTP(log *slog.Logger, st store.OTPStore, el store.ElectionStore, db store.DashboardStore, ob *outbox.Outbox, ids identity.Provider, cfg config.OTPConfig) func(ctx context.Context, input *models.GenerateOTPInput) (*models.GenerateOTPResponse, error) {
 	return func(ctx context.Context, input *models.GenerateOTPInput) (*models.GenerateOTPResponse, error) {
+		if err := validateZid(ids, input.Body.Zid, "body.zid"); err != nil {
+			return nil, err
+		}
+
+		toEmail, err := memberAddress(ctx, el, ids, input.Body.Zid)
+		if errors.Is(err, identity.ErrNoAddress) {
+			// the response is the same as when a code is sent, so it doesn't reveal who is
+			// on the member list
+			log.Warn("no email address to send OTP to", "zid", input.Body.Zid, "request_id", requestid.Get(ctx))
+			recordOTPEvent(ctx, log, db, input.Body.Zid, store.OTPEventNoAddress)
+			return &models.GenerateOTPResponse{}, nil
+		} else if err != nil {
+			log.Error("failed to get address to send OTP to", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
 		code, err := NewCode()
 		if err != nil {
 			log.Error("failed to generate OTP code", "error", err, "request_id", requestid.Get(ctx))
@@ -58,35 +74,52 @@ func GenerateOTP(log *slog.Logger, st store.OTPStore, mailer mailer.Mailer) func
 		err = st.CreateOrReplace(ctx, input.Body.Zid, code)
 		if errors.Is(err, store.ErrOTPRateLimitExceeded) {
 			log.Warn("rate limit exceeded for OTP generation", "zid", input.Body.Zid, "request_id", requestid.Get(ctx))
+			recordOTPEvent(ctx, log, db, input.Body.Zid, store.OTPEventRateLimited)
 			return nil, huma.Error429TooManyRequests("rate limit exceeded")
 		} else if err != nil {
 			log.Error("failed to create OTP entry", "error", err, "request_id", requestid.Get(ctx))
 			return nil, huma.Error500InternalServerError("internal error")
 		}
 
-		err = mailer.SendOTP(EmailFromZid(input.Body.Zid), code)
+		linkToken := st.LinkToken(input.Body.Zid, code)
+		link := ""
+		if cfg.LinkURL != "" {
+			link = cfg.LinkURL + "?" + url.Values{"token": {linkToken}}.Encode()
+		}
+
+		// each code is only emailed once. the key comes from the link token rather than the
+		// code, so it doesn't reveal the code once the email has been sent
+		keyHash := sha256.Sum256([]byte(linkToken))
+		idempotencyKey := "otp:" + hex.EncodeToString(keyHash[:])
+
+		// the outbox records whether the email was sent for the dashboard, and retries it
+		// if it wasn't
+		err = ob.SendOTP(ctx, idempotencyKey, input.Body.Zid, toEmail, code, link, time.Now().Add(cfg.Duration))
 		if err != nil {
-			log.Error("failed to send OTP email", "error", err, "request_id", requestid.Get(ctx))
+			log.Error("failed to queue OTP email", "error", err, "request_id", requestid.Get(ctx))
+			recordOTPEvent(ctx, log, db, input.Body.Zid, store.OTPEventSendFailed)
 			return nil, huma.Error500InternalServerError("internal error")
 		}
 
-		// success
 		return &models.GenerateOTPResponse{}, nil
 	}
 }
 
-func isAdminConfig(adminCfg config.AdminConfig, zid string) bool {
-	for _, admin := range adminCfg.AdminZIds {
-		if zid == admin {
-			return true
-		}
+// records an OTP event for the admin dashboard. the dashboard is informational, so a
+// failure is logged rather than failing the request
+func recordOTPEvent(ctx context.Context, log *slog.Logger, db store.DashboardStore, zid string, event store.OTPEvent) {
+	if err := db.RecordOTPEvent(ctx, zid, event); err != nil {
+		log.Error("failed to record OTP event", "error", err, "event", event, "request_id", requestid.Get(ctx))
 	}
-	return false
 }
 
 // Huma submit OTP handler
-func SubmitOTP(log *slog.Logger, st store.OTPStore, el store.ElectionStore, cfg config.JWTConfig, adminCfg config.AdminConfig) func(ctx context.Context, input *models.SubmitOTPInput) (*models.SubmitOTPResponse, error) {
+func SubmitOTP(log *slog.Logger, st store.OTPStore, el store.ElectionStore, au store.AuditStore, db store.DashboardStore, ad store.AdminStore, ss store.SessionStore, keys *jwtkeys.KeySet, ids identity.Provider, cfg config.JWTConfig) func(ctx context.Context, input *models.SubmitOTPInput) (*models.SubmitOTPResponse, error) {
 	return func(ctx context.Context, input *models.SubmitOTPInput) (*models.SubmitOTPResponse, error) {
+		if err := validateZid(ids, input.Body.Zid, "body.zid"); err != nil {
+			return nil, err
+		}
+
 		valid, reason, err := st.ValidateAndConsume(ctx, input.Body.Zid, input.Body.Otp)
 		if err != nil {
 			log.Error("failed to validate OTP", "error", err, "request_id", requestid.Get(ctx))
@@ -94,88 +127,133 @@ func SubmitOTP(log *slog.Logger, st store.OTPStore, el store.ElectionStore, cfg
 		}
 
 		if !valid {
-			clientStr := ""
-			switch reason {
-			case store.OTPValidateNotFoundOrExpired:
-				clientStr = "invalid code"
-			case store.OTPValidateAttemptsExceeded:
-				clientStr = "attempts exceeded"
-			case store.OTPValidateMismatch:
-				clientStr = "invalid code"
-			}
-
-			log.Warn("invalid OTP submission", "zid", input.Body.Zid, "reason", reason.ToString(), "request_id", requestid.Get(ctx))
-			return nil, huma.Error400BadRequest(clientStr)
-		}
-
-		isAdmin := isAdminConfig(adminCfg, input.Body.Zid)
-
-		if !isAdmin {
-			// admins need not be members, but even if we don't include this, you wouldn't be able to create elections
-			currentElection, err := el.CurrentElection(ctx)
-			if err != nil {
-				log.Error("failed to get current election", "error", err, "request_id", requestid.Get(ctx))
-				return nil, huma.Error500InternalServerError("internal error")
-			}
-			if currentElection == nil {
-				log.Warn("no current election when submitting OTP", "zid", input.Body.Zid, "request_id", requestid.Get(ctx))
-				return nil, huma.Error400BadRequest("no election is currently running")
-			}
-
-			entry, err := el.GetMember(ctx, currentElection.ElectionID, input.Body.Zid)
-			if err != nil {
-				log.Error("failed to get election member", "error", err, "request_id", requestid.Get(ctx))
-				return nil, huma.Error500InternalServerError("internal error")
-			}
-			if entry == nil {
-				log.Warn("zid not in election member list", "zid", input.Body.Zid, "request_id", requestid.Get(ctx))
-				return nil, huma.Error403Forbidden("not authorized to vote")
-			}
-		} else {
-			log.Info("admin login", "zid", input.Body.Zid, "request_id", requestid.Get(ctx))
-		}
-
-		tokenExpiry := time.Now().Add(cfg.Duration)
-
-		// user is now authenticated and authorised as a society member
-		// create JWT
-		token, err := jwt.NewBuilder().
-			Issuer(cfg.Issuer).
-			Subject(input.Body.Zid).
-			Claim("isAdmin", isAdmin).
-			Expiration(tokenExpiry).
-			Build()
+			return nil, rejectOTP(ctx, log, db, input.Body.Zid, reason)
+		}
+
+		return signIn(ctx, log, el, au, db, ad, ss, keys, cfg, input.Body.Zid)
+	}
+}
+
+// Huma submit magic link handler, signs in like SubmitOTP
+func SubmitOTPLink(log *slog.Logger, st store.OTPStore, el store.ElectionStore, au store.AuditStore, db store.DashboardStore, ad store.AdminStore, ss store.SessionStore, keys *jwtkeys.KeySet, cfg config.JWTConfig) func(ctx context.Context, input *models.SubmitOTPLinkInput) (*models.SubmitOTPResponse, error) {
+	return func(ctx context.Context, input *models.SubmitOTPLinkInput) (*models.SubmitOTPResponse, error) {
+		zid, valid, reason, err := st.ValidateLinkAndConsume(ctx, input.Body.Token)
+		if err != nil {
+			log.Error("failed to validate OTP link", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		if !valid {
+			return nil, rejectOTP(ctx, log, db, zid, reason)
+		}
+
+		return signIn(ctx, log, el, au, db, ad, ss, keys, cfg, zid)
+	}
+}
+
+// logs and records a rejected OTP or link, returning the error for the client
+func rejectOTP(ctx context.Context, log *slog.Logger, db store.DashboardStore, zid string, reason store.OTPValidate) error {
+	clientStr := ""
+	switch reason {
+	case store.OTPValidateNotFoundOrExpired:
+		clientStr = "invalid code"
+	case store.OTPValidateAttemptsExceeded:
+		clientStr = "attempts exceeded"
+	case store.OTPValidateMismatch:
+		clientStr = "invalid code"
+	}
+
+	log.Warn("invalid OTP submission", "zid", zid, "reason", reason.ToString(), "request_id", requestid.Get(ctx))
+	if zid != "" {
+		recordOTPEvent(ctx, log, db, zid, store.OTPEventRejected)
+	}
+	return huma.Error400BadRequest(clientStr)
+}
+
+// checks a verified zid may sign in, then starts a session for them
+func signIn(ctx context.Context, log *slog.Logger, el store.ElectionStore, au store.AuditStore, db store.DashboardStore, ad store.AdminStore, ss store.SessionStore, keys *jwtkeys.KeySet, cfg config.JWTConfig, zid string) (*models.SubmitOTPResponse, error) {
+	admin, err := ad.GetAdmin(ctx, zid)
+	if err != nil {
+		log.Error("failed to get admin", "error", err, "request_id", requestid.Get(ctx))
+		return nil, huma.Error500InternalServerError("internal error")
+	}
+	// permissions are checked against the admin's current role on each request, this
+	// only tells the client what to show
+	isAdmin := admin != nil
+
+	if !isAdmin {
+		// admins need not be members, but even if we don't include this, you wouldn't be able to create elections
+		currentElection, err := el.CurrentElection(ctx)
 		if err != nil {
-			log.Error("failed to build JWT", "error", err, "request_id", requestid.Get(ctx))
+			log.Error("failed to get current election", "error", err, "request_id", requestid.Get(ctx))
 			return nil, huma.Error500InternalServerError("internal error")
 		}
+		if currentElection == nil {
+			log.Warn("no current election when submitting OTP", "zid", zid, "request_id", requestid.Get(ctx))
+			return nil, huma.Error400BadRequest("no election is currently running")
+		}
 
-		// sign JWT
-		signed, err := jwt.Sign(token, jwt.WithKey(jwa.HS256(), []byte(cfg.Secret)))
+		entry, err := el.GetMember(ctx, currentElection.ElectionID, zid)
 		if err != nil {
-			log.Error("failed to sign JWT", "error", err, "request_id", requestid.Get(ctx))
+			log.Error("failed to get election member", "error", err, "request_id", requestid.Get(ctx))
 			return nil, huma.Error500InternalServerError("internal error")
 		}
+		if entry == nil {
+			log.Warn("zid not in election member list", "zid", zid, "request_id", requestid.Get(ctx))
+			return nil, huma.Error403Forbidden("not authorized to vote")
+		}
+	} else {
+		log.Info("admin login", "zid", zid, "role", admin.Role, "request_id", requestid.Get(ctx))
+	}
 
-		// serialise into a string
-		stringJWT := string(signed)
-
-		return &models.SubmitOTPResponse{
-			SetCookie: http.Cookie{
-				Name:     cfg.CookieName,
-				Value:    stringJWT,
-				HttpOnly: true,
-				Secure:   true,
-				SameSite: http.SameSiteLaxMode,
-				Path:     "/",
-				Expires:  tokenExpiry,
-			},
-
-			Body: models.SubmitOTPResponseBody{
-				Zid:     input.Body.Zid,
-				Expiry:  tokenExpiry,
-				IsAdmin: isAdmin,
-			},
+	tokenExpiry := time.Now().Add(cfg.Duration)
+
+	// user is now authenticated and authorised as a society member
+	// create a session, then a JWT identifying it
+	var sessionId string
+	err = audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+		var err error
+		sessionId, err = ss.CreateSession(ctx, zid, tokenExpiry)
+		if err != nil || !isAdmin {
+			return store.NewAuditEntry{}, err
+		}
+		return store.NewAuditEntry{
+			Actor:  zid,
+			Action: store.AuditAdminLogin,
 		}, nil
+	})
+	if err != nil {
+		log.Error("failed to create session", "error", err, "request_id", requestid.Get(ctx))
+		return nil, huma.Error500InternalServerError("internal error")
+	}
+
+	stringJWT, err := middleware.SignSessionToken(cfg, keys, zid, isAdmin, sessionId, tokenExpiry)
+	if err != nil {
+		log.Error("failed to sign JWT", "error", err, "request_id", requestid.Get(ctx))
+		return nil, huma.Error500InternalServerError("internal error")
+	}
+
+	recordOTPEvent(ctx, log, db, zid, store.OTPEventVerified)
+
+	resp := &models.SubmitOTPResponse{
+		SetCookie: http.Cookie{
+			Name:     cfg.CookieName,
+			Value:    stringJWT,
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+			Path:     "/",
+			Expires:  tokenExpiry,
+		},
+
+		Body: models.SubmitOTPResponseBody{
+			Zid:     zid,
+			Expiry:  tokenExpiry,
+			IsAdmin: isAdmin,
+		},
+	}
+	if admin != nil {
+		resp.Body.Role = &admin.Role
 	}
+	return resp, nil
 }
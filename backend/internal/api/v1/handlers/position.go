@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func GetPositions(log *slog.Logger, pos store.PositionStore, el store.ElectionStore) func(ctx context.Context, input *struct{}) (*models.GetPositionsResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*models.GetPositionsResponse, error) {
+		election, err := el.CurrentElection(ctx)
+		if err != nil {
+			log.Error("failed to get current election", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		if election == nil {
+			log.Warn("no current election", "request_id", requestid.Get(ctx))
+			return nil, huma.Error400BadRequest("no election is currently running")
+		}
+
+		positions, err := pos.GetPositions(ctx, election.ElectionID)
+		if err != nil {
+			log.Error("failed to get positions", "error", err, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		return &models.GetPositionsResponse{
+			Body: models.GetPositionsResponseBody{
+				ElectionID: election.ElectionID,
+				Positions:  positions,
+			},
+		}, nil
+	}
+}
+
+func SetElectionPositions(log *slog.Logger, pos store.PositionStore, au store.AuditStore) func(ctx context.Context, input *models.SetElectionPositionsInput) (*models.SetElectionPositionsResponse, error) {
+	return func(ctx context.Context, input *models.SetElectionPositionsInput) (*models.SetElectionPositionsResponse, error) {
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			return store.NewAuditEntry{
+				ElectionID: input.ElectionId,
+				Action:     store.AuditPositionsSet,
+				Details:    map[string]any{"positions": input.Body.Positions},
+			}, pos.SetPositions(ctx, input.ElectionId, input.Body.Positions)
+		})
+		if errors.Is(err, store.ErrElectionNotFound) {
+			return nil, huma.Error404NotFound("election not found")
+		} else if errors.Is(err, store.ErrPositionsElectionNotClosed) {
+			log.Warn("attempted to set positions after the election opened", "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error400BadRequest("positions can only be changed while the election is closed")
+		} else if err != nil {
+			log.Error("failed to set election positions", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &models.SetElectionPositionsResponse{}, nil
+	}
+}
+
+// loads the positions for an election keyed by position key, logging and returning a
+// huma error on failure
+func positionsByKey(ctx context.Context, log *slog.Logger, pos store.PositionStore, electionId string) (map[string]store.Position, error) {
+	positions, err := pos.GetPositions(ctx, electionId)
+	if err != nil {
+		log.Error("failed to get positions", "error", err, "election_id", electionId, "request_id", requestid.Get(ctx))
+		return nil, huma.Error500InternalServerError("internal error")
+	}
+
+	byKey := make(map[string]store.Position, len(positions))
+	for _, position := range positions {
+		byKey[position.Key] = position
+	}
+	return byKey, nil
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/receipt"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func GetResults(log *slog.Logger, st store.ResultsStore, el store.ElectionStore) func(ctx context.Context, input *struct{}) (*models.GetResultsResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*models.GetResultsResponse, error) {
+		election, err := el.CurrentElection(ctx)
+		if err != nil {
+			log.Error("failed to get current election", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		if election == nil {
+			log.Warn("no current election", "request_id", requestid.Get(ctx))
+			return nil, huma.Error400BadRequest("no election is currently running")
+		}
+
+		results, err := st.GetResults(ctx, election.ElectionID)
+		if err != nil {
+			log.Error("failed to tally results", "error", err, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		return &models.GetResultsResponse{Body: *results}, nil
+	}
+}
+
+// Results for any election, including past ones, once they have been published
+func GetElectionResults(log *slog.Logger, st store.ResultsStore, el store.ElectionStore) func(ctx context.Context, input *models.GetElectionInput) (*models.GetResultsResponse, error) {
+	return func(ctx context.Context, input *models.GetElectionInput) (*models.GetResultsResponse, error) {
+		election, err := el.GetElection(ctx, input.ElectionId)
+		if err != nil {
+			log.Error("failed to get election", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		if election == nil {
+			return nil, huma.Error404NotFound("election not found")
+		}
+		if election.State != store.StateResults && election.State != store.StateEnd {
+			return nil, huma.Error403Forbidden("results have not been published")
+		}
+
+		results, err := st.GetResults(ctx, election.ElectionID)
+		if err != nil {
+			log.Error("failed to tally results", "error", err, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		return &models.GetResultsResponse{Body: *results}, nil
+	}
+}
+
+func GetBulletin(log *slog.Logger, st store.ResultsStore, el store.ElectionStore, signer *receipt.Signer) func(ctx context.Context, input *struct{}) (*models.GetBulletinResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*models.GetBulletinResponse, error) {
+		election, err := el.CurrentElection(ctx)
+		if err != nil {
+			log.Error("failed to get current election", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		if election == nil {
+			log.Warn("no current election", "request_id", requestid.Get(ctx))
+			return nil, huma.Error400BadRequest("no election is currently running")
+		}
+
+		return bulletin(ctx, log, st, signer, election.ElectionID)
+	}
+}
+
+// The bulletin of any election, including past ones, once its results have been published
+func GetElectionBulletin(log *slog.Logger, st store.ResultsStore, el store.ElectionStore, signer *receipt.Signer) func(ctx context.Context, input *models.GetElectionInput) (*models.GetBulletinResponse, error) {
+	return func(ctx context.Context, input *models.GetElectionInput) (*models.GetBulletinResponse, error) {
+		election, err := el.GetElection(ctx, input.ElectionId)
+		if err != nil {
+			log.Error("failed to get election", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		if election == nil {
+			return nil, huma.Error404NotFound("election not found")
+		}
+		if election.State != store.StateResults && election.State != store.StateEnd {
+			return nil, huma.Error403Forbidden("results have not been published")
+		}
+
+		return bulletin(ctx, log, st, signer, election.ElectionID)
+	}
+}
+
+func bulletin(ctx context.Context, log *slog.Logger, st store.ResultsStore, signer *receipt.Signer, electionID string) (*models.GetBulletinResponse, error) {
+	entries, err := st.GetBulletin(ctx, electionID)
+	if err != nil {
+		log.Error("failed to get bulletin", "error", err, "election_id", electionID, "request_id", requestid.Get(ctx))
+		return nil, huma.Error500InternalServerError("internal error")
+	}
+	key, err := receiptKey(ctx, log, st, signer, electionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ballots := make([]models.BulletinBallot, 0, len(entries))
+	for _, entry := range entries {
+		ballots = append(ballots, models.BulletinBallot{
+			ReceiptHash: hex.EncodeToString(entry.ReceiptHash),
+			Positions:   entry.Positions,
+		})
+	}
+	return &models.GetBulletinResponse{
+		Body: models.Bulletin{
+			ElectionID: electionID,
+			PublicKey:  hex.EncodeToString(key),
+			Ballots:    ballots,
+		},
+	}, nil
+}
+
+func GetReceiptKey(signer *receipt.Signer) func(ctx context.Context, input *struct{}) (*models.GetReceiptKeyResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*models.GetReceiptKeyResponse, error) {
+		return &models.GetReceiptKeyResponse{
+			Body: models.ReceiptKey{
+				PublicKey: hex.EncodeToString(signer.PublicKey()),
+			},
+		}, nil
+	}
+}
+
+func GetElectionReceiptKey(log *slog.Logger, st store.ResultsStore, signer *receipt.Signer) func(ctx context.Context, input *models.GetElectionInput) (*models.GetReceiptKeyResponse, error) {
+	return func(ctx context.Context, input *models.GetElectionInput) (*models.GetReceiptKeyResponse, error) {
+		key, err := receiptKey(ctx, log, st, signer, input.ElectionId)
+		if err != nil {
+			return nil, err
+		}
+		return &models.GetReceiptKeyResponse{
+			Body: models.ReceiptKey{
+				PublicKey: hex.EncodeToString(key),
+			},
+		}, nil
+	}
+}
+
+// the key an election's receipts were signed with. before any ballots are cast, they
+// will be signed with the current key
+func receiptKey(ctx context.Context, log *slog.Logger, st store.ResultsStore, signer *receipt.Signer, electionID string) ([]byte, error) {
+	key, err := st.GetReceiptKey(ctx, electionID)
+	if errors.Is(err, store.ErrElectionNotFound) {
+		return nil, huma.Error404NotFound("election not found")
+	} else if err != nil {
+		log.Error("failed to get receipt key", "error", err, "election_id", electionID, "request_id", requestid.Get(ctx))
+		return nil, huma.Error500InternalServerError("internal error")
+	}
+	if key == nil {
+		return signer.PublicKey(), nil
+	}
+	return key, nil
+}
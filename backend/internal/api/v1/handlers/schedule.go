@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func SetElectionSchedule(log *slog.Logger, sc store.ScheduleStore, au store.AuditStore) func(ctx context.Context, input *models.SetElectionScheduleInput) (*models.SetElectionScheduleResponse, error) {
+	return func(ctx context.Context, input *models.SetElectionScheduleInput) (*models.SetElectionScheduleResponse, error) {
+		claims, valid := middleware.GetUser(ctx)
+		if !valid {
+			log.Warn("unauthenticated user tried to set election schedule", "request_id", requestid.Get(ctx))
+			return nil, huma.Error401Unauthorized("invalid user")
+		}
+
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			return store.NewAuditEntry{
+				ElectionID: input.ElectionId,
+				Action:     store.AuditScheduleSet,
+				Details:    map[string]any{"transitions": input.Body.Transitions},
+			}, sc.SetSchedule(ctx, input.ElectionId, input.Body.Transitions, claims.ZID)
+		})
+		if errors.Is(err, store.ErrElectionNotFound) {
+			return nil, huma.Error404NotFound("election not found")
+		} else if err != nil {
+			log.Error("failed to set election schedule", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &models.SetElectionScheduleResponse{}, nil
+	}
+}
+
+func GetElectionSchedule(log *slog.Logger, sc store.ScheduleStore) func(ctx context.Context, input *models.GetElectionScheduleInput) (*models.GetElectionScheduleResponse, error) {
+	return func(ctx context.Context, input *models.GetElectionScheduleInput) (*models.GetElectionScheduleResponse, error) {
+		transitions, err := sc.GetSchedule(ctx, input.ElectionId)
+		if errors.Is(err, store.ErrElectionNotFound) {
+			return nil, huma.Error404NotFound("election not found")
+		} else if err != nil {
+			log.Error("failed to get election schedule", "error", err, "election_id", input.ElectionId, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &models.GetElectionScheduleResponse{
+			Body: models.GetElectionScheduleResponseBody{
+				Transitions: transitions,
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/jwtkeys"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+func Logout(log *slog.Logger, ss store.SessionStore, cfg config.JWTConfig) func(ctx context.Context, input *struct{}) (*models.LogoutResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*models.LogoutResponse, error) {
+		claims, valid := middleware.GetUser(ctx)
+		if !valid {
+			return nil, huma.Error401Unauthorized("invalid user")
+		}
+
+		if err := ss.RevokeSession(ctx, claims.SessionID); err != nil {
+			log.Error("failed to revoke session", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		// the token no longer works, but there's no reason for the client to keep it
+		return &models.LogoutResponse{
+			SetCookie: http.Cookie{
+				Name:     cfg.CookieName,
+				Value:    "",
+				HttpOnly: true,
+				Secure:   true,
+				SameSite: http.SameSiteLaxMode,
+				Path:     "/",
+				Expires:  time.Unix(0, 0),
+				MaxAge:   -1,
+			},
+		}, nil
+	}
+}
+
+func RefreshSession(log *slog.Logger, ss store.SessionStore, ad store.AdminStore, keys *jwtkeys.KeySet, cfg config.JWTConfig) func(ctx context.Context, input *struct{}) (*models.RefreshSessionResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*models.RefreshSessionResponse, error) {
+		claims, valid := middleware.GetUser(ctx)
+		if !valid {
+			return nil, huma.Error401Unauthorized("invalid user")
+		}
+
+		// the session keeps its ID, so revoking it still revokes the refreshed token
+		session, err := ss.ExtendSession(ctx, claims.SessionID, time.Now().Add(cfg.Duration), cfg.MaxLifetime)
+		if err != nil {
+			log.Error("failed to extend session", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		if session == nil {
+			return nil, huma.Error401Unauthorized("Session has ended")
+		}
+
+		// the admin's role may have changed since they signed in
+		admin, err := ad.GetAdmin(ctx, session.Zid)
+		if err != nil {
+			log.Error("failed to get admin", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		isAdmin := admin != nil
+
+		stringJWT, err := middleware.SignSessionToken(cfg, keys, session.Zid, isAdmin, session.SessionID, session.ExpiresAt)
+		if err != nil {
+			log.Error("failed to sign JWT", "error", err, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		resp := &models.RefreshSessionResponse{
+			SetCookie: http.Cookie{
+				Name:     cfg.CookieName,
+				Value:    stringJWT,
+				HttpOnly: true,
+				Secure:   true,
+				SameSite: http.SameSiteLaxMode,
+				Path:     "/",
+				Expires:  session.ExpiresAt,
+			},
+
+			Body: models.RefreshSessionResponseBody{
+				Zid:       session.Zid,
+				Expiry:    session.ExpiresAt,
+				MaxExpiry: session.CreatedAt.Add(cfg.MaxLifetime),
+				IsAdmin:   isAdmin,
+			},
+		}
+		if admin != nil {
+			resp.Body.Role = &admin.Role
+		}
+		return resp, nil
+	}
+}
+
+func RevokeSessions(log *slog.Logger, ss store.SessionStore, au store.AuditStore, ids identity.Provider) func(ctx context.Context, input *models.RevokeSessionsInput) (*models.RevokeSessionsResponse, error) {
+	return func(ctx context.Context, input *models.RevokeSessionsInput) (*models.RevokeSessionsResponse, error) {
+		// sessions are created for the normalised zID. not validated, so sessions from
+		// before the pattern changed can still be revoked
+		zid := ids.Normalise(input.Zid)
+		var revoked int
+		err := audited(ctx, au, func(ctx context.Context) (store.NewAuditEntry, error) {
+			var err error
+			revoked, err = ss.RevokeAllSessions(ctx, zid)
+			return store.NewAuditEntry{
+				Action:  store.AuditSessionsRevoked,
+				Details: map[string]any{"zid": zid, "revoked": revoked},
+			}, err
+		})
+		if err != nil {
+			log.Error("failed to revoke sessions", "error", err, "zid", zid, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
+		return &models.RevokeSessionsResponse{
+			Body: models.RevokeSessionsResponseBody{
+				Revoked: revoked,
+			},
+		}, nil
+	}
+}
@@ -3,25 +3,92 @@ package handlers
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2/sse"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/broker"
+	"github.com/linuxunsw/vote/backend/internal/config"
 	"github.com/linuxunsw/vote/backend/internal/store"
 )
 
-func GetState(logger *slog.Logger) func(ctx context.Context, input *struct{}, send sse.Sender) {
+func GetState(log *slog.Logger, st store.ElectionStore, ss store.SessionStore, br *broker.Broker, cfg config.JWTConfig) func(ctx context.Context, input *struct{}, send sse.Sender) {
 	return func(ctx context.Context, input *struct{}, send sse.Sender) {
-		// dummy code that sends random state change events
-		// for x := 0; x < 10; x++ {
-		// 	send.Data(models.StateChangeEvent{NewState: models.StateClosed.String()})
-		// 	time.Sleep(1 * time.Second)
-		// 	send.Data(models.StateChangeEvent{NewState: models.StateVotingOpen.String()})
-		// 	time.Sleep(1 * time.Second)
-		// }
-
-		err := send.Data(models.StateChangeEvent{NewState: string(store.StateClosed)})
+		// the session is only checked when the stream is opened, so it is checked again
+		// while the stream is open
+		ticker := time.NewTicker(cfg.SessionCheckInterval)
+		defer ticker.Stop()
+
+		// subscribe before reading the current state so no change is missed in between
+		changes, unsubscribe := br.Subscribe()
+		defer unsubscribe()
+
+		election, err := st.CurrentElection(ctx)
 		if err != nil {
-			logger.Error("Unable to send StateChangeEvent", "error", err)
+			log.Error("failed to get current election", "error", err, "request_id", requestid.Get(ctx))
+			return
+		}
+
+		last := models.StateChangeEvent{NewState: "NO_ELECTION"}
+		if election != nil {
+			last = models.StateChangeEvent{
+				NewState:   string(election.State),
+				ElectionId: election.ElectionID,
+			}
+		}
+		if err := send.Data(last); err != nil {
+			log.Warn("unable to send StateChangeEvent", "error", err, "request_id", requestid.Get(ctx))
+			return
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if sessionEnded(ctx, log, ss) {
+					return
+				}
+			case change := <-changes:
+				event := models.StateChangeEvent{
+					NewState:   string(change.State),
+					ElectionId: change.ElectionID,
+				}
+				// the broker republishes the current state after reconnecting
+				if event == last {
+					continue
+				}
+				if sessionEnded(ctx, log, ss) {
+					return
+				}
+
+				if err := send.Data(event); err != nil {
+					log.Warn("unable to send StateChangeEvent", "error", err, "request_id", requestid.Get(ctx))
+					return
+				}
+				last = event
+			}
 		}
 	}
 }
+
+// whether the session a stream was opened with has been revoked or has expired, so the
+// stream should be closed. requests made with an API token have no session
+func sessionEnded(ctx context.Context, log *slog.Logger, ss store.SessionStore) bool {
+	claims, valid := middleware.GetUser(ctx)
+	if !valid {
+		return true
+	}
+	if claims.SessionID == "" {
+		return false
+	}
+
+	active, err := ss.SessionActive(ctx, claims.SessionID)
+	if err != nil {
+		log.Error("failed to check session", "error", err, "request_id", requestid.Get(ctx))
+		return true
+	}
+	return !active
+}
@@ -2,17 +2,23 @@ package handlers
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"log/slog"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/mailer"
+	"github.com/linuxunsw/vote/backend/internal/outbox"
+	"github.com/linuxunsw/vote/backend/internal/receipt"
 	"github.com/linuxunsw/vote/backend/internal/store"
 )
 
-func SubmitVote(log *slog.Logger, st store.BallotStore, el store.ElectionStore, nom store.NominationStore) func(ctx context.Context, input *models.SubmitVoteInput) (*struct{}, error) {
-	return func(ctx context.Context, input *models.SubmitVoteInput) (*struct{}, error) {
+func SubmitVote(log *slog.Logger, st store.BallotStore, el store.ElectionStore, nom store.NominationStore, pos store.PositionStore, signer *receipt.Signer, ob *outbox.Outbox, ids identity.Provider) func(ctx context.Context, input *models.SubmitVoteInput) (*models.SubmitVoteResponse, error) {
+	return func(ctx context.Context, input *models.SubmitVoteInput) (*models.SubmitVoteResponse, error) {
 		claims, valid := middleware.GetUser(ctx)
 		if !valid {
 			log.Warn("unauthenticated user tried to submit vote", "request_id", requestid.Get(ctx))
@@ -29,32 +35,96 @@ func SubmitVote(log *slog.Logger, st store.BallotStore, el store.ElectionStore,
 			return nil, huma.Error400BadRequest("no election is currently running")
 		}
 
-		// the keys of input.Body.Positions have been validated
-		// the values have been validated for the correct zID format, but not if they are running 
-		for position, nominationId := range input.Body.Positions {
-			nomination, err := nom.GetNominationByPublicId(ctx, nominationId)
-			if err != nil {
-				log.Error("failed to get nomination", "error", err, "nomination_id", nominationId, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
-				return nil, huma.Error500InternalServerError("internal error")
-			}
-			if nomination == nil {
-				log.Warn("candidate is not running", "nomination_id", nominationId, "position", position, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
-				return nil, huma.Error400BadRequest("candidate is not running for position " + position)
+		positions, err := positionsByKey(ctx, log, pos, election.ElectionID)
+		if err != nil {
+			return nil, err
+		}
+		for position := range input.Body.Positions {
+			if _, ok := positions[position]; !ok {
+				log.Warn("attempted to vote for invalid position", "position", position, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+				return nil, huma.Error422UnprocessableEntity("validation failed", &huma.ErrorDetail{
+					Message:  "invalid position",
+					Location: "body.positions",
+					Value:    position,
+				})
 			}
-			if !nomination.IsRunningFor(position) {
-				log.Warn("candidate is not running for position", "nomination_id", nominationId, "position", position, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
-				return nil, huma.Error400BadRequest("candidate is not running for position " + position)
+		}
+
+		nominations, err := nom.GetElectionNominations(ctx, election.ElectionID)
+		if err != nil {
+			log.Error("failed to get nominations", "error", err, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+		running := make(map[string]store.Nomination, len(nominations))
+		for _, nomination := range nominations {
+			running[nomination.NominationId] = nomination
+		}
+
+		// the keys of input.Body.Positions are positions in this election, and each ranking has no duplicates
+		// the values have not been checked for whether they are running
+		for position, preferences := range input.Body.Positions {
+			for _, nominationId := range preferences {
+				if nominationId == store.ChoiceAbstain || nominationId == store.ChoiceRON {
+					// offered for every position
+					continue
+				}
+				nomination, ok := running[nominationId]
+				if !ok {
+					log.Warn("candidate is not running", "nomination_id", nominationId, "position", position, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+					return nil, huma.Error400BadRequest("candidate is not running for position " + position)
+				}
+				if !nomination.IsRunningFor(position) {
+					log.Warn("candidate is not running for position", "nomination_id", nominationId, "position", position, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+					return nil, huma.Error400BadRequest("candidate is not running for position " + position)
+				}
 			}
 		}
 
+		voteReceipt, err := signer.Issue(election.ElectionID, input.Body.Positions)
+		if err != nil {
+			log.Error("failed to issue vote receipt", "error", err, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error500InternalServerError("internal error")
+		}
+
 		err = st.SubmitOrReplaceBallot(ctx, election.ElectionID, claims.ZID, store.SubmitBallot{
-			Positions: input.Body.Positions,
+			Positions:   input.Body.Positions,
+			ReceiptHash: voteReceipt.Hash,
+			ReceiptKey:  signer.PublicKey(),
 		})
-		if err != nil {
+		if errors.Is(err, store.ErrBallotNotReplaceable) {
+			log.Warn("attempted to replace a secret ballot from before voting was reopened", "zid", claims.ZID, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error409Conflict("your vote was cast before voting was reopened and can no longer be changed")
+		} else if errors.Is(err, store.ErrBallotSecretDestroyed) {
+			// voting closed after the election was fetched
+			log.Warn("attempted to submit a secret ballot after voting closed", "zid", claims.ZID, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error409Conflict("voting has closed")
+		} else if err != nil {
 			log.Error("failed to submit ballot", "error", err, "zid", claims.ZID, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
 			return nil, huma.Error500InternalServerError("internal error")
 		}
-		return &struct{}{}, nil
+		// the outbox keeps who each email went to, and the bulletin publishes each receipt
+		// with its ballot, so emailing receipts would link secret ballots to voters
+		if !election.SecretBallot {
+			notifyVoteRecorded(ctx, log, el, ob, ids, election, claims.ZID, voteReceipt.Hash)
+		}
+
+		return &models.SubmitVoteResponse{Body: models.FromReceipt(*voteReceipt)}, nil
+	}
+}
+
+// every receipt is different, so each vote is notified once. the vote has been recorded,
+// so a failure is logged
+func notifyVoteRecorded(ctx context.Context, log *slog.Logger, el store.ElectionStore, ob *outbox.Outbox, ids identity.Provider, election *store.Election, zid string, hash []byte) {
+	receiptHash := hex.EncodeToString(hash)
+	toEmail, err := memberAddress(ctx, el, ids, zid)
+	if err == nil {
+		err = ob.Notify(ctx, "vote_recorded:"+election.ElectionID+":"+receiptHash, toEmail, mailer.NotificationVoteRecorded, mailer.NotificationData{
+			ElectionName: election.Name,
+			ReceiptHash:  receiptHash,
+		})
+	}
+	if err != nil {
+		log.Error("failed to queue vote receipt notification", "error", err, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
 	}
 }
 
@@ -86,9 +156,10 @@ func GetVote(log *slog.Logger, st store.BallotStore, el store.ElectionStore) fun
 		}
 		return &models.GetVoteResponse{
 			Body: models.Vote{
-				Positions: ballot.Positions,
-				CreatedAt: ballot.CreatedAt,
-				UpdatedAt: ballot.UpdatedAt,
+				Positions:   ballot.Positions,
+				ReceiptHash: hex.EncodeToString(ballot.ReceiptHash),
+				CreatedAt:   ballot.CreatedAt,
+				UpdatedAt:   ballot.UpdatedAt,
 			},
 		}, nil
 	}
@@ -113,7 +184,10 @@ func DeleteVote(log *slog.Logger, st store.BallotStore, el store.ElectionStore)
 		}
 
 		err = st.TryDeleteBallot(ctx, election.ElectionID, claims.ZID)
-		if err != nil {
+		if errors.Is(err, store.ErrBallotNotReplaceable) {
+			log.Warn("attempted to delete a secret ballot from before voting was reopened", "zid", claims.ZID, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
+			return nil, huma.Error409Conflict("your vote was cast before voting was reopened and can no longer be changed")
+		} else if err != nil {
 			log.Error("failed to delete ballot", "error", err, "zid", claims.ZID, "election_id", election.ElectionID, "request_id", requestid.Get(ctx))
 			return nil, huma.Error500InternalServerError("internal error")
 		}
@@ -2,14 +2,18 @@ package middleware
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
-	"github.com/lestrrat-go/jwx/v3/jwa"
-	"github.com/lestrrat-go/jwx/v3/jwk"
 	"github.com/lestrrat-go/jwx/v3/jwt"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware/requestid"
 	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/jwtkeys"
+	"github.com/linuxunsw/vote/backend/internal/store"
 )
 
 // Define custom context keys to avoid collisions.
@@ -21,105 +25,184 @@ const userContextKey = contextKey("user")
 type UserClaims struct {
 	ZID     string `json:"sub"`
 	IsAdmin bool   `json:"isAdmin"`
+	// identifies the session in the SessionStore, so it can be revoked
+	SessionID string `json:"jti"`
+
+	// set instead of SessionID for requests made with an API token, which act as the
+	// admin who created it (ZID) but can only use the token's scopes
+	APITokenID string             `json:"-"`
+	Scopes     []store.Permission `json:"-"`
 }
 
-// Authenticator creates a Huma middleware to validate JWTs.
-// FIXME: cookie auth instead of bearer token? THIS IS SUBJECT TO CHANGE
-func BearerAuthenticator(api huma.API, cfg config.JWTConfig) func(ctx huma.Context, next func(ctx huma.Context)) {
-	return func(ctx huma.Context, next func(ctx huma.Context)) {
-		authHeader := ctx.Header("Authorization")
-		if authHeader == "" {
-			_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Missing Authorization header")
-			return
-		}
+// Whether the request was made with an API token rather than by a signed in user
+func (c *UserClaims) IsAPIToken() bool {
+	return c.APITokenID != ""
+}
 
-		parts := strings.Fields(authHeader)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Invalid Authorization header format")
-			return
-		}
-		tokenString := parts[1]
+// Signs a JWT for a session with the current signing key, which expires at expiry.
+func SignSessionToken(cfg config.JWTConfig, keys *jwtkeys.KeySet, zid string, isAdmin bool, sessionId string, expiry time.Time) (string, error) {
+	token, err := jwt.NewBuilder().
+		Issuer(cfg.Issuer).
+		Subject(zid).
+		JwtID(sessionId).
+		Claim("isAdmin", isAdmin).
+		Expiration(expiry).
+		Build()
+	if err != nil {
+		return "", err
+	}
 
-		// Convert the given secret into type jwt.Key (symmetric key)
-		key, err := jwk.Import([]byte(cfg.Secret))
-		if err != nil {
-			_ = huma.WriteErr(api, ctx, http.StatusInternalServerError, "Unable to authenticate")
-			return
-		}
+	signed, err := keys.Sign(token)
+	if err != nil {
+		return "", err
+	}
+	return string(signed), nil
+}
 
-		// Parse and verify the token, validate it too
-		token, err := jwt.ParseString(tokenString, jwt.WithKey(jwa.HS256(), key), jwt.WithValidate(true), jwt.WithIssuer(cfg.Issuer), jwt.WithPedantic(true))
-		if err != nil {
-			_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Invalid or expired token")
-			return
-		}
+// verifies tokenString and that its session is still active, then passes the claims on
+// to next in the request context
+func authenticate(api huma.API, log *slog.Logger, cfg config.JWTConfig, keys *jwtkeys.KeySet, sessions store.SessionStore, ctx huma.Context, tokenString string, next func(ctx huma.Context)) {
+	// Parse and verify the token against the key named by its kid, validate it too
+	token, err := keys.Parse(tokenString, jwt.WithValidate(true), jwt.WithIssuer(cfg.Issuer), jwt.WithPedantic(true))
+	if err != nil {
+		_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Invalid or expired token")
+		return
+	}
 
-		// Extract custom claims into UserClaims.
-		claims := &UserClaims{}
-		err = token.Get("isAdmin", &claims.IsAdmin)
-		if err != nil {
-			_ = huma.WriteErr(api, ctx, http.StatusInternalServerError, "Unable to authenticate")
-			return
-		}
-		claims.ZID, _ = token.Subject()
+	// Extract custom claims into UserClaims.
+	claims := &UserClaims{}
+	err = token.Get("isAdmin", &claims.IsAdmin)
+	if err != nil {
+		_ = huma.WriteErr(api, ctx, http.StatusInternalServerError, "Unable to authenticate")
+		return
+	}
+	claims.ZID, _ = token.Subject()
 
-		// Put the claims into the request context and create a new huma.Context.
-		newCtx := context.WithValue(ctx.Context(), userContextKey, claims)
-		ctx = huma.WithContext(ctx, newCtx)
+	// tokens without a session can't be revoked, so aren't accepted
+	sessionId, ok := token.JwtID()
+	if !ok {
+		_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Invalid or expired token")
+		return
+	}
+	active, err := sessions.SessionActive(ctx.Context(), sessionId)
+	if err != nil {
+		log.Error("Couldn't check session in authenticate", "error", err, "request_id", requestid.Get(ctx.Context()))
+		_ = huma.WriteErr(api, ctx, http.StatusInternalServerError, "Unable to authenticate")
+		return
+	}
+	if !active {
+		_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Session has ended")
+		return
+	}
+	claims.SessionID = sessionId
 
-		next(ctx)
+	// Put the claims into the request context and create a new huma.Context.
+	newCtx := context.WithValue(ctx.Context(), userContextKey, claims)
+	ctx = huma.WithContext(ctx, newCtx)
+
+	next(ctx)
+}
+
+// looks up an API token and passes its claims on to next in the request context
+func authenticateAPIToken(api huma.API, log *slog.Logger, tokens store.APITokenStore, ctx huma.Context, tokenString string, next func(ctx huma.Context)) {
+	token, err := tokens.UseAPIToken(ctx.Context(), store.HashAPIToken(tokenString))
+	if err != nil {
+		log.Error("Couldn't get API token in authenticateAPIToken", "error", err, "request_id", requestid.Get(ctx.Context()))
+		_ = huma.WriteErr(api, ctx, http.StatusInternalServerError, "Unable to authenticate")
+		return
 	}
+	if token == nil {
+		_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Invalid or expired token")
+		return
+	}
+
+	claims := &UserClaims{
+		ZID:        token.CreatedBy,
+		IsAdmin:    true,
+		APITokenID: token.TokenID,
+		Scopes:     token.Scopes,
+	}
+	newCtx := context.WithValue(ctx.Context(), userContextKey, claims)
+	next(huma.WithContext(ctx, newCtx))
 }
 
-func CookieAuthenticator(api huma.API, cfg config.JWTConfig) func(ctx huma.Context, next func(ctx huma.Context)) {
+// Authenticator creates a Huma middleware that accepts a session JWT in either the session
+// cookie or an "Authorization: Bearer" header, or an API token in the header. The header
+// is used if both are sent.
+func Authenticator(api huma.API, log *slog.Logger, cfg config.JWTConfig, keys *jwtkeys.KeySet, sessions store.SessionStore, tokens store.APITokenStore) func(ctx huma.Context, next func(ctx huma.Context)) {
 	return func(ctx huma.Context, next func(ctx huma.Context)) {
-		sessionCookie, err := huma.ReadCookie(ctx, cfg.CookieName)
-		if err != nil {
-			_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Missing session cookie", err)
+		if authHeader := ctx.Header("Authorization"); authHeader != "" {
+			parts := strings.Fields(authHeader)
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Invalid Authorization header format")
+				return
+			}
+
+			if store.IsAPIToken(parts[1]) {
+				authenticateAPIToken(api, log, tokens, ctx, parts[1], next)
+				return
+			}
+			authenticate(api, log, cfg, keys, sessions, ctx, parts[1], next)
 			return
 		}
-		tokenString := sessionCookie.Value
 
-		// Convert the given secret into type jwt.Key (symmetric key)
-		key, err := jwk.Import([]byte(cfg.Secret))
+		sessionCookie, err := huma.ReadCookie(ctx, cfg.CookieName)
 		if err != nil {
-			_ = huma.WriteErr(api, ctx, http.StatusInternalServerError, "Unable to authenticate")
+			_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Missing session cookie or Authorization header", err)
 			return
 		}
 
-		// Parse and verify the token, validate it too
-		token, err := jwt.ParseString(tokenString, jwt.WithKey(jwa.HS256(), key), jwt.WithValidate(true), jwt.WithIssuer(cfg.Issuer), jwt.WithPedantic(true))
-		if err != nil {
-			_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Invalid or expired token")
-			return
-		}
+		authenticate(api, log, cfg, keys, sessions, ctx, sessionCookie.Value, next)
+	}
+}
 
-		// Extract custom claims into UserClaims.
-		claims := &UserClaims{}
-		err = token.Get("isAdmin", &claims.IsAdmin)
-		if err != nil {
-			_ = huma.WriteErr(api, ctx, http.StatusInternalServerError, "Unable to authenticate")
+// RequireUserSession is a middleware that rejects API tokens, for operations that act as
+// a member such as nominating and voting. It must be placed *after* the Authenticator middleware.
+func RequireUserSession(api huma.API) func(ctx huma.Context, next func(ctx huma.Context)) {
+	return func(ctx huma.Context, next func(ctx huma.Context)) {
+		claims, ok := ctx.Context().Value(userContextKey).(*UserClaims)
+		if !ok || claims.IsAPIToken() {
+			_ = huma.WriteErr(api, ctx, http.StatusForbidden, "API tokens can't be used for this operation")
 			return
 		}
-		claims.ZID, _ = token.Subject()
-
-		// Put the claims into the request context and create a new huma.Context.
-		newCtx := context.WithValue(ctx.Context(), userContextKey, claims)
-		ctx = huma.WithContext(ctx, newCtx)
 
 		next(ctx)
 	}
 }
 
-// RequireAdmin is a middleware that checks for admin privileges from the context.
-// It must be placed *after* the Authenticator middleware.
-func RequireAdmin(api huma.API) func(ctx huma.Context, next func(ctx huma.Context)) {
+// RequirePermission is a middleware that checks the user has an admin role granting
+// permission, and for API tokens that it is one of the token's scopes. Roles are looked up
+// on every request, so revoking one takes effect immediately. It must be placed *after* the Authenticator middleware.
+func RequirePermission(api huma.API, log *slog.Logger, admins store.AdminStore, permission store.Permission) func(ctx huma.Context, next func(ctx huma.Context)) {
 	return func(ctx huma.Context, next func(ctx huma.Context)) {
 		claims, ok := ctx.Context().Value(userContextKey).(*UserClaims)
-		if !ok || !claims.IsAdmin {
+		if !ok {
 			_ = huma.WriteErr(api, ctx, http.StatusForbidden, "Admin access required")
 			return
 		}
+
+		admin, err := admins.GetAdmin(ctx.Context(), claims.ZID)
+		if err != nil {
+			log.Error("Couldn't get admin in RequirePermission", "error", err, "request_id", requestid.Get(ctx.Context()))
+			_ = huma.WriteErr(api, ctx, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+		if admin == nil {
+			_ = huma.WriteErr(api, ctx, http.StatusForbidden, "Admin access required")
+			return
+		}
+		if claims.IsAPIToken() && !slices.Contains(claims.Scopes, permission) {
+			log.Warn("API token used without scope", "token_id", claims.APITokenID, "permission", permission, "request_id", requestid.Get(ctx.Context()), "path", ctx.Operation().Path)
+			_ = huma.WriteErr(api, ctx, http.StatusForbidden, "Token scope required: "+string(permission))
+			return
+		}
+		// tokens are also limited to what their creator can currently do
+		if !admin.Role.Can(permission) {
+			log.Warn("Admin attempted request without permission", "zid", claims.ZID, "role", admin.Role, "permission", permission, "request_id", requestid.Get(ctx.Context()), "path", ctx.Operation().Path)
+			_ = huma.WriteErr(api, ctx, http.StatusForbidden, "Permission required: "+string(permission))
+			return
+		}
+
 		next(ctx)
 	}
 }
@@ -0,0 +1,27 @@
+package models
+
+import "github.com/linuxunsw/vote/backend/internal/store"
+
+type ListAdminsResponse struct {
+	Body ListAdminsResponseBody
+}
+
+type ListAdminsResponseBody struct {
+	Admins []store.Admin `json:"admins" doc:"Admins ordered by zID"`
+}
+
+type GrantAdminRoleInput struct {
+	Zid string `path:"zid" doc:"User zID" example:"z0000000"`
+
+	Body struct {
+		Role store.AdminRole `json:"role" enum:"RETURNING_OFFICER,ELECTION_MANAGER,SCRUTINEER" doc:"Role to grant"`
+	}
+}
+
+type GrantAdminRoleResponse struct {
+	Body store.Admin
+}
+
+type RevokeAdminRoleInput struct {
+	Zid string `path:"zid" doc:"User zID" example:"z0000000"`
+}
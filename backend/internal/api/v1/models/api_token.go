@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type CreateAPITokenInput struct {
+	Body struct {
+		Name      string             `json:"name" minLength:"1" maxLength:"100" example:"email-nominations" doc:"What the token is used for"`
+		Scopes    []store.Permission `json:"scopes" minItems:"1" uniqueItems:"true" enum:"VIEW_ELECTIONS,MANAGE_ELECTIONS,MANAGE_ADMINS" doc:"Permissions the token may use, which your role must grant"`
+		ExpiresAt *time.Time         `json:"expires_at,omitempty" format:"date-time" doc:"When the token stops working. Tokens without one work until revoked."`
+	}
+}
+
+type CreateAPITokenResponse struct {
+	Body CreateAPITokenResponseBody
+}
+
+type CreateAPITokenResponseBody struct {
+	store.APIToken
+
+	Token string `json:"token" example:"vote_Zm9yIGV4YW1wbGUgb25seSwgbm90IGEgcmVhbCB0b2tlbg" doc:"The token, sent as \"Authorization: Bearer <token>\". It is only shown once."`
+}
+
+type ListAPITokensResponse struct {
+	Body ListAPITokensResponseBody
+}
+
+type ListAPITokensResponseBody struct {
+	Tokens []store.APIToken `json:"tokens" doc:"Every token, including expired and revoked ones, newest first"`
+}
+
+type RevokeAPITokenInput struct {
+	TokenID string `path:"token_id" format:"uuid" doc:"Token ID"`
+}
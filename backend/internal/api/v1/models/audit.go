@@ -0,0 +1,16 @@
+package models
+
+import "github.com/linuxunsw/vote/backend/internal/store"
+
+type GetAuditLogInput struct {
+	After int64 `query:"after" minimum:"0" default:"0" doc:"Only return entries with a sequence number greater than this"`
+	Limit int   `query:"limit" minimum:"1" maximum:"1000" default:"100" doc:"Maximum number of entries to return"`
+}
+
+type GetAuditLogResponse struct {
+	Body GetAuditLogResponseBody
+}
+
+type GetAuditLogResponseBody struct {
+	Entries []store.AuditEntry `json:"entries" doc:"Audit log entries, oldest first. Each entry's prev_hash is the hash of the entry before it."`
+}
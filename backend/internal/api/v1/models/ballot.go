@@ -1,11 +1,27 @@
 package models
 
+import "github.com/linuxunsw/vote/backend/internal/store"
+
 type PublicBallot struct {
 	ElectionID string                        `json:"election_id" example:"1" doc:"Election ID"`
-	Candidates map[string][]PublicNomination `json:"candidates" doc:"Map of executive role to list of candidates running for that role" example:"{\"president\": [], \"secretary\": []}"`
+	Positions  []store.Position              `json:"positions" doc:"Positions on the ballot, in the order they should be shown"`
+	Candidates map[string][]PublicNomination `json:"candidates" doc:"Map of position key to list of candidates running for that position. Every position has an entry, even if nobody is running." example:"{\"president\": [], \"secretary\": []}"`
+	Options    []BallotOption                `json:"options" doc:"Choices offered for every position in addition to its candidates"`
 	HasVoted   bool                          `json:"has_voted" doc:"Whether the current user has already voted in this election"`
 }
 
+// A choice that is not a candidate, voted for by its ID in place of a nomination ID
+type BallotOption struct {
+	ID   string `json:"id" example:"RON"`
+	Name string `json:"name" example:"Re-open nominations (RON)"`
+}
+
+// The choices every position offers alongside its candidates
+var BallotOptions = []BallotOption{
+	{ID: store.ChoiceRON, Name: "Re-open nominations (RON)"},
+	{ID: store.ChoiceAbstain, Name: "Abstain"},
+}
+
 type GetBallotResponse struct {
 	Body PublicBallot
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type DashboardEvent struct {
+	State      string `json:"state" enum:"NO_ELECTION,CLOSED,NOMINATIONS_OPEN,NOMINATIONS_CLOSED,VOTING_OPEN,VOTING_CLOSED,RESULTS,END"`
+	ElectionId string `json:"election_id,omitempty" doc:"Election ID. Only set if an election is running."`
+
+	// not set if no election is running
+	Stats *store.DashboardStats `json:"stats,omitempty" doc:"Live statistics for the current election. Only set if an election is running."`
+
+	OTPWindowSeconds int       `json:"otp_window_seconds" doc:"OTP events are counted over this many seconds before generated_at"`
+	GeneratedAt      time.Time `json:"generated_at" format:"date-time" example:"2024-01-15T10:30:00Z"`
+}
@@ -1,15 +1,24 @@
 package models
 
 import (
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
 	"github.com/linuxunsw/vote/backend/internal/store"
 )
 
 type CreateElectionInput struct {
 	Body struct {
-		Name string `json:"name" doc:"Election name"`
+		Name         string           `json:"name" doc:"Election name"`
+		Positions    []store.Position `json:"positions,omitempty" required:"false" doc:"Positions that can be nominated for and voted on. Defaults to the standard executive roles if omitted. These can be changed until nominations open."`
+		SecretBallot bool             `json:"secret_ballot,omitempty" required:"false" doc:"Store ballots so they cannot be linked to the voter once voting closes. Votes can still be replaced while voting is open."`
 	}
 }
 
+func (b *CreateElectionInput) Resolve(ctx huma.Context) []error {
+	return resolvePositions(b.Body.Positions)
+}
+
 type CreateElectionResponse struct {
 	Body CreateElectionResponseBody
 }
@@ -19,7 +28,7 @@ type CreateElectionResponseBody struct {
 }
 
 type ElectionMemberListSetInput struct {
-	ElectionId string `path:"election_id" doc:"Election ID"`
+	ElectionId string `path:"election_id" format:"uuid" doc:"Election ID"`
 
 	Body struct {
 		Zids []string `json:"zids" doc:"User zIDs" example:"z0000000"`
@@ -30,7 +39,8 @@ type ElectionMemberListSetResponse struct {
 }
 
 type StateChangeEvent struct {
-	NewState string `json:"new_state" enum:"CLOSED,NOMINATIONS_OPEN,NOMINATIONS_CLOSED,VOTING_OPEN,VOTING_CLOSED,RESULTS,END"`
+	NewState   string `json:"new_state" enum:"NO_ELECTION,CLOSED,NOMINATIONS_OPEN,NOMINATIONS_CLOSED,VOTING_OPEN,VOTING_CLOSED,RESULTS,END"`
+	ElectionId string `json:"election_id,omitempty" doc:"Election ID. Not set if no election is running."`
 }
 
 type GetElectionStateResponse struct {
@@ -53,3 +63,64 @@ type TransitionElectionStateBody struct {
 
 type TransitionElectionStateResponse struct {
 }
+
+type ElectionDetails struct {
+	ElectionId   string              `json:"election_id" doc:"Election ID"`
+	Name         string              `json:"name" example:"2025 Annual General Meeting"`
+	State        store.ElectionState `json:"state" enum:"CLOSED,NOMINATIONS_OPEN,NOMINATIONS_CLOSED,VOTING_OPEN,VOTING_CLOSED,RESULTS,END"`
+	SecretBallot bool                `json:"secret_ballot"`
+	CreatedAt    time.Time           `json:"created_at" format:"date-time" example:"2024-01-15T10:30:00Z"`
+
+	NominationsOpenAt  *time.Time `json:"nominations_open_at,omitempty" format:"date-time" doc:"Only set once the election has entered this state, as are the other timestamps."`
+	NominationsCloseAt *time.Time `json:"nominations_close_at,omitempty" format:"date-time"`
+	VotingOpenAt       *time.Time `json:"voting_open_at,omitempty" format:"date-time"`
+	VotingCloseAt      *time.Time `json:"voting_close_at,omitempty" format:"date-time"`
+	ResultsPublishedAt *time.Time `json:"results_published_at,omitempty" format:"date-time"`
+	EndedAt            *time.Time `json:"ended_at,omitempty" format:"date-time"`
+}
+
+func FromStoreElection(e store.Election) ElectionDetails {
+	return ElectionDetails{
+		ElectionId:         e.ElectionID,
+		Name:               e.Name,
+		State:              e.State,
+		SecretBallot:       e.SecretBallot,
+		CreatedAt:          e.CreatedAt,
+		NominationsOpenAt:  e.NominationsOpenAt,
+		NominationsCloseAt: e.NominationsCloseAt,
+		VotingOpenAt:       e.VotingOpenAt,
+		VotingCloseAt:      e.VotingCloseAt,
+		ResultsPublishedAt: e.ResultsPublishedAt,
+		EndedAt:            e.EndedAt,
+	}
+}
+
+type ListElectionsInput struct {
+	Before string `query:"before" doc:"Only list elections created before this election ID, pass next_before from the previous page"`
+	Limit  int    `query:"limit" minimum:"1" maximum:"100" default:"20" doc:"Maximum number of elections to return"`
+}
+
+type ListElectionsResponse struct {
+	Body ListElectionsResponseBody
+}
+
+type ListElectionsResponseBody struct {
+	Elections  []ElectionDetails `json:"elections" doc:"Elections, newest first"`
+	NextBefore string            `json:"next_before,omitempty" doc:"Pass as before to get the next page. Not set on the last page."`
+}
+
+type GetElectionInput struct {
+	ElectionId string `path:"election_id" format:"uuid" doc:"Election ID"`
+}
+
+type GetElectionResponse struct {
+	Body ElectionDetails
+}
+
+type GetElectionNominationsResponse struct {
+	Body GetElectionNominationsResponseBody
+}
+
+type GetElectionNominationsResponseBody struct {
+	Nominations []store.Nomination `json:"nominations" doc:"Every nomination submitted in the election, oldest first"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "github.com/linuxunsw/vote/backend/internal/store"
+
+type ListEmailsInput struct {
+	Status store.EmailStatus `query:"status" enum:"PENDING,SENT,DEAD" default:"DEAD" doc:"Only list emails with this status"`
+	Limit  int               `query:"limit" minimum:"1" maximum:"1000" default:"100" doc:"Maximum number of emails to return"`
+}
+
+type ListEmailsResponse struct {
+	Body ListEmailsResponseBody
+}
+
+type ListEmailsResponseBody struct {
+	Emails []store.OutboxEmail `json:"emails" doc:"Emails with the status, newest first"`
+}
+
+type RetryEmailInput struct {
+	EmailID string `path:"email_id" format:"uuid" doc:"Email ID"`
+}
+
+type RetryEmailResponse struct {
+	Body store.OutboxEmail
+}
@@ -0,0 +1,21 @@
+package models
+
+type GetJWKSResponse struct {
+	CacheControl string `header:"Cache-Control"`
+
+	Body JWKS
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys" doc:"Every key session tokens are currently accepted from"`
+}
+
+type JWK struct {
+	Kid string `json:"kid" doc:"Key ID, matching the kid header of tokens signed with this key" example:"dGhpcyBpcyBub3QgYSByZWFsIGtpZA"`
+	Kty string `json:"kty" enum:"OKP,EC" doc:"Key type"`
+	Crv string `json:"crv" enum:"Ed25519,P-256" doc:"Curve"`
+	X   string `json:"x" doc:"Base64url encoded public key, or its x coordinate for EC keys"`
+	Y   string `json:"y,omitempty" doc:"Base64url encoded y coordinate, only set for EC keys"`
+	Alg string `json:"alg" enum:"EdDSA,ES256" doc:"Algorithm tokens are signed with"`
+	Use string `json:"use" enum:"sig" doc:"Public key use"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/memberlist"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type UploadMembersInput struct {
+	ElectionId string `path:"election_id" format:"uuid" doc:"Election ID"`
+	DryRun     bool   `query:"dry_run" doc:"Validate the file and report what would change without changing the member list"`
+
+	RawBody huma.MultipartFormFiles[struct {
+		File huma.FormFile `form:"file" required:"true" doc:"CSV membership export from Arc/Rubric. Needs a zID column, name, email and membership expiry columns are also read."`
+	}]
+}
+
+type UploadMembersResponse struct {
+	Body UploadMembersResponseBody
+}
+
+type UploadMembersResponseBody struct {
+	DryRun   bool                      `json:"dry_run" doc:"Whether the member list was left unchanged"`
+	Rows     int                       `json:"rows" doc:"Data rows read from the file, not including the header or blank lines"`
+	Imported int                       `json:"imported" doc:"Rows that passed validation. These replace the member list."`
+	Summary  store.MemberImportSummary `json:"summary"`
+	Errors   []memberlist.RowError     `json:"errors" doc:"Rows that failed validation and were left out"`
+	Members  []store.ElectionMember    `json:"members,omitempty" doc:"Members that would be imported. Only set for dry runs."`
+}
+
+type AddMemberInput struct {
+	ElectionId string `path:"election_id" format:"uuid" doc:"Election ID"`
+
+	Body struct {
+		Zid                 string     `json:"zid" doc:"User zID" example:"z0000000"`
+		Name                *string    `json:"name,omitempty" required:"false" example:"John Doe"`
+		Email               *string    `json:"email,omitempty" required:"false" format:"email" example:"john@example.com"`
+		MembershipExpiresAt *time.Time `json:"membership_expires_at,omitempty" required:"false" format:"date-time"`
+	}
+}
+
+type AddMemberResponse struct {
+	Body store.ListedMember
+}
+
+type RemoveMemberInput struct {
+	ElectionId string `path:"election_id" format:"uuid" doc:"Election ID"`
+	Zid        string `path:"zid" doc:"User zID" example:"z0000000"`
+}
+
+type ListMembersInput struct {
+	ElectionId string `path:"election_id" format:"uuid" doc:"Election ID"`
+	Search     string `query:"search" maxLength:"100" doc:"Only list members whose zID, name or email contains this, ignoring case"`
+	After      string `query:"after" doc:"Only list members with a zID after this, pass next_after from the previous page"`
+	Limit      int    `query:"limit" minimum:"1" maximum:"500" default:"100" doc:"Maximum number of members to return"`
+}
+
+type ListMembersResponse struct {
+	Body ListMembersResponseBody
+}
+
+type ListMembersResponseBody struct {
+	Members   []store.ListedMember `json:"members" doc:"Members ordered by zID"`
+	NextAfter string               `json:"next_after,omitempty" doc:"Pass as after to get the next page. Not set on the last page."`
+}
+
+type CountMembersInput struct {
+	ElectionId string `path:"election_id" format:"uuid" doc:"Election ID"`
+	Search     string `query:"search" maxLength:"100" doc:"Only count members whose zID, name or email contains this, ignoring case"`
+}
+
+type CountMembersResponse struct {
+	Body CountMembersResponseBody
+}
+
+type CountMembersResponseBody struct {
+	Count int `json:"count" example:"120"`
+}
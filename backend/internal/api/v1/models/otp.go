@@ -3,11 +3,13 @@ package models
 import (
 	"net/http"
 	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/store"
 )
 
 type GenerateOTPInput struct {
 	Body struct {
-		Zid string `json:"zid" doc:"User zID" pattern:"^z[0-9]{7}$" example:"z0000000"`
+		Zid string `json:"zid" doc:"User zID" example:"z0000000"`
 	}
 }
 
@@ -16,11 +18,17 @@ type GenerateOTPResponse struct {
 
 type SubmitOTPInput struct {
 	Body struct {
-		Zid string `json:"zid" doc:"User zID" pattern:"^z[0-9]{7}$" example:"z0000000"`
+		Zid string `json:"zid" doc:"User zID" example:"z0000000"`
 		Otp string `json:"otp" doc:"OTP Code" pattern:"^[0-9]{6}$" example:"123123"`
 	}
 }
 
+type SubmitOTPLinkInput struct {
+	Body struct {
+		Token string `json:"token" doc:"Token from the link in an OTP email" maxLength:"128"`
+	}
+}
+
 type SubmitOTPResponse struct {
 	SetCookie http.Cookie `header:"Set-Cookie"`
 
@@ -28,7 +36,9 @@ type SubmitOTPResponse struct {
 }
 
 type SubmitOTPResponseBody struct {
-	Zid     string    `json:"zid" doc:"User zID" pattern:"^z[0-9]{7}$" example:"z0000000"`
+	Zid     string    `json:"zid" doc:"User zID" example:"z0000000"`
 	Expiry  time.Time `json:"expiry" format:"date-time" example:"2024-01-15T10:30:00Z" doc:"Timestamp when your session expires."`
 	IsAdmin bool      `json:"is_admin" doc:"Admin status"`
+
+	Role *store.AdminRole `json:"role,omitempty" enum:"RETURNING_OFFICER,ELECTION_MANAGER,SCRUTINEER" doc:"Admin role, which decides what admin operations are allowed. Only set for admins."`
 }
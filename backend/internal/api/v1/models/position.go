@@ -0,0 +1,50 @@
+package models
+
+import (
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type SetElectionPositionsInput struct {
+	ElectionId string `path:"election_id" format:"uuid" doc:"Election ID"`
+
+	Body struct {
+		Positions []store.Position `json:"positions" minItems:"1" doc:"Positions that can be nominated for and voted on, in the order they should be shown. Replaces all existing positions."`
+	}
+}
+
+func (b *SetElectionPositionsInput) Resolve(ctx huma.Context) []error {
+	return resolvePositions(b.Body.Positions)
+}
+
+type SetElectionPositionsResponse struct {
+}
+
+type GetPositionsResponse struct {
+	Body GetPositionsResponseBody
+}
+
+type GetPositionsResponseBody struct {
+	ElectionID string           `json:"election_id" doc:"Election ID"`
+	Positions  []store.Position `json:"positions" doc:"Positions in the current election, in the order they should be shown"`
+}
+
+// position keys must be unique within an election
+func resolvePositions(positions []store.Position) []error {
+	var errors []error
+
+	seen := make(map[string]struct{}, len(positions))
+	for _, position := range positions {
+		if _, ok := seen[position.Key]; ok {
+			errors = append(errors, &huma.ErrorDetail{
+				Message:  "position defined more than once",
+				Location: "body.positions",
+				Value:    position.Key,
+			})
+			continue
+		}
+		seen[position.Key] = struct{}{}
+	}
+
+	return errors
+}
@@ -0,0 +1,30 @@
+package models
+
+import "github.com/linuxunsw/vote/backend/internal/store"
+
+type GetResultsResponse struct {
+	Body store.ElectionResults
+}
+
+type GetBulletinResponse struct {
+	Body Bulletin
+}
+
+type Bulletin struct {
+	ElectionID string           `json:"election_id" doc:"Election ID"`
+	PublicKey  string           `json:"public_key" doc:"Hex encoded ed25519 public key that vote receipts are signed with"`
+	Ballots    []BulletinBallot `json:"ballots" doc:"Every counted ballot, ordered by receipt hash"`
+}
+
+type BulletinBallot struct {
+	ReceiptHash string              `json:"receipt_hash" doc:"Hex encoded receipt hash. Empty for ballots cast before receipts were issued."`
+	Positions   map[string][]string `json:"positions" doc:"A map from categories to public nomination IDs ranked in order of preference, first preference first."`
+}
+
+type GetReceiptKeyResponse struct {
+	Body ReceiptKey
+}
+
+type ReceiptKey struct {
+	PublicKey string `json:"public_key" doc:"Hex encoded ed25519 public key that vote receipts are signed with"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type SetElectionScheduleInput struct {
+	ElectionId string `path:"election_id" format:"uuid" doc:"Election ID"`
+
+	Body struct {
+		Transitions []store.ScheduleEntry `json:"transitions" doc:"State transitions to run automatically. Replaces all pending transitions, an empty list cancels them."`
+	}
+}
+
+// each state can only be scheduled once
+func (b *SetElectionScheduleInput) Resolve(ctx huma.Context) []error {
+	var errors []error
+
+	seen := make(map[store.ElectionState]struct{}, len(b.Body.Transitions))
+	for _, transition := range b.Body.Transitions {
+		if _, ok := seen[transition.State]; ok {
+			errors = append(errors, &huma.ErrorDetail{
+				Message:  "state scheduled more than once",
+				Location: "body.transitions",
+				Value:    transition.State,
+			})
+			continue
+		}
+		seen[transition.State] = struct{}{}
+	}
+
+	return errors
+}
+
+type SetElectionScheduleResponse struct {
+}
+
+type GetElectionScheduleInput struct {
+	ElectionId string `path:"election_id" format:"uuid" doc:"Election ID"`
+}
+
+type GetElectionScheduleResponse struct {
+	Body GetElectionScheduleResponseBody
+}
+
+type GetElectionScheduleResponseBody struct {
+	Transitions []store.ScheduledTransition `json:"transitions" doc:"Scheduled transitions ordered by run time, including ones that have already run"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type LogoutResponse struct {
+	SetCookie http.Cookie `header:"Set-Cookie"`
+}
+
+type RefreshSessionResponse struct {
+	SetCookie http.Cookie `header:"Set-Cookie"`
+
+	Body RefreshSessionResponseBody
+}
+
+type RefreshSessionResponseBody struct {
+	Zid       string    `json:"zid" doc:"User zID" example:"z0000000"`
+	Expiry    time.Time `json:"expiry" format:"date-time" example:"2024-01-15T10:30:00Z" doc:"Timestamp when your session expires."`
+	MaxExpiry time.Time `json:"max_expiry" format:"date-time" example:"2024-01-15T18:00:00Z" doc:"Timestamp after which your session can't be refreshed, and you must sign in again."`
+	IsAdmin   bool      `json:"is_admin" doc:"Admin status"`
+
+	Role *store.AdminRole `json:"role,omitempty" enum:"RETURNING_OFFICER,ELECTION_MANAGER,SCRUTINEER" doc:"Admin role, which decides what admin operations are allowed. Only set for admins."`
+}
+
+type RevokeSessionsInput struct {
+	Zid string `path:"zid" doc:"User zID" example:"z0000000"`
+}
+
+type RevokeSessionsResponse struct {
+	Body RevokeSessionsResponseBody
+}
+
+type RevokeSessionsResponseBody struct {
+	Revoked int `json:"revoked" doc:"Number of active sessions that were revoked" example:"1"`
+}
@@ -1,52 +1,93 @@
 package models
 
 import (
+	"encoding/hex"
+	"slices"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/linuxunsw/vote/backend/internal/receipt"
+	"github.com/linuxunsw/vote/backend/internal/store"
 )
 
 type SubmitVoteInput struct {
 	Body SubmitVoteBody
 }
 
-// president,secretary,treasurer,arc_delegate,edi_officer,grievance_officer
-
-var validPositions = map[string]struct{}{
-	"president":         {},
-	"secretary":         {},
-	"treasurer":         {},
-	"arc_delegate":      {},
-	"edi_officer":       {},
-	"grievance_officer": {},
-}
-
 type SubmitVoteBody struct {
-	Positions map[string]string `json:"positions" doc:"A map from categories to public nomination IDs. Find these by accessing your ballot." example:"{\"president\":\"01996ae6-31e5-7bc6-bac4-399ffc8c80de\",\"secretary\":\"01996ae6-31e5-7bc6-bac4-399ffc8c80de\"}"`
+	Positions map[string][]string `json:"positions" doc:"A map from categories to public nomination IDs ranked in order of preference, first preference first. Ranking is optional, a single ID is a valid vote. RON can be ranked like a candidate, and ABSTAIN must be the only choice for a position. Find these by accessing your ballot." example:"{\"president\":[\"01996ae6-31e5-7bc6-bac4-399ffc8c80de\",\"01996ae6-5a1b-7c3e-a1d2-0b8c9f6e4d21\"],\"secretary\":[\"01996ae6-31e5-7bc6-bac4-399ffc8c80de\"]}"`
 }
 
 func (b *SubmitVoteInput) Resolve(ctx huma.Context) []error {
 	var errors []error
 
-	for position := range b.Body.Positions {
-		if _, ok := validPositions[position]; !ok {
+	// positions are defined per election, so they are validated by the handler
+	for position, preferences := range b.Body.Positions {
+		if len(preferences) == 0 {
+			errors = append(errors, &huma.ErrorDetail{
+				Message:  "at least one preference is required, omit the position to not vote for it",
+				Location: "body.positions." + position,
+				Value:    preferences,
+			})
+			continue
+		}
+
+		if len(preferences) > 1 && slices.Contains(preferences, store.ChoiceAbstain) {
 			errors = append(errors, &huma.ErrorDetail{
-				Message:  "invalid position",
-				Location: "body.positions",
-				Value:    position,
+				Message:  "abstain cannot be ranked with other choices",
+				Location: "body.positions." + position,
+				Value:    preferences,
 			})
+			continue
+		}
+
+		// a candidate can only be ranked once
+		seen := make(map[string]struct{}, len(preferences))
+		for _, nominationId := range preferences {
+			if _, ok := seen[nominationId]; ok {
+				errors = append(errors, &huma.ErrorDetail{
+					Message:  "candidate ranked more than once",
+					Location: "body.positions." + position,
+					Value:    nominationId,
+				})
+				break
+			}
+			seen[nominationId] = struct{}{}
 		}
 	}
 
 	return errors
 }
 
+type SubmitVoteResponse struct {
+	Body VoteReceipt
+}
+
+// Proof of a submitted vote. Once results are published the receipt hash is listed on the
+// bulletin next to the ballot it was counted as.
+type VoteReceipt struct {
+	ElectionID  string `json:"election_id" doc:"Election ID"`
+	ReceiptHash string `json:"receipt_hash" doc:"Hex encoded SHA-256 of the nonce followed by the JSON encoded positions, with object keys sorted"`
+	Nonce       string `json:"nonce" doc:"Hex encoded random nonce mixed into the receipt hash. Keep this private."`
+	Signature   string `json:"signature" doc:"Hex encoded ed25519 signature of \"vote-receipt:<election_id>:<receipt_hash>\", verifiable with the receipt public key"`
+}
+
+func FromReceipt(r receipt.Receipt) VoteReceipt {
+	return VoteReceipt{
+		ElectionID:  r.ElectionID,
+		ReceiptHash: hex.EncodeToString(r.Hash),
+		Nonce:       hex.EncodeToString(r.Nonce),
+		Signature:   hex.EncodeToString(r.Signature),
+	}
+}
+
 type GetVoteResponse struct {
 	Body Vote
 }
 
 type Vote struct {
-	Positions map[string]string `json:"positions" doc:"A map from categories to public nomination IDs. Find these by accessing your ballot." example:"{\"president\":\"01996ae6-31e5-7bc6-bac4-399ffc8c80de\",\"secretary\":\"01996ae6-31e5-7bc6-bac4-399ffc8c80de\"}"`
-	CreatedAt time.Time         `json:"created_at" format:"date-time" example:"2024-01-15T10:30:00Z"`
-	UpdatedAt time.Time         `json:"updated_at" format:"date-time" example:"2024-01-15T10:30:00Z"`
+	Positions   map[string][]string `json:"positions" doc:"A map from categories to public nomination IDs ranked in order of preference, first preference first. Null in a secret ballot election once voting has closed, as the vote can no longer be linked to you." example:"{\"president\":[\"01996ae6-31e5-7bc6-bac4-399ffc8c80de\",\"01996ae6-5a1b-7c3e-a1d2-0b8c9f6e4d21\"],\"secretary\":[\"01996ae6-31e5-7bc6-bac4-399ffc8c80de\"]}"`
+	ReceiptHash string              `json:"receipt_hash,omitempty" doc:"Hex encoded receipt hash of the vote, as listed on the bulletin"`
+	CreatedAt   time.Time           `json:"created_at" format:"date-time" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt   time.Time           `json:"updated_at" format:"date-time" example:"2024-01-15T10:30:00Z"`
 }
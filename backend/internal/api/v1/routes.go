@@ -3,15 +3,22 @@ package v1
 import (
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/alexliesenfeld/health"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/handlers"
 	"github.com/linuxunsw/vote/backend/internal/api/v1/middleware"
+	"github.com/linuxunsw/vote/backend/internal/api/v1/models"
+	"github.com/linuxunsw/vote/backend/internal/broker"
 	"github.com/linuxunsw/vote/backend/internal/config"
-	"github.com/linuxunsw/vote/backend/internal/mailer"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/jwtkeys"
+	"github.com/linuxunsw/vote/backend/internal/outbox"
+	"github.com/linuxunsw/vote/backend/internal/receipt"
 	"github.com/linuxunsw/vote/backend/internal/store"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/sse"
 )
 
 type HandlerDependencies struct {
@@ -19,14 +26,40 @@ type HandlerDependencies struct {
 
 	Cfg config.Config
 
-	Mailer  mailer.Mailer
 	Checker health.Checker
 
+	// queues emails and retries them if they fail
+	Outbox *outbox.Outbox
+
+	// signs vote receipts
+	ReceiptSigner *receipt.Signer
+
+	// signs and verifies session tokens
+	SessionKeys *jwtkeys.KeySet
+
+	// publishes election state changes to stream clients
+	StateBroker *broker.Broker
+
+	// validates zIDs and decides where members are emailed
+	Identity identity.Provider
+
+	// the society's time zone, see config.ElectionConfig.TimeZone
+	Location *time.Location
+
 	// Stores
 	OtpStore        store.OTPStore
 	ElectionStore   store.ElectionStore
 	NominationStore store.NominationStore
 	BallotStore     store.BallotStore
+	ResultsStore    store.ResultsStore
+	PositionStore   store.PositionStore
+	AuditStore      store.AuditStore
+	ScheduleStore   store.ScheduleStore
+	DashboardStore  store.DashboardStore
+	AdminStore      store.AdminStore
+	SessionStore    store.SessionStore
+	APITokenStore   store.APITokenStore
+	EmailStore      store.EmailOutboxStore
 }
 
 // Register mounts all the API v1 routes using Huma groups and middleware.
@@ -42,6 +75,16 @@ func Register(api huma.API, deps HandlerDependencies) {
 		Hidden: true,
 	}, handlers.GetHealth(deps.Checker))
 
+	// outside /api/v1 so other services can find it at the usual place
+	huma.Register(api, huma.Operation{
+		OperationID: "get-jwks",
+		Method:      http.MethodGet,
+		Path:        "/.well-known/jwks.json",
+		Summary:     "Get the public keys session tokens are signed with",
+		Description: "A JSON Web Key Set of every key session tokens are currently accepted from. Tokens name the key they were signed with in their kid header.",
+		Tags:        []string{"Auth"},
+	}, handlers.GetJWKS(deps.Logger, deps.SessionKeys))
+
 	// deps for election state middleware
 	esDeps := middleware.RequireElectionStateDeps{
 		API:           api,
@@ -56,7 +99,7 @@ func Register(api huma.API, deps HandlerDependencies) {
 		Path:        "/otp/generate",
 		Summary:     "Generate an OTP code",
 		Tags:        []string{"OTP"},
-	}, handlers.GenerateOTP(deps.Logger, deps.OtpStore, deps.Mailer))
+	}, handlers.GenerateOTP(deps.Logger, deps.OtpStore, deps.ElectionStore, deps.DashboardStore, deps.Outbox, deps.Identity, deps.Cfg.OTP))
 
 	huma.Register(v1, huma.Operation{
 		OperationID: "submit-otp",
@@ -64,31 +107,103 @@ func Register(api huma.API, deps HandlerDependencies) {
 		Path:        "/otp/submit",
 		Summary:     "Submit an OTP to enter a session",
 		Tags:        []string{"OTP"},
-	}, handlers.SubmitOTP(deps.Logger, deps.OtpStore, deps.ElectionStore, deps.Cfg.JWT, deps.Cfg.Admin))
+	}, handlers.SubmitOTP(deps.Logger, deps.OtpStore, deps.ElectionStore, deps.AuditStore, deps.DashboardStore, deps.AdminStore, deps.SessionStore, deps.SessionKeys, deps.Identity, deps.Cfg.JWT))
+
+	// POST rather than GET, so email link scanners opening the page don't use up the link
+	huma.Register(v1, huma.Operation{
+		OperationID: "submit-otp-link",
+		Method:      http.MethodPost,
+		Path:        "/otp/link",
+		Summary:     "Submit the token from an OTP email's link to enter a session",
+		Tags:        []string{"OTP"},
+	}, handlers.SubmitOTPLink(deps.Logger, deps.OtpStore, deps.ElectionStore, deps.AuditStore, deps.DashboardStore, deps.AdminStore, deps.SessionStore, deps.SessionKeys, deps.Cfg.JWT))
+
+	// == Public Routes ==
+	// Anyone can check receipts against the bulletin, without logging in
+	huma.Register(v1, huma.Operation{
+		OperationID: "get-receipt-key",
+		Method:      http.MethodGet,
+		Path:        "/receipts/key",
+		Summary:     "Get the public key vote receipts are signed with",
+		Tags:        []string{"Results"},
+	}, handlers.GetReceiptKey(deps.ReceiptSigner))
+
+	huma.Register(v1, huma.Operation{
+		OperationID: "get-election-receipt-key",
+		Method:      http.MethodGet,
+		Path:        "/elections/{election_id}/receipts/key",
+		Summary:     "Get the public key an election's vote receipts were signed with",
+		Description: "The key the election's first ballot was signed with, or the current key if no ballots have been cast.",
+		Tags:        []string{"Results"},
+	}, handlers.GetElectionReceiptKey(deps.Logger, deps.ResultsStore, deps.ReceiptSigner))
+
+	// past elections aren't current, so are checked by the handler instead
+	huma.Register(v1, huma.Operation{
+		OperationID: "get-election-bulletin",
+		Method:      http.MethodGet,
+		Path:        "/elections/{election_id}/bulletin",
+		Summary:     "Get every ballot counted in any election",
+		Description: "Lists each counted ballot of a current or past election by its receipt hash once its results have been published, so voters can confirm their ballot was counted unchanged.",
+		Tags:        []string{"Results"},
+	}, handlers.GetElectionBulletin(deps.Logger, deps.ResultsStore, deps.ElectionStore, deps.ReceiptSigner))
+
+	bulletinRoutes := huma.NewGroup(v1)
+	bulletinRoutes.UseMiddleware(middleware.RequireElectionState(esDeps, store.StateResults, store.StateEnd))
+
+	huma.Register(bulletinRoutes, huma.Operation{
+		OperationID: "get-bulletin",
+		Method:      http.MethodGet,
+		Path:        "/results/bulletin",
+		Summary:     "Get every ballot counted in the current election",
+		Description: "Lists each counted ballot by its receipt hash, so voters can confirm their ballot was counted unchanged.",
+		Tags:        []string{"Results"},
+	}, handlers.GetBulletin(deps.Logger, deps.ResultsStore, deps.ElectionStore, deps.ReceiptSigner))
 
 	// == Authenticated Routes ==
-	// This group requires a valid JWT for all its routes.
-	userRoutes := huma.NewGroup(v1)
-	userRoutes.UseSimpleModifier(func(op *huma.Operation) {
+	// This group requires a valid JWT or API token for all its routes.
+	authRoutes := huma.NewGroup(v1)
+	authRoutes.UseSimpleModifier(func(op *huma.Operation) {
 		op.Security = []map[string][]string{
 			{"cookieAuth": {}},
+			{"bearerAuth": {}},
 		}
 	})
-	authMiddleware := middleware.CookieAuthenticator(api, deps.Cfg.JWT)
-	userRoutes.UseMiddleware(authMiddleware)
+	authMiddleware := middleware.Authenticator(api, deps.Logger, deps.Cfg.JWT, deps.SessionKeys, deps.SessionStore, deps.APITokenStore)
+	authRoutes.UseMiddleware(authMiddleware)
+
+	// This group acts as the signed in member, so API tokens can't use it.
+	userRoutes := huma.NewGroup(authRoutes)
+	userRoutes.UseMiddleware(middleware.RequireUserSession(api))
+
+	huma.Register(userRoutes, huma.Operation{
+		OperationID: "logout",
+		Method:      http.MethodPost,
+		Path:        "/logout",
+		Summary:     "Log out",
+		Description: "Ends the current session. The session token stops working immediately, even though it hasn't expired.",
+		Tags:        []string{"Auth"},
+	}, handlers.Logout(deps.Logger, deps.SessionStore, deps.Cfg.JWT))
+
+	huma.Register(userRoutes, huma.Operation{
+		OperationID: "refresh-session",
+		Method:      http.MethodPost,
+		Path:        "/refresh",
+		Summary:     "Refresh session",
+		Description: "Issues a new session cookie which expires later. Sessions can only be refreshed until their max expiry, after which you must sign in again.",
+		Tags:        []string{"Auth"},
+	}, handlers.RefreshSession(deps.Logger, deps.SessionStore, deps.AdminStore, deps.SessionKeys, deps.Cfg.JWT))
 
 	// state updates via SSE
-	/* sse.Register(userRoutes, huma.Operation{
-		OperationID: "state",
+	sse.Register(userRoutes, huma.Operation{
+		OperationID: "stream-election-state",
 		Method:      http.MethodGet,
-		Path:        "/state",
-		Summary:     "Get State (SSE)",
-		Description: "Gets current election state changes as server sent events.",
+		Path:        "/state/stream",
+		Summary:     "Stream election state changes",
+		Description: "Sends the current election state, then an event each time it changes.",
 		Tags:        []string{"State"},
 	}, map[string]any{
 		"stateChange": &models.StateChangeEvent{},
-	},
-		handlers.GetState(deps.Logger)) */
+	}, handlers.GetState(deps.Logger, deps.ElectionStore, deps.SessionStore, deps.StateBroker, deps.Cfg.JWT))
 
 	// election state
 	huma.Register(userRoutes, huma.Operation{
@@ -99,6 +214,14 @@ func Register(api huma.API, deps HandlerDependencies) {
 		Tags:        []string{"State"},
 	}, handlers.GetElectionState(deps.Logger, deps.ElectionStore))
 
+	huma.Register(userRoutes, huma.Operation{
+		OperationID: "get-positions",
+		Method:      http.MethodGet,
+		Path:        "/positions",
+		Summary:     "Get the positions in the current election",
+		Tags:        []string{"Positions"},
+	}, handlers.GetPositions(deps.Logger, deps.PositionStore, deps.ElectionStore))
+
 	// == Nomination Routes ==
 	// Group for nomination operations that REQUIRE nominations to be open
 	nominationRoutes := huma.NewGroup(userRoutes)
@@ -113,7 +236,7 @@ func Register(api huma.API, deps HandlerDependencies) {
 		Path:        "/nomination",
 		Summary:     "Submit self-nomination",
 		Description: "Creates a self-nomination for the current election, replacing an existing one.",
-	}, handlers.SubmitNomination(deps.Logger, deps.NominationStore, deps.ElectionStore))
+	}, handlers.SubmitNomination(deps.Logger, deps.NominationStore, deps.ElectionStore, deps.PositionStore, deps.AuditStore, deps.Outbox))
 
 	huma.Register(nominationRoutes, huma.Operation{
 		OperationID: "delete-nomination",
@@ -121,7 +244,7 @@ func Register(api huma.API, deps HandlerDependencies) {
 		Path:        "/nomination",
 		Summary:     "Delete self-nomination",
 		Description: "Deletes an existing self-nomination for the current election. If an election is running, this route will always return as it succeeded even if a nomination did not exist.",
-	}, handlers.DeleteNomination(deps.Logger, deps.NominationStore, deps.ElectionStore))
+	}, handlers.DeleteNomination(deps.Logger, deps.NominationStore, deps.ElectionStore, deps.AuditStore, deps.Outbox))
 
 	// Nomination read operations (no state restriction needed)
 	huma.Register(userRoutes, huma.Operation{
@@ -154,7 +277,7 @@ func Register(api huma.API, deps HandlerDependencies) {
 		Method:      "PUT",
 		Path:        "/vote",
 		Summary:     "Submit or update your current vote",
-	}, handlers.SubmitVote(deps.Logger, deps.BallotStore, deps.ElectionStore, deps.NominationStore))
+	}, handlers.SubmitVote(deps.Logger, deps.BallotStore, deps.ElectionStore, deps.NominationStore, deps.PositionStore, deps.ReceiptSigner, deps.Outbox, deps.Identity))
 
 	huma.Register(votingRoutes, huma.Operation{
 		OperationID: "delete-vote",
@@ -178,50 +301,255 @@ func Register(api huma.API, deps HandlerDependencies) {
 		Path:        "/ballot",
 		Summary:     "Get the current ballot",
 		Tags:        []string{"Voting"},
-	}, handlers.GetBallot(deps.Logger, deps.BallotStore, deps.ElectionStore, deps.NominationStore))
+	}, handlers.GetBallot(deps.Logger, deps.BallotStore, deps.ElectionStore, deps.NominationStore, deps.PositionStore))
+
+	// == Results Routes ==
+	// Group for results, only available once they have been published
+	resultsRoutes := huma.NewGroup(userRoutes)
+	resultsRoutes.UseSimpleModifier(func(op *huma.Operation) {
+		op.Tags = []string{"Results"}
+	})
+	resultsRoutes.UseMiddleware(middleware.RequireElectionState(esDeps, store.StateResults, store.StateEnd))
+
+	huma.Register(resultsRoutes, huma.Operation{
+		OperationID: "get-results",
+		Method:      http.MethodGet,
+		Path:        "/results",
+		Summary:     "Get the results of the current election",
+		Description: "Tallies all submitted ballots, returning vote counts and winners for each position along with turnout.",
+	}, handlers.GetResults(deps.Logger, deps.ResultsStore, deps.ElectionStore))
+
+	// past elections aren't current, so are checked by the handler instead
+	huma.Register(userRoutes, huma.Operation{
+		OperationID: "get-election-results",
+		Method:      http.MethodGet,
+		Path:        "/elections/{election_id}/results",
+		Summary:     "Get the results of any election",
+		Description: "Tallies the ballots of a current or past election once its results have been published.",
+		Tags:        []string{"Results"},
+	}, handlers.GetElectionResults(deps.Logger, deps.ResultsStore, deps.ElectionStore))
 
 	// == Admin Routes ==
-	// This group requires a valid JWT AND admin privileges.
-	adminRoutes := huma.NewGroup(userRoutes)
+	// These groups require a valid JWT AND an admin role granting the group's permission,
+	// or an API token scoped to it.
+	adminRoutes := huma.NewGroup(authRoutes)
 	adminRoutes.UseSimpleModifier(func(op *huma.Operation) {
 		op.Tags = []string{"Admin"}
 	})
-	adminRoutes.UseMiddleware(middleware.RequireAdmin(api))
 
-	huma.Register(adminRoutes, huma.Operation{
+	// Group for admin operations that only read, scrutineers can use these
+	adminViewRoutes := huma.NewGroup(adminRoutes)
+	adminViewRoutes.UseMiddleware(middleware.RequirePermission(api, deps.Logger, deps.AdminStore, store.PermissionViewElections))
+
+	huma.Register(adminViewRoutes, huma.Operation{
+		OperationID: "list-elections",
+		Method:      http.MethodGet,
+		Path:        "/elections",
+		Summary:     "List elections",
+		Description: "Lists current and past elections a page at a time, newest first.",
+	}, handlers.ListElections(deps.Logger, deps.ElectionStore))
+
+	huma.Register(adminViewRoutes, huma.Operation{
+		OperationID: "get-election",
+		Method:      http.MethodGet,
+		Path:        "/elections/{election_id}",
+		Summary:     "Get an election",
+	}, handlers.GetElection(deps.Logger, deps.ElectionStore))
+
+	huma.Register(adminViewRoutes, huma.Operation{
+		OperationID: "get-election-nominations",
+		Method:      http.MethodGet,
+		Path:        "/elections/{election_id}/nominations",
+		Summary:     "Get every nomination in an election",
+		Description: "Includes the candidate's zID and contact details, unlike public nominations.",
+	}, handlers.GetElectionNominations(deps.Logger, deps.ElectionStore, deps.NominationStore))
+
+	huma.Register(adminViewRoutes, huma.Operation{
+		OperationID: "list-election-members",
+		Method:      http.MethodGet,
+		Path:        "/elections/{election_id}/members",
+		Summary:     "List the members of an election",
+		Description: "Lists members a page at a time, ordered by zID. Use `search` to find members by zID, name or email.",
+	}, handlers.ListMembers(deps.Logger, deps.ElectionStore))
+
+	huma.Register(adminViewRoutes, huma.Operation{
+		OperationID: "count-election-members",
+		Method:      http.MethodGet,
+		Path:        "/elections/{election_id}/members/count",
+		Summary:     "Count the members of an election",
+	}, handlers.CountMembers(deps.Logger, deps.ElectionStore))
+
+	huma.Register(adminViewRoutes, huma.Operation{
+		OperationID: "get-election-schedule",
+		Method:      http.MethodGet,
+		Path:        "/elections/{election_id}/schedule",
+		Summary:     "Get the scheduled state transitions for an election",
+	}, handlers.GetElectionSchedule(deps.Logger, deps.ScheduleStore))
+
+	huma.Register(adminViewRoutes, huma.Operation{
+		OperationID: "get-audit-log",
+		Method:      http.MethodGet,
+		Path:        "/audit",
+		Summary:     "Get the audit log",
+		Description: "Lists admin and election actions, oldest first. Entries are hash chained, so changes to earlier entries can be detected with `vote-api audit verify`.",
+	}, handlers.GetAuditLog(deps.Logger, deps.AuditStore))
+
+	sse.Register(adminViewRoutes, huma.Operation{
+		OperationID: "admin-dashboard-stream",
+		Method:      http.MethodGet,
+		Path:        "/dashboard/stream",
+		Summary:     "Stream live updates for the admin dashboard",
+		Description: "Sends turnout, nomination counts, sign in activity and active sessions for the current election every few seconds.",
+	}, map[string]any{
+		"dashboard": &models.DashboardEvent{},
+	}, handlers.DashboardStream(deps.Logger, deps.ElectionStore, deps.DashboardStore, deps.SessionStore, deps.Cfg.Dashboard))
+
+	huma.Register(adminViewRoutes, huma.Operation{
+		OperationID: "list-admins",
+		Method:      http.MethodGet,
+		Path:        "/admins",
+		Summary:     "List admins and their roles",
+	}, handlers.ListAdmins(deps.Logger, deps.AdminStore))
+
+	huma.Register(adminViewRoutes, huma.Operation{
+		OperationID: "list-emails",
+		Method:      http.MethodGet,
+		Path:        "/emails",
+		Summary:     "List emails in the outbox",
+		Description: "Lists dead lettered emails by default, which failed too many times or expired before they could be sent. Use `status` to see pending or sent emails. Email contents aren't shown, as they may hold OTP codes.",
+	}, handlers.ListEmails(deps.Logger, deps.EmailStore))
+
+	// Group for admin operations that change elections
+	adminManageRoutes := huma.NewGroup(adminRoutes)
+	adminManageRoutes.UseMiddleware(middleware.RequirePermission(api, deps.Logger, deps.AdminStore, store.PermissionManageElections))
+
+	huma.Register(adminManageRoutes, huma.Operation{
 		OperationID: "create-election",
 		Method:      http.MethodPost,
 		Path:        "/elections",
 		Summary:     "Create an election",
-	}, handlers.CreateElection(deps.Logger, deps.ElectionStore))
+	}, handlers.CreateElection(deps.Logger, deps.ElectionStore, deps.AuditStore, deps.Cfg.Election))
 
-	huma.Register(adminRoutes, huma.Operation{
+	huma.Register(adminManageRoutes, huma.Operation{
 		OperationID: "set-election-members",
 		Method:      http.MethodPut,
 		Path:        "/elections/{election_id}/members",
 		Summary:     "Set the member list for an election",
-	}, handlers.ElectionMemberListSet(deps.Logger, deps.ElectionStore))
+	}, handlers.ElectionMemberListSet(deps.Logger, deps.ElectionStore, deps.AuditStore))
+
+	huma.Register(adminManageRoutes, huma.Operation{
+		OperationID:  "admin-upload-members",
+		Method:       http.MethodPost,
+		Path:         "/elections/{election_id}/members/upload",
+		Summary:      "Upload a CSV of members",
+		Description:  "Replaces the member list for an election with the members in an Arc/Rubric membership export. Rows with an invalid zID, an expired membership or a repeated zID are reported and left out. Use `dry_run` to preview the changes first.",
+		MaxBodyBytes: 10 * 1024 * 1024,
+	}, handlers.UploadMembers(deps.Logger, deps.ElectionStore, deps.AuditStore, deps.Identity, deps.Location))
 
-	huma.Register(adminRoutes, huma.Operation{
+	huma.Register(adminManageRoutes, huma.Operation{
+		OperationID:   "add-election-member",
+		Method:        http.MethodPost,
+		Path:          "/elections/{election_id}/members",
+		Summary:       "Add a member to an election",
+		DefaultStatus: http.StatusCreated,
+	}, handlers.AddMember(deps.Logger, deps.ElectionStore, deps.AuditStore, deps.Identity))
+
+	huma.Register(adminManageRoutes, huma.Operation{
+		OperationID: "remove-election-member",
+		Method:      http.MethodDelete,
+		Path:        "/elections/{election_id}/members/{zid}",
+		Summary:     "Remove a member from an election",
+	}, handlers.RemoveMember(deps.Logger, deps.ElectionStore, deps.AuditStore, deps.Identity))
+
+	huma.Register(adminManageRoutes, huma.Operation{
 		OperationID: "admin-transition-election-state",
 		Method:      "PUT",
 		Path:        "/state",
 		Summary:     "Transition the election state",
-	}, handlers.TransitionElectionState(deps.Logger, deps.ElectionStore))
-
-	// huma.Register(adminRoutes, huma.Operation{
-	// 	OperationID: "admin-upload-members",
-	// 	Method:      "POST",
-	// 	Path:        "/members/upload",
-	// 	Summary:     "Upload a CSV of members",
-	// 	Tags:        []string{"Admin"},
-	// }, handlers.UploadMembers(store))
-	//
-	// huma.Register(adminRoutes, huma.Operation{
-	// 	OperationID: "admin-dashboard-stream",
-	// 	Method:      "GET",
-	// 	Path:        "/dashboard/stream",
-	// 	Summary:     "Stream live updates for the admin dashboard",
-	// 	Tags:        []string{"Admin"},
-	// }, handlers.DashboardStream(store))
+	}, handlers.TransitionElectionState(deps.Logger, deps.ElectionStore, deps.AuditStore, deps.Outbox))
+
+	huma.Register(adminManageRoutes, huma.Operation{
+		OperationID: "set-election-schedule",
+		Method:      http.MethodPut,
+		Path:        "/elections/{election_id}/schedule",
+		Summary:     "Schedule state transitions for an election",
+		Description: "Replaces the pending state transitions for an election. Each transition runs automatically once its time has passed, as if an admin had transitioned the state.",
+	}, handlers.SetElectionSchedule(deps.Logger, deps.ScheduleStore, deps.AuditStore))
+
+	huma.Register(adminManageRoutes, huma.Operation{
+		OperationID: "revoke-sessions",
+		Method:      http.MethodDelete,
+		Path:        "/sessions/{zid}",
+		Summary:     "Revoke every session for a zID",
+		Description: "Signs a zID out everywhere, for when an account may be compromised. They can sign in again with a new code.",
+	}, handlers.RevokeSessions(deps.Logger, deps.SessionStore, deps.AuditStore, deps.Identity))
+
+	huma.Register(adminManageRoutes, huma.Operation{
+		OperationID: "retry-email",
+		Method:      http.MethodPost,
+		Path:        "/emails/{email_id}/retry",
+		Summary:     "Retry a dead lettered email",
+		Description: "Queues the email to be sent again on the outbox's next check, with its attempts reset. Emails that have expired can't be retried, nor can OTP emails, as their code is cleared once dead lettered. Members can request a new code instead.",
+	}, handlers.RetryEmail(deps.Logger, deps.EmailStore, deps.AuditStore))
+
+	// Group for admin operations that REQUIRE the election to be closed
+	adminClosedRoutes := huma.NewGroup(adminManageRoutes)
+	adminClosedRoutes.UseMiddleware(middleware.RequireElectionState(esDeps, store.StateClosed))
+
+	huma.Register(adminClosedRoutes, huma.Operation{
+		OperationID: "set-election-positions",
+		Method:      http.MethodPut,
+		Path:        "/elections/{election_id}/positions",
+		Summary:     "Set the positions for an election",
+		Description: "Replaces the positions that can be nominated for and voted on. Positions can only be changed before nominations open.",
+	}, handlers.SetElectionPositions(deps.Logger, deps.PositionStore, deps.AuditStore))
+
+	// Group for managing who can administer elections
+	adminRolesRoutes := huma.NewGroup(adminRoutes)
+	adminRolesRoutes.UseMiddleware(middleware.RequirePermission(api, deps.Logger, deps.AdminStore, store.PermissionManageAdmins))
+
+	huma.Register(adminRolesRoutes, huma.Operation{
+		OperationID: "grant-admin-role",
+		Method:      http.MethodPut,
+		Path:        "/admins/{zid}",
+		Summary:     "Grant an admin role",
+		Description: "Grants a role to a zID, replacing any role they already have. Returning officers can do everything, election managers can do everything but manage admins, and scrutineers can only view.",
+	}, handlers.GrantAdminRole(deps.Logger, deps.AdminStore, deps.AuditStore, deps.Identity))
+
+	huma.Register(adminRolesRoutes, huma.Operation{
+		OperationID: "revoke-admin-role",
+		Method:      http.MethodDelete,
+		Path:        "/admins/{zid}",
+		Summary:     "Revoke an admin role",
+		Description: "The last returning officer can't be revoked or given another role.",
+	}, handlers.RevokeAdminRole(deps.Logger, deps.AdminStore, deps.AuditStore))
+
+	// API tokens can't create more tokens, even when scoped to manage admins
+	apiTokenRoutes := huma.NewGroup(adminRolesRoutes)
+	apiTokenRoutes.UseMiddleware(middleware.RequireUserSession(api))
+
+	huma.Register(apiTokenRoutes, huma.Operation{
+		OperationID:   "create-api-token",
+		Method:        http.MethodPost,
+		Path:          "/api-tokens",
+		Summary:       "Create an API token",
+		Description:   "Creates a long-lived token for scripts and services, sent as \"Authorization: Bearer <token>\". Requests made with it act as you, but can only use its scopes.",
+		DefaultStatus: http.StatusCreated,
+	}, handlers.CreateAPIToken(deps.Logger, deps.APITokenStore, deps.AdminStore, deps.AuditStore))
+
+	huma.Register(apiTokenRoutes, huma.Operation{
+		OperationID: "list-api-tokens",
+		Method:      http.MethodGet,
+		Path:        "/api-tokens",
+		Summary:     "List API tokens",
+		Description: "Tokens themselves are only shown when they are created.",
+	}, handlers.ListAPITokens(deps.Logger, deps.APITokenStore))
+
+	huma.Register(apiTokenRoutes, huma.Operation{
+		OperationID: "revoke-api-token",
+		Method:      http.MethodDelete,
+		Path:        "/api-tokens/{token_id}",
+		Summary:     "Revoke an API token",
+		Description: "The token stops working immediately.",
+	}, handlers.RevokeAPIToken(deps.Logger, deps.APITokenStore, deps.AuditStore))
 }
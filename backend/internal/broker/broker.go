@@ -0,0 +1,120 @@
+package broker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+// number of changes a subscriber can fall behind by before older ones are dropped
+const subscriberBuffer = 8
+
+// Fans election state changes from every API process out to subscribers in this one,
+// e.g. clients connected to the state stream.
+type Broker struct {
+	log       *slog.Logger
+	listener  store.StateListener
+	elections store.ElectionStore
+
+	// how long to wait before listening again after the connection is lost
+	RetryInterval time.Duration
+
+	mu          sync.Mutex
+	subscribers map[chan store.StateChange]struct{}
+}
+
+func New(log *slog.Logger, listener store.StateListener, elections store.ElectionStore) *Broker {
+	return &Broker{
+		log:       log,
+		listener:  listener,
+		elections: elections,
+
+		RetryInterval: 5 * time.Second,
+
+		subscribers: make(map[chan store.StateChange]struct{}),
+	}
+}
+
+// Listens for state changes and publishes them until ctx is cancelled, listening again
+// if the connection is lost.
+func (b *Broker) Run(ctx context.Context) {
+	reconnecting := false
+	for {
+		err := b.listener.ListenStateChanges(ctx, func() {
+			// changes made while we weren't listening were missed, so catch subscribers up
+			if reconnecting {
+				b.publishCurrent(ctx)
+			}
+		}, b.Publish)
+		if ctx.Err() != nil {
+			return
+		}
+		b.log.Error("lost connection listening for state changes", "error", err, "retry_in", b.RetryInterval)
+		reconnecting = true
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(b.RetryInterval):
+		}
+	}
+}
+
+func (b *Broker) publishCurrent(ctx context.Context) {
+	election, err := b.elections.CurrentElection(ctx)
+	if err != nil {
+		b.log.Error("failed to get current election", "error", err)
+		return
+	}
+	if election == nil {
+		return
+	}
+	b.Publish(store.StateChange{
+		ElectionID: election.ElectionID,
+		State:      election.State,
+	})
+}
+
+// Sends a state change to every subscriber. Subscribers that have fallen behind lose
+// their oldest change rather than blocking the others.
+func (b *Broker) Publish(change store.StateChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- change:
+			continue
+		default:
+		}
+
+		// full, make room for the latest change
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+// Subscribe to state changes. The returned function must be called to unsubscribe once
+// the subscriber stops reading.
+func (b *Broker) Subscribe() (<-chan store.StateChange, func()) {
+	ch := make(chan store.StateChange, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
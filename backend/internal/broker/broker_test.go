@@ -0,0 +1,143 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type fakeListener struct {
+	changes chan store.StateChange
+	// the first connection is lost after forwarding this many changes
+	failAfter int
+	connects  int
+}
+
+func (l *fakeListener) ListenStateChanges(ctx context.Context, listening func(), fn func(store.StateChange)) error {
+	l.connects++
+	listening()
+
+	forwarded := 0
+	for {
+		if l.connects == 1 && forwarded == l.failAfter {
+			return errors.New("connection lost")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case change := <-l.changes:
+			fn(change)
+			forwarded++
+		}
+	}
+}
+
+type fakeElections struct {
+	store.ElectionStore
+	current *store.Election
+}
+
+func (e *fakeElections) CurrentElection(ctx context.Context) (*store.Election, error) {
+	return e.current, nil
+}
+
+func receive(t *testing.T, ch <-chan store.StateChange) store.StateChange {
+	t.Helper()
+	select {
+	case change := <-ch:
+		return change
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for state change")
+		return store.StateChange{}
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	b := New(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil)
+
+	first, unsubscribeFirst := b.Subscribe()
+	second, unsubscribeSecond := b.Subscribe()
+	defer unsubscribeSecond()
+
+	change := store.StateChange{ElectionID: "e1", State: store.StateVotingOpen}
+	b.Publish(change)
+	if got := receive(t, first); got != change {
+		t.Fatalf("expected %+v, got %+v", change, got)
+	}
+	if got := receive(t, second); got != change {
+		t.Fatalf("expected %+v, got %+v", change, got)
+	}
+
+	unsubscribeFirst()
+	b.Publish(store.StateChange{ElectionID: "e1", State: store.StateVotingClosed})
+	select {
+	case got := <-first:
+		t.Fatalf("expected no change after unsubscribing, got %+v", got)
+	default:
+	}
+}
+
+func TestPublishSlowSubscriber(t *testing.T) {
+	b := New(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil)
+	ch, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	states := []store.ElectionState{store.StateNominationsOpen, store.StateNominationsClosed}
+	for i := 0; i < subscriberBuffer; i++ {
+		b.Publish(store.StateChange{ElectionID: "e1", State: states[i%2]})
+	}
+	// doesn't block, the oldest change is dropped
+	b.Publish(store.StateChange{ElectionID: "e1", State: store.StateVotingOpen})
+
+	var last store.StateChange
+	for i := 0; i < subscriberBuffer; i++ {
+		last = receive(t, ch)
+	}
+	if last.State != store.StateVotingOpen {
+		t.Fatalf("expected the latest change to be kept, got %+v", last)
+	}
+}
+
+func TestRunReconnects(t *testing.T) {
+	listener := &fakeListener{changes: make(chan store.StateChange), failAfter: 1}
+	elections := &fakeElections{current: &store.Election{ElectionID: "e1", State: store.StateVotingClosed}}
+	b := New(slog.New(slog.NewTextHandler(io.Discard, nil)), listener, elections)
+	b.RetryInterval = time.Millisecond
+
+	ch, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		b.Run(ctx)
+		close(done)
+	}()
+
+	listener.changes <- store.StateChange{ElectionID: "e1", State: store.StateVotingOpen}
+	if got := receive(t, ch); got.State != store.StateVotingOpen {
+		t.Fatalf("expected VOTING_OPEN, got %+v", got)
+	}
+
+	// after reconnecting, the current state is published in case a change was missed
+	if got := receive(t, ch); got.State != store.StateVotingClosed {
+		t.Fatalf("expected VOTING_CLOSED, got %+v", got)
+	}
+
+	listener.changes <- store.StateChange{ElectionID: "e1", State: store.StateResults}
+	if got := receive(t, ch); got.State != store.StateResults {
+		t.Fatalf("expected RESULTS, got %+v", got)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Run to return once cancelled")
+	}
+}
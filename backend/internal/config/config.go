@@ -1,23 +1,33 @@
 package config
 
 import (
+	"strconv"
 	"strings"
 	"time"
 )
 
 type Config struct {
-	API      APIConfig
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	OTP      OTPConfig
-	Mailer   MailerConfig
-	Logger   LoggerConfig
-	Admin    AdminConfig
+	API       APIConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	OTP       OTPConfig
+	Mailer    MailerConfig
+	Identity  IdentityConfig
+	Logger    LoggerConfig
+	Admin     AdminConfig
+	Election  ElectionConfig
+	Receipt   ReceiptConfig
+	Scheduler SchedulerConfig
+	Dashboard DashboardConfig
+	Outbox    OutboxConfig
 }
 
 type APIConfig struct {
 	Version string
+	// allows running without the keys production needs, generating throwaway ones instead.
+	// never set this in production
+	Development bool
 }
 
 type ServerConfig struct {
@@ -38,10 +48,20 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret     string
-	CookieName string
-	Duration   time.Duration
-	Issuer     string
+	// PEM file with the ed25519 or P-256 private key new session tokens are signed with.
+	// if empty a key is generated on startup, so sessions end when the server restarts. only
+	// allowed in development
+	SigningKeyFile string
+	// PEM files with keys tokens are also accepted from. when rotating, list the previous
+	// signing key here until the tokens it signed have expired
+	VerificationKeyFiles []string
+	CookieName           string
+	Duration             time.Duration
+	Issuer               string
+	// sessions can be refreshed to extend them by Duration, up to this long after signing in
+	MaxLifetime time.Duration
+	// how often streams check their session is still active, closing once it isn't
+	SessionCheckInterval time.Duration
 }
 
 type OTPConfig struct {
@@ -51,13 +71,58 @@ type OTPConfig struct {
 
 	RatelimitCount  int
 	RatelimitWithin time.Duration
+
+	// page OTP emails link to, with a single-use token in its "token" query parameter that
+	// it submits to sign in. if empty, emails only contain the code
+	LinkURL string
 }
 
 type MailerConfig struct {
-	ResendAPIKey  string
-	FromEmail     string
-	// sets whether the development console "mailer" should be used (logs to console)
-	ConsoleMailer bool
+	// which mailer sends emails: "console" (development, logs to console), "resend" or "smtp".
+	// defaults to "resend" if the deprecated CONSOLE_MAILER is false
+	Backend      string
+	ResendAPIKey string
+	FromEmail    string
+	SMTP         SMTPConfig
+	Branding     BrandingConfig
+}
+
+// The society emails are sent on behalf of, so other societies can run their own elections
+type BrandingConfig struct {
+	SocietyName string
+	// image at the top of HTML emails, omitted if empty
+	LogoURL string
+	// CSS hex colours: primary for headings and buttons, accent for highlighted boxes like
+	// the OTP code
+	PrimaryColour string
+	AccentColour  string
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// "starttls" to upgrade a plaintext connection, "tls" for implicit TLS (usually port
+	// 465), or "none" for relays only reachable over a trusted network
+	Security string
+	// how long a single connection attempt or send may take
+	Timeout time.Duration
+}
+
+// How members are identified, and which address they are emailed at
+type IdentityConfig struct {
+	// regular expression every identifier (zID) must match, after normalising
+	Pattern string
+	// prepended to identifiers that are only valid with it, e.g "z" so bare student numbers
+	// are accepted. optional
+	Prefix string
+	// identifiers are emailed at <id>@<EmailDomain>. if empty, only members with an email
+	// in the member list can be emailed
+	EmailDomain string
+	// email members at the email in the member list when they have one, rather than the
+	// address derived from their identifier
+	PreferMemberEmail bool
 }
 
 type LoggerConfig struct {
@@ -81,13 +146,59 @@ type RateLimitConfig struct {
 }
 
 type AdminConfig struct {
+	// granted the returning officer role on startup if there are no admins yet. once there
+	// are, roles are granted and revoked through the API
 	AdminZIds []string
 }
 
+type ElectionConfig struct {
+	// number of seats for each of the default positions new elections are created with,
+	// positions not listed have one. positions can be changed per election before it opens
+	PositionSeats map[string]int
+	// IANA time zone the society is in. dates in member lists without a time zone are read
+	// in it, so a membership lasts until the end of its expiry date there
+	TimeZone string
+}
+
+type ReceiptConfig struct {
+	// base64 encoded ed25519 seed used to sign vote receipts. if empty a key is generated
+	// on startup, so receipts can't be verified after a restart. only allowed in development
+	SigningKey string
+}
+
+type SchedulerConfig struct {
+	// how often to check for scheduled state transitions that are due
+	Interval time.Duration
+}
+
+type DashboardConfig struct {
+	// how often the admin dashboard stream sends updated statistics
+	Interval time.Duration
+	// how far back OTP events are counted
+	OTPWindow time.Duration
+	// how long OTP events are kept for, at least OTPWindow
+	OTPRetention time.Duration
+}
+
+type OutboxConfig struct {
+	// how often the outbox is checked for emails to retry
+	Interval time.Duration
+	// most emails sent per check
+	BatchSize int
+	// emails are dead lettered after failing this many times
+	MaxAttempts int
+	// the delay after the first failed attempt, doubling after each one up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// how long a process has to send an email it has claimed before another may try
+	Lease time.Duration
+}
+
 func Load() Config {
 	config := Config{
 		API: APIConfig{
-			Version: "1.0.0",
+			Version:     "1.0.0",
+			Development: GetBool("DEVELOPMENT", false),
 		},
 		Server: ServerConfig{
 			Port:            GetInt("PORT", 8888),
@@ -105,10 +216,13 @@ func Load() Config {
 			MaxIdleTime:        GetString("DB_MAX_IDLE_TIME", "15m"),
 		},
 		JWT: JWTConfig{
-			Secret:     GetString("JWT_SECRET", "DONTUSEMEINPRODPLEASE"),
-			CookieName: "SESSION",
-			Duration:   time.Minute * 30,
-			Issuer:     "vote-api",
+			SigningKeyFile:       GetString("JWT_SIGNING_KEY_FILE", ""),
+			VerificationKeyFiles: SplitAndTrim(GetString("JWT_VERIFICATION_KEY_FILES", "")),
+			CookieName:           "SESSION",
+			Duration:             time.Minute * 30,
+			Issuer:               "vote-api",
+			MaxLifetime:          time.Duration(GetInt("JWT_MAX_LIFETIME_MINUTES", 8*60)) * time.Minute,
+			SessionCheckInterval: time.Duration(GetInt("SESSION_CHECK_INTERVAL_SECONDS", 15)) * time.Second,
 		},
 		OTP: OTPConfig{
 			Secret:          GetString("OTP_SECRET", "DONTUSEMEINPRODPLEASE-IMEANIT!"),
@@ -116,11 +230,32 @@ func Load() Config {
 			Duration:        time.Minute * 10,
 			RatelimitCount:  3,
 			RatelimitWithin: time.Minute * 5,
+			LinkURL:         GetString("MAGIC_LINK_URL", ""),
 		},
 		Mailer: MailerConfig{
-			ResendAPIKey:  GetString("RESEND_API_KEY", ""),
-			FromEmail:     GetString("MAILER_FROM_EMAIL", ""),
-			ConsoleMailer: GetBool("CONSOLE_MAILER", true),
+			Backend:      GetString("MAILER_BACKEND", defaultMailerBackend()),
+			ResendAPIKey: GetString("RESEND_API_KEY", ""),
+			FromEmail:    GetString("MAILER_FROM_EMAIL", ""),
+			SMTP: SMTPConfig{
+				Host:     GetString("SMTP_HOST", ""),
+				Port:     GetInt("SMTP_PORT", 587),
+				Username: GetString("SMTP_USERNAME", ""),
+				Password: GetString("SMTP_PASSWORD", ""),
+				Security: GetString("SMTP_SECURITY", "starttls"),
+				Timeout:  time.Second * 30,
+			},
+			Branding: BrandingConfig{
+				SocietyName:   GetString("SOCIETY_NAME", "Linux Society UNSW"),
+				LogoURL:       GetString("SOCIETY_LOGO_URL", ""),
+				PrimaryColour: GetString("SOCIETY_PRIMARY_COLOUR", "#222222"),
+				AccentColour:  GetString("SOCIETY_ACCENT_COLOUR", "#f5f5f5"),
+			},
+		},
+		Identity: IdentityConfig{
+			Pattern:           GetString("IDENTITY_PATTERN", `^z[0-9]{7}$`),
+			Prefix:            GetString("IDENTITY_PREFIX", "z"),
+			EmailDomain:       GetString("IDENTITY_EMAIL_DOMAIN", "unsw.edu.au"),
+			PreferMemberEmail: GetBool("IDENTITY_PREFER_MEMBER_EMAIL", false),
 		},
 		Logger: LoggerConfig{
 			Level:       GetString("LOGGER_LEVEL", "debug"),
@@ -140,11 +275,44 @@ func Load() Config {
 		Admin: AdminConfig{
 			AdminZIds: SplitAndTrim(GetString("ADMIN_ZIDS", "")),
 		},
+		Election: ElectionConfig{
+			// e.g "arc_delegate=2,general_executive=4"
+			PositionSeats: ParseSeats(GetString("POSITION_SEATS", "")),
+			TimeZone:      GetString("TIME_ZONE", "Australia/Sydney"),
+		},
+		Receipt: ReceiptConfig{
+			SigningKey: GetString("RECEIPT_SIGNING_KEY", ""),
+		},
+		Scheduler: SchedulerConfig{
+			Interval: time.Duration(GetInt("SCHEDULER_INTERVAL_SECONDS", 15)) * time.Second,
+		},
+		Dashboard: DashboardConfig{
+			Interval:     time.Duration(GetInt("DASHBOARD_INTERVAL_SECONDS", 2)) * time.Second,
+			OTPWindow:    time.Minute * 5,
+			OTPRetention: time.Hour * 24,
+		},
+		Outbox: OutboxConfig{
+			Interval:    time.Duration(GetInt("OUTBOX_INTERVAL_SECONDS", 5)) * time.Second,
+			BatchSize:   50,
+			MaxAttempts: GetInt("OUTBOX_MAX_ATTEMPTS", 8),
+			BaseDelay:   time.Second * 15,
+			MaxDelay:    time.Minute * 15,
+			Lease:       time.Minute * 2,
+		},
 	}
 
 	return config
 }
 
+// deployments from before MAILER_BACKEND chose between the console and Resend with
+// CONSOLE_MAILER, so CONSOLE_MAILER=false keeps sending with Resend
+func defaultMailerBackend() string {
+	if !GetBool("CONSOLE_MAILER", true) {
+		return "resend"
+	}
+	return "console"
+}
+
 // takes a comma separated string and returns a slice of
 // trimmed, non-empty components. If s is empty it returns nil.
 func SplitAndTrim(s string) []string {
@@ -163,3 +331,21 @@ func SplitAndTrim(s string) []string {
 	}
 	return out
 }
+
+// takes a comma separated list of position=seats pairs and returns a map
+// of position to seats. Malformed pairs and non-positive seat counts are skipped.
+func ParseSeats(s string) map[string]int {
+	seats := make(map[string]int)
+	for _, pair := range SplitAndTrim(s) {
+		position, count, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || n < 1 {
+			continue
+		}
+		seats[strings.TrimSpace(position)] = n
+	}
+	return seats
+}
@@ -0,0 +1,87 @@
+package identity
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+)
+
+var (
+	ErrInvalidPattern = errors.New("IDENTITY_PATTERN must be a valid regular expression")
+	ErrNoAddress      = errors.New("no email address for identifier")
+)
+
+// Decides who can be identified, and where they are emailed. Identifiers are called zIDs
+// elsewhere, as UNSW's are the default.
+type Provider interface {
+	// The regular expression every identifier matches
+	Pattern() string
+
+	// Whether id is an identifier, as it would be stored
+	Valid(id string) bool
+
+	// Turns an identifier as typed or exported by another system into the form it is
+	// stored in, e.g "5555555" into "z5555555". Values that can't be made valid are
+	// returned trimmed, and fail Valid.
+	Normalise(raw string) string
+
+	// The address to email id at. memberEmail is their email from the member list, or
+	// nil if they aren't a member or it wasn't imported.
+	// Returns error ErrNoAddress if there is nowhere to send it.
+	Address(id string, memberEmail *string) (string, error)
+}
+
+type patternProvider struct {
+	pattern           *regexp.Regexp
+	prefix            string
+	emailDomain       string
+	preferMemberEmail bool
+}
+
+// Creates the provider described by cfg
+func New(cfg config.IdentityConfig) (Provider, error) {
+	pattern, err := regexp.Compile(cfg.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
+	}
+
+	return &patternProvider{
+		pattern:           pattern,
+		prefix:            cfg.Prefix,
+		emailDomain:       cfg.EmailDomain,
+		preferMemberEmail: cfg.PreferMemberEmail,
+	}, nil
+}
+
+func (p *patternProvider) Pattern() string {
+	return p.pattern.String()
+}
+
+func (p *patternProvider) Valid(id string) bool {
+	return p.pattern.MatchString(id)
+}
+
+func (p *patternProvider) Normalise(raw string) string {
+	id := strings.TrimSpace(raw)
+	lower := strings.ToLower(id)
+	for _, candidate := range []string{id, lower, p.prefix + lower} {
+		if p.Valid(candidate) {
+			return candidate
+		}
+	}
+	return id
+}
+
+func (p *patternProvider) Address(id string, memberEmail *string) (string, error) {
+	hasMemberEmail := memberEmail != nil && *memberEmail != ""
+	if hasMemberEmail && (p.preferMemberEmail || p.emailDomain == "") {
+		return *memberEmail, nil
+	}
+	if p.emailDomain != "" {
+		return id + "@" + p.emailDomain, nil
+	}
+	return "", fmt.Errorf("%s: %w", id, ErrNoAddress)
+}
@@ -0,0 +1,120 @@
+package identity
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+)
+
+func newProvider(t *testing.T, cfg config.IdentityConfig) Provider {
+	t.Helper()
+
+	ids, err := New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create identity provider: %v", err)
+	}
+	return ids
+}
+
+func unswConfig() config.IdentityConfig {
+	return config.IdentityConfig{
+		Pattern:     `^z[0-9]{7}$`,
+		Prefix:      "z",
+		EmailDomain: "unsw.edu.au",
+	}
+}
+
+func TestValid(t *testing.T) {
+	ids := newProvider(t, unswConfig())
+
+	for id, valid := range map[string]bool{
+		"z5555555":  true,
+		"Z5555555":  false,
+		"5555555":   false,
+		"z555555":   false,
+		"z55555555": false,
+		"":          false,
+	} {
+		if ids.Valid(id) != valid {
+			t.Errorf("expected Valid(%q) to be %v", id, valid)
+		}
+	}
+}
+
+func TestNormalise(t *testing.T) {
+	ids := newProvider(t, unswConfig())
+
+	for raw, want := range map[string]string{
+		"z5555555":     "z5555555",
+		" Z1234567 ":   "z1234567",
+		"5555555":      "z5555555",
+		"not a zid":    "not a zid",
+		"  z123  ":     "z123",
+		"\tz0000000\n": "z0000000",
+	} {
+		if got := ids.Normalise(raw); got != want {
+			t.Errorf("expected Normalise(%q) to be %q, got %q", raw, want, got)
+		}
+	}
+
+	// a pattern without a prefix is matched as typed
+	ids = newProvider(t, config.IdentityConfig{Pattern: `^[a-z]+\.[a-z]+$`})
+	if got := ids.Normalise("Jane.Doe"); got != "jane.doe" {
+		t.Errorf("expected Normalise to lowercase, got %q", got)
+	}
+}
+
+func TestAddress(t *testing.T) {
+	memberEmail := "jane@example.com"
+	empty := ""
+
+	tests := []struct {
+		name        string
+		cfg         config.IdentityConfig
+		memberEmail *string
+		want        string
+		err         error
+	}{
+		{name: "domain", cfg: unswConfig(), want: "z5555555@unsw.edu.au"},
+		{name: "domain over member email", cfg: unswConfig(), memberEmail: &memberEmail, want: "z5555555@unsw.edu.au"},
+		{
+			name:        "prefer member email",
+			cfg:         config.IdentityConfig{Pattern: unswConfig().Pattern, EmailDomain: "unsw.edu.au", PreferMemberEmail: true},
+			memberEmail: &memberEmail,
+			want:        memberEmail,
+		},
+		{
+			name:        "prefer missing member email",
+			cfg:         config.IdentityConfig{Pattern: unswConfig().Pattern, EmailDomain: "unsw.edu.au", PreferMemberEmail: true},
+			memberEmail: &empty,
+			want:        "z5555555@unsw.edu.au",
+		},
+		{
+			name:        "no domain",
+			cfg:         config.IdentityConfig{Pattern: unswConfig().Pattern},
+			memberEmail: &memberEmail,
+			want:        memberEmail,
+		},
+		{name: "nowhere to send", cfg: config.IdentityConfig{Pattern: unswConfig().Pattern}, err: ErrNoAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newProvider(t, tt.cfg).Address("z5555555", tt.memberEmail)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestInvalidPattern(t *testing.T) {
+	_, err := New(config.IdentityConfig{Pattern: `^z[0-9{7}$`})
+	if !errors.Is(err, ErrInvalidPattern) {
+		t.Fatalf("expected ErrInvalidPattern, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+var (
+	ErrInvalidKeyFile   = errors.New("key file must contain a PEM encoded private or public key")
+	ErrUnsupportedKey   = errors.New("keys must be ed25519 (EdDSA) or P-256 ecdsa (ES256)")
+	ErrPrivateKeyNeeded = errors.New("the signing key file must contain a private key")
+)
+
+// Signs session tokens with a single key, and verifies them against every active key.
+// Keeping the previous signing key as a verification key during a rotation means tokens
+// it signed keep working until they expire.
+type KeySet struct {
+	signing jwk.Key
+	alg     jwa.SignatureAlgorithm
+
+	// public keys tokens are verified against, including the signing key's
+	public jwk.Set
+}
+
+// Loads the signing key and any extra verification keys from PEM files. If signingKeyFile
+// is empty a new ed25519 key is generated, and sessions end when the server restarts.
+// Keys are identified by their RFC 7638 thumbprint, which is sent as each token's kid.
+func NewKeySet(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	var raw crypto.PrivateKey
+	if signingKeyFile == "" {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		raw = key
+	} else {
+		key, err := readKeyFile(signingKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := key.(crypto.Signer); !ok {
+			return nil, fmt.Errorf("%s: %w", signingKeyFile, ErrPrivateKeyNeeded)
+		}
+		raw = key
+	}
+
+	signing, alg, err := importKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", signingKeyFile, err)
+	}
+
+	ks := &KeySet{
+		signing: signing,
+		alg:     alg,
+		public:  jwk.NewSet(),
+	}
+	if err := ks.addPublic(signing); err != nil {
+		return nil, err
+	}
+
+	for _, file := range verificationKeyFiles {
+		raw, err := readKeyFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, _, err := importKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if err := ks.addPublic(key); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	return ks, nil
+}
+
+// Signs token with the signing key, setting its kid header
+func (ks *KeySet) Sign(token jwt.Token) ([]byte, error) {
+	return jwt.Sign(token, jwt.WithKey(ks.alg, ks.signing))
+}
+
+// Parses tokenString, verifying it against the key matching its kid. Tokens without a
+// kid, or signed by a key that isn't active, are rejected.
+func (ks *KeySet) Parse(tokenString string, options ...jwt.ParseOption) (jwt.Token, error) {
+	options = append(options, jwt.WithKeySet(ks.public))
+	return jwt.ParseString(tokenString, options...)
+}
+
+// The public half of every active key, for publishing as a JWKS
+func (ks *KeySet) PublicKeys() jwk.Set {
+	return ks.public
+}
+
+// The kid of the key new tokens are signed with
+func (ks *KeySet) SigningKeyID() string {
+	kid, _ := ks.signing.KeyID()
+	return kid
+}
+
+func (ks *KeySet) addPublic(key jwk.Key) error {
+	public, err := jwk.PublicKeyOf(key)
+	if err != nil {
+		return err
+	}
+
+	// the same key may be listed twice while rotating, it only needs to appear once
+	kid, _ := public.KeyID()
+	if _, ok := ks.public.LookupKeyID(kid); ok {
+		return nil
+	}
+	return ks.public.AddKey(public)
+}
+
+// Converts a parsed private or public key to a JWK with its algorithm, use and kid set
+func importKey(raw any) (jwk.Key, jwa.SignatureAlgorithm, error) {
+	var alg jwa.SignatureAlgorithm
+	switch k := raw.(type) {
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		alg = jwa.EdDSA()
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, alg, ErrUnsupportedKey
+		}
+		alg = jwa.ES256()
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, alg, ErrUnsupportedKey
+		}
+		alg = jwa.ES256()
+	default:
+		return nil, alg, ErrUnsupportedKey
+	}
+
+	key, err := jwk.Import(raw)
+	if err != nil {
+		return nil, alg, err
+	}
+	if err := jwk.AssignKeyID(key); err != nil {
+		return nil, alg, err
+	}
+	if err := key.Set(jwk.AlgorithmKey, alg); err != nil {
+		return nil, alg, err
+	}
+	if err := key.Set(jwk.KeyUsageKey, jwk.ForSignature); err != nil {
+		return nil, alg, err
+	}
+
+	return key, alg, nil
+}
+
+// Reads a PEM encoded PKCS #8 or SEC 1 private key, or PKIX public key
+func readKeyFile(file string) (any, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: %w", file, ErrInvalidKeyFile)
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: %w", file, ErrInvalidKeyFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", file, ErrInvalidKeyFile, err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,191 @@
+package jwtkeys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jws"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+// writes key to a PEM file in a temporary directory, returning its path
+func writeKey(t *testing.T, name string, key any, public bool) string {
+	t.Helper()
+
+	var der []byte
+	var err error
+	blockType := "PRIVATE KEY"
+	if public {
+		der, err = x509.MarshalPKIXPublicKey(key)
+		blockType = "PUBLIC KEY"
+	} else {
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+	}
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return file
+}
+
+func sign(t *testing.T, ks *KeySet) string {
+	t.Helper()
+
+	token, err := jwt.NewBuilder().Subject("z0000000").Build()
+	if err != nil {
+		t.Fatalf("failed to build token: %v", err)
+	}
+	signed, err := ks.Sign(token)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	return string(signed)
+}
+
+func TestSignAndParse(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	tests := []struct {
+		name string
+		key  any
+		alg  jwa.SignatureAlgorithm
+	}{
+		{"ed25519", edKey, jwa.EdDSA()},
+		{"p256", ecKey, jwa.ES256()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := NewKeySet(writeKey(t, tt.name+".pem", tt.key, false), nil)
+			if err != nil {
+				t.Fatalf("NewKeySet failed: %v", err)
+			}
+
+			signed := sign(t, ks)
+			msg, err := jws.Parse([]byte(signed))
+			if err != nil {
+				t.Fatalf("failed to parse signature: %v", err)
+			}
+			headers := msg.Signatures()[0].ProtectedHeaders()
+			if kid, _ := headers.KeyID(); kid != ks.SigningKeyID() {
+				t.Fatalf("expected kid %q, got %q", ks.SigningKeyID(), kid)
+			}
+			if alg, _ := headers.Algorithm(); alg != tt.alg {
+				t.Fatalf("expected alg %s, got %s", tt.alg, alg)
+			}
+
+			token, err := ks.Parse(signed)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if sub, _ := token.Subject(); sub != "z0000000" {
+				t.Fatalf("expected subject z0000000, got %q", sub)
+			}
+		})
+	}
+}
+
+func TestGeneratedKey(t *testing.T) {
+	ks, err := NewKeySet("", nil)
+	if err != nil {
+		t.Fatalf("NewKeySet failed: %v", err)
+	}
+	if _, err := ks.Parse(sign(t, ks)); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if ks.PublicKeys().Len() != 1 {
+		t.Fatalf("expected 1 public key, got %d", ks.PublicKeys().Len())
+	}
+}
+
+func TestRotation(t *testing.T) {
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	oldFile := writeKey(t, "old.pem", oldKey, false)
+	newFile := writeKey(t, "new.pem", newKey, false)
+
+	old, err := NewKeySet(oldFile, nil)
+	if err != nil {
+		t.Fatalf("NewKeySet failed: %v", err)
+	}
+	oldToken := sign(t, old)
+
+	// only the public half of the previous key is needed to verify its tokens
+	rotating, err := NewKeySet(newFile, []string{writeKey(t, "old.pub.pem", oldKey.Public(), true)})
+	if err != nil {
+		t.Fatalf("NewKeySet failed: %v", err)
+	}
+	if rotating.PublicKeys().Len() != 2 {
+		t.Fatalf("expected 2 public keys, got %d", rotating.PublicKeys().Len())
+	}
+	if _, err := rotating.Parse(oldToken); err != nil {
+		t.Fatalf("expected a token signed by the previous key to verify, got %v", err)
+	}
+	if _, err := old.Parse(sign(t, rotating)); err == nil {
+		t.Fatalf("expected a token signed by the new key to not verify against the old key set")
+	}
+
+	rotated, err := NewKeySet(newFile, nil)
+	if err != nil {
+		t.Fatalf("NewKeySet failed: %v", err)
+	}
+	if _, err := rotated.Parse(oldToken); err == nil {
+		t.Fatalf("expected a token signed by a retired key to be rejected")
+	}
+}
+
+func TestParseRejectsSharedSecret(t *testing.T) {
+	ks, err := NewKeySet("", nil)
+	if err != nil {
+		t.Fatalf("NewKeySet failed: %v", err)
+	}
+
+	token, _ := jwt.NewBuilder().Subject("z0000000").Build()
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.HS256(), []byte("DONTUSEMEINPRODPLEASE")))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	if _, err := ks.Parse(string(signed)); err == nil {
+		t.Fatalf("expected a HS256 token to be rejected")
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := NewKeySet(writeKey(t, "rsa.pem", rsaKey, false), nil); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("expected ErrUnsupportedKey, got %v", err)
+	}
+
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if _, err := NewKeySet(writeKey(t, "p384.pem", p384, false), nil); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("expected ErrUnsupportedKey, got %v", err)
+	}
+
+	edPublic, _, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := NewKeySet(writeKey(t, "public.pem", edPublic, true), nil); !errors.Is(err, ErrPrivateKeyNeeded) {
+		t.Fatalf("expected ErrPrivateKeyNeeded, got %v", err)
+	}
+
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	_ = os.WriteFile(garbage, []byte("not a key"), 0o600)
+	if _, err := NewKeySet(garbage, nil); !errors.Is(err, ErrInvalidKeyFile) {
+		t.Fatalf("expected ErrInvalidKeyFile, got %v", err)
+	}
+	if _, err := NewKeySet("", []string{garbage}); !errors.Is(err, ErrInvalidKeyFile) {
+		t.Fatalf("expected ErrInvalidKeyFile, got %v", err)
+	}
+}
@@ -8,8 +8,13 @@ type ConsoleMailer struct {
 	Logger *slog.Logger
 }
 
-func (c *ConsoleMailer) SendOTP(toEmail, otpCode string) error {
-	c.Logger.Info("Sending OTP", "email", toEmail, "otp", otpCode)
+func (c *ConsoleMailer) SendOTP(toEmail, otpCode, link string) error {
+	c.Logger.Info("Sending OTP", "email", toEmail, "otp", otpCode, "link", link)
+	return nil
+}
+
+func (c *ConsoleMailer) Send(toEmail string, email *Email) error {
+	c.Logger.Info("Sending email", "email", toEmail, "subject", email.Subject, "text", email.Text)
 	return nil
 }
 
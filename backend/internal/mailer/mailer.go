@@ -1,10 +1,48 @@
 package mailer
 
-import "embed"
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+)
 
 //go:embed "templates"
 var FS embed.FS
 
+var ErrUnknownBackend = errors.New("mailer backend must be console, resend or smtp")
+
+// An email rendered as both HTML and plain text
+type Email struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
 type Mailer interface {
-	SendOTP(toEmail string, otpCode string) error
+	// link signs in without entering otpCode, and is omitted if empty
+	SendOTP(toEmail string, otpCode string, link string) error
+
+	// Sends an email that has already been rendered, e.g by Templates.Render
+	Send(toEmail string, email *Email) error
+}
+
+// Creates the mailer selected by cfg.Mailer.Backend, rendering OTP emails from templates
+func New(cfg config.Config, logger *slog.Logger, templates *Templates) (Mailer, error) {
+	switch cfg.Mailer.Backend {
+	case "console":
+		return NewConsoleMailer(logger), nil
+	case "resend":
+		return NewResendMailer(cfg, templates), nil
+	case "smtp":
+		m, err := NewSMTPMailer(cfg, templates)
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("%q: %w", cfg.Mailer.Backend, ErrUnknownBackend)
+	}
 }
@@ -3,17 +3,34 @@ package mock_mailer
 import "github.com/linuxunsw/vote/backend/internal/mailer"
 
 type MockMailer struct {
-	otpEmails map[string]string
+	otpEmails  map[string]string
+	linkEmails map[string]string
+	emails     map[string][]*mailer.Email
+	err        error
 }
 
 func NewMockMailer() mailer.Mailer {
 	return &MockMailer{
-		otpEmails: make(map[string]string),
+		otpEmails:  make(map[string]string),
+		linkEmails: make(map[string]string),
+		emails:     make(map[string][]*mailer.Email),
 	}
 }
 
-func (m *MockMailer) SendOTP(toEmail string, otpCode string) error {
+func (m *MockMailer) SendOTP(toEmail string, otpCode string, link string) error {
+	if m.err != nil {
+		return m.err
+	}
 	m.otpEmails[toEmail] = otpCode
+	m.linkEmails[toEmail] = link
+	return nil
+}
+
+func (m *MockMailer) Send(toEmail string, email *mailer.Email) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.emails[toEmail] = append(m.emails[toEmail], email)
 	return nil
 }
 
@@ -22,3 +39,19 @@ func (m *MockMailer) SendOTP(toEmail string, otpCode string) error {
 func (m *MockMailer) MockRetrieveOTP(toEmail string) string {
 	return m.otpEmails[toEmail]
 }
+
+// Retrieve the magic link sent with the most recent OTP code for an email address.
+// Returns empty string if no link was sent
+func (m *MockMailer) MockRetrieveLink(toEmail string) string {
+	return m.linkEmails[toEmail]
+}
+
+// Make every send fail with err until it is called again with nil
+func (m *MockMailer) MockSetError(err error) {
+	m.err = err
+}
+
+// Retrieve every email other than OTPs sent to an email address, oldest first
+func (m *MockMailer) MockRetrieveEmails(toEmail string) []*mailer.Email {
+	return m.emails[toEmail]
+}
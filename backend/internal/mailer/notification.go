@@ -0,0 +1,27 @@
+package mailer
+
+// Names of the notification templates, rendered with NotificationData
+const (
+	NotificationNominationReceived  = "nomination_received"
+	NotificationNominationUpdated   = "nomination_updated"
+	NotificationNominationWithdrawn = "nomination_withdrawn"
+	NotificationVotingOpen          = "voting_open"
+	NotificationVoteRecorded        = "vote_recorded"
+	NotificationResultsPublished    = "results_published"
+)
+
+// What notifications are rendered from. Each notification only uses the fields that
+// apply to it.
+type NotificationData struct {
+	ElectionName string `json:"election_name"`
+
+	// for nomination notifications
+	CandidateName string `json:"candidate_name,omitempty"`
+	// names of the positions nominated for
+	Positions    []string `json:"positions,omitempty"`
+	NominationID string   `json:"nomination_id,omitempty"`
+
+	// for vote receipts, the hex encoded receipt hash that can be checked against the bulletin.
+	// never sent for secret ballots, as the outbox would link the receipt to the voter
+	ReceiptHash string `json:"receipt_hash,omitempty"`
+}
@@ -0,0 +1,20 @@
+package mailer
+
+import (
+	"time"
+)
+
+const TemplateOTP = "otp"
+
+// Renders an OTP email. link signs in without entering otpCode, and is omitted if empty
+func (t *Templates) RenderOTP(otpCode, link string, expiry time.Duration) (*Email, error) {
+	return t.Render(TemplateOTP, struct {
+		OTP           string
+		Link          string
+		ExpiryMinutes int
+	}{
+		OTP:           otpCode,
+		Link:          link,
+		ExpiryMinutes: int(expiry.Minutes()),
+	})
+}
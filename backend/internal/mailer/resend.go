@@ -1,9 +1,6 @@
 package mailer
 
 import (
-	"bytes"
-	"fmt"
-	"html/template"
 	"time"
 
 	"github.com/linuxunsw/vote/backend/internal/config"
@@ -15,47 +12,38 @@ type ResendMailer struct {
 	apiKey    string
 	client    *resend.Client
 	otpExpiry time.Duration
+	templates *Templates
 }
 
-func NewResendMailer(cfg config.Config) Mailer {
+func NewResendMailer(cfg config.Config, templates *Templates) Mailer {
 	client := resend.NewClient(cfg.Mailer.ResendAPIKey)
 	return &ResendMailer{
 		fromEmail: cfg.Mailer.FromEmail,
 		apiKey:    cfg.Mailer.ResendAPIKey,
 		client:    client,
 		otpExpiry: cfg.OTP.Duration,
+		templates: templates,
 	}
 }
 
-func (m *ResendMailer) SendOTP(toEmail, otpCode string) error {
-	tmpl, err := template.ParseFS(FS, "templates/otp.html")
+func (m *ResendMailer) SendOTP(toEmail, otpCode, link string) error {
+	msg, err := m.templates.RenderOTP(otpCode, link, m.otpExpiry)
 	if err != nil {
 		return err
 	}
 
-	data := struct {
-		OTP           string
-		ExpiryMinutes int
-	}{
-		OTP:           otpCode,
-		ExpiryMinutes: int(m.otpExpiry.Minutes()),
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return err
-	}
-
-	plainText := fmt.Sprintf("Your OTP code is: %s\n\nThis code will expire in %d minutes.\n\nRegards,\nLinux Society UNSW", otpCode, data.ExpiryMinutes)
+	return m.Send(toEmail, msg)
+}
 
+func (m *ResendMailer) Send(toEmail string, email *Email) error {
 	params := &resend.SendEmailRequest{
 		From:    m.fromEmail,
 		To:      []string{toEmail},
-		Html:    buf.String(),
-		Text:    plainText,
-		Subject: "Your Linux Society Vote OTP",
+		Html:    email.HTML,
+		Text:    email.Text,
+		Subject: email.Subject,
 	}
 
-	_, err = m.client.Emails.Send(params)
+	_, err := m.client.Emails.Send(params)
 	return err
 }
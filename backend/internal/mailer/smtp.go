@@ -0,0 +1,258 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+)
+
+var (
+	ErrSMTPHostRequired    = errors.New("SMTP_HOST must be set to use the smtp mailer")
+	ErrUnknownSMTPSecurity = errors.New("SMTP security must be starttls, tls or none")
+	ErrSTARTTLSUnsupported = errors.New("SMTP server does not support STARTTLS")
+)
+
+// Sends emails through an SMTP relay. One connection is kept open and reused between
+// emails, and is redialled if the server has closed it while idle.
+type SMTPMailer struct {
+	addr      string
+	host      string
+	username  string
+	password  string
+	security  string
+	timeout   time.Duration
+	fromEmail string
+	// the bare address from fromEmail, sent as the envelope sender
+	fromAddress string
+	otpExpiry   time.Duration
+	templates   *Templates
+	tlsConfig   *tls.Config
+
+	// guards the connection, which can only send one email at a time
+	mu     sync.Mutex
+	conn   net.Conn
+	client *smtp.Client
+}
+
+// Creates an SMTP mailer from cfg.Mailer.SMTP. It connects when the first email is sent,
+// so an unreachable relay doesn't stop the server starting.
+func NewSMTPMailer(cfg config.Config, templates *Templates) (*SMTPMailer, error) {
+	smtpCfg := cfg.Mailer.SMTP
+	if smtpCfg.Host == "" {
+		return nil, ErrSMTPHostRequired
+	}
+	switch smtpCfg.Security {
+	case "starttls", "tls", "none":
+	default:
+		return nil, fmt.Errorf("%q: %w", smtpCfg.Security, ErrUnknownSMTPSecurity)
+	}
+
+	from, err := mail.ParseAddress(cfg.Mailer.FromEmail)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from email %q: %w", cfg.Mailer.FromEmail, err)
+	}
+
+	return &SMTPMailer{
+		addr:        net.JoinHostPort(smtpCfg.Host, strconv.Itoa(smtpCfg.Port)),
+		host:        smtpCfg.Host,
+		username:    smtpCfg.Username,
+		password:    smtpCfg.Password,
+		security:    smtpCfg.Security,
+		timeout:     smtpCfg.Timeout,
+		fromEmail:   cfg.Mailer.FromEmail,
+		fromAddress: from.Address,
+		otpExpiry:   cfg.OTP.Duration,
+		templates:   templates,
+		tlsConfig:   &tls.Config{ServerName: smtpCfg.Host, MinVersion: tls.VersionTLS12},
+	}, nil
+}
+
+func (m *SMTPMailer) SendOTP(toEmail, otpCode, link string) error {
+	msg, err := m.templates.RenderOTP(otpCode, link, m.otpExpiry)
+	if err != nil {
+		return err
+	}
+
+	return m.Send(toEmail, msg)
+}
+
+func (m *SMTPMailer) Send(toEmail string, email *Email) error {
+	data, err := m.buildMessage(toEmail, email)
+	if err != nil {
+		return err
+	}
+
+	return m.send(toEmail, data)
+}
+
+// Quits and closes the open connection, if any
+func (m *SMTPMailer) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.client == nil {
+		return nil
+	}
+	_ = m.conn.SetDeadline(time.Now().Add(m.timeout))
+	err := m.client.Quit()
+	m.closeLocked()
+	return err
+}
+
+func (m *SMTPMailer) send(toEmail string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.client != nil {
+		// relays drop idle connections, so check this one is still usable before reusing it
+		_ = m.conn.SetDeadline(time.Now().Add(m.timeout))
+		if err := m.client.Reset(); err != nil {
+			m.closeLocked()
+		}
+	}
+	if m.client == nil {
+		if err := m.dial(); err != nil {
+			return err
+		}
+	}
+
+	// the state of the session is unknown after an error, so the next email starts a new one
+	if err := m.transaction(toEmail, data); err != nil {
+		m.closeLocked()
+		return err
+	}
+	return nil
+}
+
+func (m *SMTPMailer) transaction(toEmail string, data []byte) error {
+	_ = m.conn.SetDeadline(time.Now().Add(m.timeout))
+
+	if err := m.client.Mail(m.fromAddress); err != nil {
+		return err
+	}
+	if err := m.client.Rcpt(toEmail); err != nil {
+		return err
+	}
+	w, err := m.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// connects, upgrades to TLS if configured, and authenticates. the new connection is only
+// kept if every step succeeds
+func (m *SMTPMailer) dial() error {
+	dialer := &net.Dialer{Timeout: m.timeout}
+
+	var conn net.Conn
+	var err error
+	if m.security == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", m.addr, m.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", m.addr)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(m.timeout))
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	if m.security == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			_ = client.Close()
+			return ErrSTARTTLSUnsupported
+		}
+		if err := client.StartTLS(m.tlsConfig); err != nil {
+			_ = client.Close()
+			return err
+		}
+	}
+
+	if m.username != "" {
+		// PlainAuth refuses to send the password over an unencrypted connection to anything
+		// but localhost
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			_ = client.Close()
+			return err
+		}
+	}
+
+	m.conn = conn
+	m.client = client
+	return nil
+}
+
+func (m *SMTPMailer) closeLocked() {
+	if m.client != nil {
+		_ = m.client.Close()
+	}
+	m.conn = nil
+	m.client = nil
+}
+
+// builds a multipart/alternative message with the email's plain text and HTML versions
+func (m *SMTPMailer) buildMessage(toEmail string, msg *Email) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	headers := []struct{ key, value string }{
+		{"From", m.fromEmail},
+		{"To", toEmail},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h.key, h.value)
+	}
+	buf.WriteString("\r\n")
+
+	parts := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(p.body)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,417 @@
+package mailer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+)
+
+const (
+	testSMTPUsername = "vote"
+	testSMTPPassword = "hunter2"
+)
+
+type receivedEmail struct {
+	from string
+	to   []string
+	data string
+}
+
+// A minimal SMTP server supporting STARTTLS or implicit TLS and AUTH PLAIN, recording
+// every email it accepts
+type smtpStandIn struct {
+	ln net.Listener
+	// nil if the server doesn't support TLS
+	tlsConfig   *tls.Config
+	implicitTLS bool
+
+	mu       sync.Mutex
+	conns    []net.Conn
+	accepted int
+	emails   []receivedEmail
+}
+
+func newSMTPStandIn(t *testing.T, tlsConfig *tls.Config, implicitTLS bool) *smtpStandIn {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	if implicitTLS {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	s := &smtpStandIn{ln: ln, tlsConfig: tlsConfig, implicitTLS: implicitTLS}
+	t.Cleanup(func() {
+		_ = ln.Close()
+		s.dropConnections()
+	})
+	go s.serve()
+	return s
+}
+
+func (s *smtpStandIn) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.accepted++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// closes every open connection, like a relay timing out idle clients
+func (s *smtpStandIn) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *smtpStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	secure := s.implicitTLS
+	authed := false
+	var current *receivedEmail
+
+	_ = tp.PrintfLine("220 localhost ESMTP stand-in")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			ext := []string{"localhost"}
+			if s.tlsConfig != nil && !secure {
+				ext = append(ext, "STARTTLS")
+			}
+			ext = append(ext, "AUTH PLAIN", "8BITMIME")
+			for i, e := range ext {
+				sep := "-"
+				if i == len(ext)-1 {
+					sep = " "
+				}
+				_ = tp.PrintfLine("250%s%s", sep, e)
+			}
+		case "STARTTLS":
+			_ = tp.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			secure = true
+		case "AUTH":
+			mech, initial, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(initial)
+			if mech == "PLAIN" && string(decoded) == "\x00"+testSMTPUsername+"\x00"+testSMTPPassword {
+				authed = true
+				_ = tp.PrintfLine("235 authenticated")
+			} else {
+				_ = tp.PrintfLine("535 authentication failed")
+			}
+		case "MAIL":
+			if !authed {
+				_ = tp.PrintfLine("530 authentication required")
+				continue
+			}
+			current = &receivedEmail{from: pathAddress(strings.TrimPrefix(arg, "FROM:"))}
+			_ = tp.PrintfLine("250 ok")
+		case "RCPT":
+			current.to = append(current.to, pathAddress(strings.TrimPrefix(arg, "TO:")))
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 end data with .")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			current.data = string(data)
+			s.mu.Lock()
+			s.emails = append(s.emails, *current)
+			s.mu.Unlock()
+			current = nil
+			_ = tp.PrintfLine("250 queued")
+		case "RSET":
+			current = nil
+			_ = tp.PrintfLine("250 ok")
+		case "NOOP":
+			_ = tp.PrintfLine("250 ok")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// returns the address from a MAIL or RCPT path, dropping any parameters after it
+func pathAddress(path string) string {
+	address, _, _ := strings.Cut(path, " ")
+	return strings.Trim(address, "<>")
+}
+
+func (s *smtpStandIn) received() []receivedEmail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedEmail(nil), s.emails...)
+}
+
+func (s *smtpStandIn) connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accepted
+}
+
+// creates a self signed certificate for 127.0.0.1, returning the server's config and a
+// client config trusting it
+func testTLSConfigs(t *testing.T) (server *tls.Config, client *tls.Config) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smtp stand-in"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}},
+		&tls.Config{ServerName: "127.0.0.1", RootCAs: roots}
+}
+
+func newTestSMTPMailer(t *testing.T, s *smtpStandIn, security string, clientTLS *tls.Config) *SMTPMailer {
+	t.Helper()
+
+	_, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	cfg := config.Load()
+	cfg.Mailer.FromEmail = "Linux Society <vote@example.com>"
+	cfg.Mailer.SMTP = config.SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     mustAtoi(t, port),
+		Username: testSMTPUsername,
+		Password: testSMTPPassword,
+		Security: security,
+		Timeout:  5 * time.Second,
+	}
+
+	m, err := NewSMTPMailer(cfg, testTemplates(t))
+	if err != nil {
+		t.Fatalf("NewSMTPMailer failed: %v", err)
+	}
+	if clientTLS != nil {
+		m.tlsConfig = clientTLS
+	}
+	t.Cleanup(func() { _ = m.Close() })
+	return m
+}
+
+func mustAtoi(t *testing.T, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatalf("invalid number %q: %v", s, err)
+	}
+	return n
+}
+
+// returns the decoded plain text part of a received email
+func plainTextPart(t *testing.T, data string) string {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, got %q (%v)", mediaType, err)
+	}
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextRawPart()
+		if err != nil {
+			t.Fatalf("no plain text part: %v", err)
+		}
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain") {
+			text, err := io.ReadAll(quotedprintable.NewReader(part))
+			if err != nil {
+				t.Fatalf("failed to decode plain text part: %v", err)
+			}
+			return string(text)
+		}
+	}
+}
+
+func TestSMTPSecurity(t *testing.T) {
+	serverTLS, clientTLS := testTLSConfigs(t)
+
+	tests := []struct {
+		name        string
+		security    string
+		tlsConfig   *tls.Config
+		implicitTLS bool
+	}{
+		{"none", "none", nil, false},
+		{"starttls", "starttls", serverTLS, false},
+		{"tls", "tls", serverTLS, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSMTPStandIn(t, tt.tlsConfig, tt.implicitTLS)
+			m := newTestSMTPMailer(t, s, tt.security, clientTLS)
+
+			link := "https://vote.example.com/login?token=z0000000.abc"
+			if err := m.SendOTP("z0000000@unsw.edu.au", "123456", link); err != nil {
+				t.Fatalf("SendOTP failed: %v", err)
+			}
+
+			emails := s.received()
+			if len(emails) != 1 {
+				t.Fatalf("expected 1 email, got %d", len(emails))
+			}
+			if emails[0].from != "vote@example.com" {
+				t.Fatalf("expected envelope sender vote@example.com, got %q", emails[0].from)
+			}
+			if len(emails[0].to) != 1 || emails[0].to[0] != "z0000000@unsw.edu.au" {
+				t.Fatalf("expected recipient z0000000@unsw.edu.au, got %v", emails[0].to)
+			}
+
+			text := plainTextPart(t, emails[0].data)
+			if !strings.Contains(text, "123456") || !strings.Contains(text, link) {
+				t.Fatalf("expected code and link in plain text, got %q", text)
+			}
+		})
+	}
+}
+
+func TestSMTPConnectionReuse(t *testing.T) {
+	s := newSMTPStandIn(t, nil, false)
+	m := newTestSMTPMailer(t, s, "none", nil)
+
+	for range 3 {
+		if err := m.SendOTP("z0000000@unsw.edu.au", "123456", ""); err != nil {
+			t.Fatalf("SendOTP failed: %v", err)
+		}
+	}
+	if len(s.received()) != 3 {
+		t.Fatalf("expected 3 emails, got %d", len(s.received()))
+	}
+	if s.connections() != 1 {
+		t.Fatalf("expected emails to share 1 connection, got %d", s.connections())
+	}
+}
+
+func TestSMTPReconnect(t *testing.T) {
+	s := newSMTPStandIn(t, nil, false)
+	m := newTestSMTPMailer(t, s, "none", nil)
+
+	if err := m.SendOTP("z0000000@unsw.edu.au", "123456", ""); err != nil {
+		t.Fatalf("SendOTP failed: %v", err)
+	}
+	s.dropConnections()
+	if err := m.SendOTP("z0000000@unsw.edu.au", "654321", ""); err != nil {
+		t.Fatalf("expected SendOTP to reconnect, got %v", err)
+	}
+
+	if len(s.received()) != 2 {
+		t.Fatalf("expected 2 emails, got %d", len(s.received()))
+	}
+	if s.connections() != 2 {
+		t.Fatalf("expected 2 connections, got %d", s.connections())
+	}
+}
+
+func TestSMTPAuthFailure(t *testing.T) {
+	s := newSMTPStandIn(t, nil, false)
+	m := newTestSMTPMailer(t, s, "none", nil)
+	m.password = "wrong"
+
+	if err := m.SendOTP("z0000000@unsw.edu.au", "123456", ""); err == nil {
+		t.Fatalf("expected SendOTP to fail with the wrong password")
+	}
+	if len(s.received()) != 0 {
+		t.Fatalf("expected no emails, got %d", len(s.received()))
+	}
+}
+
+func TestSMTPStartTLSUnsupported(t *testing.T) {
+	s := newSMTPStandIn(t, nil, false)
+	m := newTestSMTPMailer(t, s, "starttls", nil)
+
+	if err := m.SendOTP("z0000000@unsw.edu.au", "123456", ""); !errors.Is(err, ErrSTARTTLSUnsupported) {
+		t.Fatalf("expected ErrSTARTTLSUnsupported, got %v", err)
+	}
+}
+
+func TestNewMailer(t *testing.T) {
+	cfg := config.Load()
+
+	cfg.Mailer.Backend = "carrier-pigeon"
+	if _, err := New(cfg, nil, nil); !errors.Is(err, ErrUnknownBackend) {
+		t.Fatalf("expected ErrUnknownBackend, got %v", err)
+	}
+
+	cfg.Mailer.Backend = "smtp"
+	cfg.Mailer.FromEmail = "vote@example.com"
+	if _, err := New(cfg, nil, nil); !errors.Is(err, ErrSMTPHostRequired) {
+		t.Fatalf("expected ErrSMTPHostRequired, got %v", err)
+	}
+
+	cfg.Mailer.SMTP.Host = "smtp.example.com"
+	cfg.Mailer.SMTP.Security = "ssl"
+	if _, err := New(cfg, nil, nil); !errors.Is(err, ErrUnknownSMTPSecurity) {
+		t.Fatalf("expected ErrUnknownSMTPSecurity, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+)
+
+var (
+	ErrUnknownTemplate = errors.New("unknown email template")
+	ErrInvalidColour   = errors.New("society colours must be CSS hex colours, e.g #222222")
+)
+
+var hexColour = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// the layouts every email is rendered within, rather than emails themselves
+const (
+	layoutHTML = "templates/layout.html"
+	layoutText = "templates/layout.txt"
+)
+
+// Email templates, parsed once when the registry is created. Each email is a pair of
+// templates, templates/<name>.txt and templates/<name>.html, defining the "content" of
+// its plain text and HTML versions, which are rendered within the layout of the same
+// type. The text template also defines the email's "subject". Every template can use
+// {{brand}} for the society the email is sent on behalf of.
+type Templates struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// Parses every email template, branded for the configured society
+func NewTemplates(branding config.BrandingConfig) (*Templates, error) {
+	for _, colour := range []string{branding.PrimaryColour, branding.AccentColour} {
+		if !hexColour.MatchString(colour) {
+			return nil, fmt.Errorf("%q: %w", colour, ErrInvalidColour)
+		}
+	}
+
+	brand := func() config.BrandingConfig { return branding }
+	t := &Templates{
+		html: make(map[string]*htmltemplate.Template),
+		text: make(map[string]*texttemplate.Template),
+	}
+
+	textFiles, err := fs.Glob(FS, "templates/*.txt")
+	if err != nil {
+		return nil, err
+	}
+	for _, textFile := range textFiles {
+		if textFile == layoutText {
+			continue
+		}
+		name := strings.TrimSuffix(path.Base(textFile), ".txt")
+
+		text, err := texttemplate.New(path.Base(layoutText)).
+			Funcs(texttemplate.FuncMap{"brand": brand}).
+			ParseFS(FS, layoutText, textFile)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", name, err)
+		}
+		if text.Lookup("subject") == nil || text.Lookup("content") == nil {
+			return nil, fmt.Errorf("template %s: %s must define a subject and content", name, textFile)
+		}
+
+		html, err := htmltemplate.New(path.Base(layoutHTML)).
+			Funcs(htmltemplate.FuncMap{"brand": brand}).
+			ParseFS(FS, layoutHTML, "templates/"+name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", name, err)
+		}
+		if html.Lookup("content") == nil {
+			return nil, fmt.Errorf("template %s: templates/%s.html must define content", name, name)
+		}
+
+		t.text[name] = text
+		t.html[name] = html
+	}
+
+	return t, nil
+}
+
+// Renders the email name with data
+func (t *Templates) Render(name string, data any) (*Email, error) {
+	html, ok := t.html[name]
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", name, ErrUnknownTemplate)
+	}
+	text := t.text[name]
+
+	var subjectBuf, htmlBuf, textBuf bytes.Buffer
+	if err := text.ExecuteTemplate(&subjectBuf, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := text.Execute(&textBuf, data); err != nil {
+		return nil, err
+	}
+	if err := html.Execute(&htmlBuf, data); err != nil {
+		return nil, err
+	}
+
+	return &Email{
+		Subject: strings.TrimSpace(subjectBuf.String()),
+		HTML:    htmlBuf.String(),
+		Text:    textBuf.String(),
+	}, nil
+}
@@ -0,0 +1,116 @@
+package mailer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+)
+
+func testTemplates(t *testing.T) *Templates {
+	t.Helper()
+	templates, err := NewTemplates(config.Load().Mailer.Branding)
+	if err != nil {
+		t.Fatalf("failed to parse templates: %v", err)
+	}
+	return templates
+}
+
+func TestRenderNotification(t *testing.T) {
+	templates := testTemplates(t)
+	data := NotificationData{
+		ElectionName:  "2030 Annual Election",
+		CandidateName: "<John Doe>",
+		Positions:     []string{"President", "Secretary"},
+		NominationID:  "00000000-0000-0000-0000-000000000000",
+		ReceiptHash:   "abc123",
+	}
+
+	notifications := []string{
+		NotificationNominationReceived,
+		NotificationNominationUpdated,
+		NotificationNominationWithdrawn,
+		NotificationVotingOpen,
+		NotificationVoteRecorded,
+		NotificationResultsPublished,
+	}
+	for _, name := range notifications {
+		t.Run(name, func(t *testing.T) {
+			email, err := templates.Render(name, data)
+			if err != nil {
+				t.Fatalf("failed to render: %v", err)
+			}
+			if !strings.Contains(email.Subject, data.ElectionName) {
+				t.Fatalf("expected the subject to name the election, got %q", email.Subject)
+			}
+			if !strings.Contains(email.HTML, data.ElectionName) || !strings.Contains(email.Text, data.ElectionName) {
+				t.Fatalf("expected both versions to name the election")
+			}
+			// values are escaped in the HTML version only
+			if strings.Contains(email.HTML, "<John Doe>") {
+				t.Fatalf("expected the candidate name to be escaped, got %s", email.HTML)
+			}
+		})
+	}
+
+	email, _ := templates.Render(NotificationNominationUpdated, data)
+	if !strings.Contains(email.Text, "- President\n- Secretary") {
+		t.Fatalf("expected positions to be listed, got %s", email.Text)
+	}
+	email, _ = templates.Render(NotificationVoteRecorded, data)
+	if !strings.Contains(email.Text, data.ReceiptHash) || !strings.Contains(email.HTML, data.ReceiptHash) {
+		t.Fatalf("expected the receipt hash to be included")
+	}
+
+	if _, err := templates.Render("unknown", data); !errors.Is(err, ErrUnknownTemplate) {
+		t.Fatalf("expected ErrUnknownTemplate, got %v", err)
+	}
+}
+
+func TestTemplatesBranding(t *testing.T) {
+	branding := config.BrandingConfig{
+		SocietyName:   "Example Society",
+		LogoURL:       "https://example.com/logo.png",
+		PrimaryColour: "#0055aa",
+		AccentColour:  "#eee",
+	}
+	templates, err := NewTemplates(branding)
+	if err != nil {
+		t.Fatalf("failed to parse templates: %v", err)
+	}
+
+	email, err := templates.RenderOTP("123456", "https://vote.example.com/login?token=abc", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	if email.Subject != "Your Example Society Vote OTP" {
+		t.Fatalf("unexpected subject %q", email.Subject)
+	}
+	for _, expected := range []string{"Example Society Elections", `src="https://example.com/logo.png"`, "color: #0055aa", "background-color: #eee", "123456"} {
+		if !strings.Contains(email.HTML, expected) {
+			t.Fatalf("expected the HTML to contain %q, got %s", expected, email.HTML)
+		}
+	}
+	expectedText := "Your OTP code is: 123456\n\nOr sign in with this link, which can only be used once:\nhttps://vote.example.com/login?token=abc\n\nThis code will expire in 10 minutes.\n\nRegards,\nExample Society\n"
+	if email.Text != expectedText {
+		t.Fatalf("expected text %q, got %q", expectedText, email.Text)
+	}
+	if strings.Contains(email.HTML+email.Text, "Linux Society") {
+		t.Fatalf("expected no default branding")
+	}
+
+	// the logo is optional
+	branding.LogoURL = ""
+	templates, _ = NewTemplates(branding)
+	if email, _ := templates.RenderOTP("123456", "", 10*time.Minute); strings.Contains(email.HTML, "<img") {
+		t.Fatalf("expected no logo, got %s", email.HTML)
+	}
+
+	// colours are put in styles, so only hex colours are allowed
+	branding.PrimaryColour = "red; display: none"
+	if _, err := NewTemplates(branding); !errors.Is(err, ErrInvalidColour) {
+		t.Fatalf("expected ErrInvalidColour, got %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+// Package memberlist parses membership exports from Arc/Rubric into election members.
+package memberlist
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+var ErrNoZIDColumn = errors.New("no zID column found in header")
+
+// A row that couldn't be imported. Row counts from 1 at the header, matching the line
+// numbers a spreadsheet shows.
+type RowError struct {
+	Row     int    `json:"row" example:"2"`
+	Column  string `json:"column,omitempty" doc:"Header of the column with the problem, if there is one" example:"zID"`
+	Value   string `json:"value,omitempty" example:"z123"`
+	Message string `json:"message" example:"invalid zID"`
+}
+
+type Result struct {
+	Members []store.ElectionMember
+	Errors  []RowError
+	// data rows read, not including the header or blank lines
+	Rows int
+}
+
+type column int
+
+const (
+	columnZID column = iota
+	columnName
+	columnFirstName
+	columnLastName
+	columnEmail
+	columnExpiry
+)
+
+// header names are compared lowercased with anything but letters and digits removed
+var headerAliases = map[string]column{
+	"zid":               columnZID,
+	"studentid":         columnZID,
+	"studentnumber":     columnZID,
+	"studentno":         columnZID,
+	"name":              columnName,
+	"fullname":          columnName,
+	"membername":        columnName,
+	"firstname":         columnFirstName,
+	"preferredname":     columnFirstName,
+	"givenname":         columnFirstName,
+	"lastname":          columnLastName,
+	"surname":           columnLastName,
+	"familyname":        columnLastName,
+	"email":             columnEmail,
+	"emailaddress":      columnEmail,
+	"expiry":            columnExpiry,
+	"expires":           columnExpiry,
+	"expirydate":        columnExpiry,
+	"membershipexpiry":  columnExpiry,
+	"membershipexpires": columnExpiry,
+	"membershipend":     columnExpiry,
+	"enddate":           columnExpiry,
+}
+
+// date formats seen in exports, Arc uses Australian day first dates
+var expiryLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"02/01/2006 15:04",
+}
+
+// dates without a time, valid until the end of that day
+var expiryDateLayouts = []string{
+	"2006-01-02",
+	"02/01/2006",
+	"2/1/2006",
+	"2 Jan 2006",
+	"2 January 2006",
+}
+
+func normaliseHeader(header string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// values without a time zone are read in loc
+func parseExpiry(value string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range expiryLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, true
+		}
+	}
+	for _, layout := range expiryDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.AddDate(0, 0, 1).Add(-time.Microsecond), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Parses a CSV membership export. The header row is used to find the zID, name, email
+// and membership expiry columns, other columns are ignored. Only the zID column is
+// required. zIDs are normalised and validated by ids. Rows with an invalid zID or email,
+// an unreadable or past expiry, or a zID seen on an earlier row are reported in Errors and
+// left out of Members. Expiry dates and times without a time zone are in loc. Returns
+// ErrNoZIDColumn if the header has no zID column, or an error if the file isn't valid CSV.
+func Parse(r io.Reader, now time.Time, loc *time.Location, ids identity.Provider) (*Result, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrNoZIDColumn
+	} else if err != nil {
+		return nil, err
+	}
+
+	// the first column with a known header wins
+	columns := make(map[column]int)
+	headers := make(map[column]string)
+	for i, name := range header {
+		// excel adds a byte order mark to the start of the first header
+		name = strings.TrimPrefix(name, "\ufeff")
+		col, ok := headerAliases[normaliseHeader(name)]
+		if !ok {
+			continue
+		}
+		if _, seen := columns[col]; !seen {
+			columns[col] = i
+			headers[col] = strings.TrimSpace(name)
+		}
+	}
+	if _, ok := columns[columnZID]; !ok {
+		return nil, ErrNoZIDColumn
+	}
+
+	field := func(record []string, col column) string {
+		i, ok := columns[col]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	result := &Result{}
+	seen := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		// blank lines are skipped by the reader, so take the row from where it was read
+		row, _ := reader.FieldPos(0)
+
+		blank := true
+		for _, value := range record {
+			if strings.TrimSpace(value) != "" {
+				blank = false
+				break
+			}
+		}
+		if blank {
+			continue
+		}
+		result.Rows++
+
+		rawZID := field(record, columnZID)
+		zid := ids.Normalise(rawZID)
+		if !ids.Valid(zid) {
+			result.Errors = append(result.Errors, RowError{Row: row, Column: headers[columnZID], Value: rawZID, Message: "invalid zID"})
+			continue
+		}
+		if first, ok := seen[zid]; ok {
+			result.Errors = append(result.Errors, RowError{Row: row, Column: headers[columnZID], Value: rawZID, Message: fmt.Sprintf("duplicate of row %d", first)})
+			continue
+		}
+
+		member := store.ElectionMember{Zid: zid}
+
+		name := field(record, columnName)
+		if name == "" {
+			name = strings.TrimSpace(field(record, columnFirstName) + " " + field(record, columnLastName))
+		}
+		if name != "" {
+			member.Name = &name
+		}
+		if rawEmail := field(record, columnEmail); rawEmail != "" {
+			// emails are sent to it, so it mustn't be able to add headers
+			addr, err := mail.ParseAddress(rawEmail)
+			if err != nil {
+				result.Errors = append(result.Errors, RowError{Row: row, Column: headers[columnEmail], Value: rawEmail, Message: "invalid email"})
+				continue
+			}
+			member.Email = &addr.Address
+		}
+
+		if rawExpiry := field(record, columnExpiry); rawExpiry != "" {
+			expiry, ok := parseExpiry(rawExpiry, loc)
+			if !ok {
+				result.Errors = append(result.Errors, RowError{Row: row, Column: headers[columnExpiry], Value: rawExpiry, Message: "unrecognised date"})
+				continue
+			}
+			if expiry.Before(now) {
+				result.Errors = append(result.Errors, RowError{Row: row, Column: headers[columnExpiry], Value: rawExpiry, Message: "membership has expired"})
+				continue
+			}
+			member.MembershipExpiresAt = &expiry
+		}
+
+		seen[zid] = row
+		result.Members = append(result.Members, member)
+	}
+
+	return result, nil
+}
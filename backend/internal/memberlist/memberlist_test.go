@@ -0,0 +1,133 @@
+package memberlist
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+)
+
+var now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func testIdentity(t *testing.T) identity.Provider {
+	t.Helper()
+	ids, err := identity.New(config.Load().Identity)
+	if err != nil {
+		t.Fatalf("failed to create identity provider: %v", err)
+	}
+	return ids
+}
+
+func TestParse(t *testing.T) {
+	csv := "\ufeffStudent ID,First Name,Last Name,Email Address,Membership Expiry,Paid\n" +
+		"z1234567,John,Doe,john@example.com,31/12/2025,yes\n" +
+		"7654321,Jane,Roe,,,no\n" +
+		"\n" +
+		"Z1111111,Expired,Member,,28/02/2025,yes\n" +
+		"z123,Bad,Zid,,,yes\n" +
+		"z1234567,John,Again,,,yes\n" +
+		"z2222222,Bad,Date,,someday,yes\n" +
+		"z3333333,Last,Day,,01/03/2025,yes\n" +
+		"z4444444,Bad,Email,\"eve@example.com\r\nBcc: everyone@example.com\",,yes\n"
+
+	result, err := Parse(strings.NewReader(csv), now, time.UTC, testIdentity(t))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if result.Rows != 8 {
+		t.Fatalf("expected 8 rows, got %d", result.Rows)
+	}
+
+	var zids []string
+	for _, member := range result.Members {
+		zids = append(zids, member.Zid)
+	}
+	if !reflect.DeepEqual(zids, []string{"z1234567", "z7654321", "z3333333"}) {
+		t.Fatalf("unexpected members %v", zids)
+	}
+
+	john := result.Members[0]
+	if john.Name == nil || *john.Name != "John Doe" || john.Email == nil || *john.Email != "john@example.com" {
+		t.Fatalf("expected John Doe's details, got %+v", john)
+	}
+	if john.MembershipExpiresAt == nil || john.MembershipExpiresAt.Format("2006-01-02") != "2025-12-31" {
+		t.Fatalf("expected membership to expire on 2025-12-31, got %v", john.MembershipExpiresAt)
+	}
+	if jane := result.Members[1]; jane.Email != nil || jane.MembershipExpiresAt != nil {
+		t.Fatalf("expected empty columns to be unset, got %+v", jane)
+	}
+
+	expected := []RowError{
+		{Row: 5, Column: "Membership Expiry", Value: "28/02/2025", Message: "membership has expired"},
+		{Row: 6, Column: "Student ID", Value: "z123", Message: "invalid zID"},
+		{Row: 7, Column: "Student ID", Value: "z1234567", Message: "duplicate of row 2"},
+		{Row: 8, Column: "Membership Expiry", Value: "someday", Message: "unrecognised date"},
+		// a header could be injected into emails sent to it
+		{Row: 10, Column: "Email Address", Value: "eve@example.com\nBcc: everyone@example.com", Message: "invalid email"},
+	}
+	if !reflect.DeepEqual(result.Errors, expected) {
+		t.Fatalf("expected errors %+v, got %+v", expected, result.Errors)
+	}
+}
+
+func TestParseExpiryLocation(t *testing.T) {
+	sydney := time.FixedZone("AEDT", 11*60*60)
+	csv := "zID,Expiry\n" +
+		"z1111111,28/02/2025\n" +
+		"z2222222,2025-03-01T12:00:00Z\n" +
+		"z3333333,01/03/2025 09:00\n" +
+		"z4444444,01/03/2025\n"
+
+	// already 1 March in Sydney, though still 28 February in UTC
+	result, err := Parse(strings.NewReader(csv), time.Date(2025, 2, 28, 14, 0, 0, 0, time.UTC), sydney, testIdentity(t))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	expected := []RowError{
+		{Row: 2, Column: "Expiry", Value: "28/02/2025", Message: "membership has expired"},
+	}
+	if !reflect.DeepEqual(result.Errors, expected) {
+		t.Fatalf("expected errors %+v, got %+v", expected, result.Errors)
+	}
+
+	expiries := map[string]time.Time{
+		// times with a zone keep it
+		"z2222222": time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		"z3333333": time.Date(2025, 3, 1, 9, 0, 0, 0, sydney),
+		// dates last until the end of the day in Sydney
+		"z4444444": time.Date(2025, 3, 1, 23, 59, 59, 999999000, sydney),
+	}
+	if len(result.Members) != len(expiries) {
+		t.Fatalf("expected %d members, got %+v", len(expiries), result.Members)
+	}
+	for _, member := range result.Members {
+		if member.MembershipExpiresAt == nil || !member.MembershipExpiresAt.Equal(expiries[member.Zid]) {
+			t.Fatalf("expected %s's membership to expire at %v, got %v", member.Zid, expiries[member.Zid], member.MembershipExpiresAt)
+		}
+	}
+}
+
+func TestParseNameColumn(t *testing.T) {
+	result, err := Parse(strings.NewReader("zID,Name\nz1234567,John Doe\n"), now, time.UTC, testIdentity(t))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if len(result.Members) != 1 || *result.Members[0].Name != "John Doe" {
+		t.Fatalf("expected John Doe, got %+v", result.Members)
+	}
+}
+
+func TestParseNoZIDColumn(t *testing.T) {
+	for _, csv := range []string{"", "Name,Email\nJohn Doe,john@example.com\n"} {
+		_, err := Parse(strings.NewReader(csv), now, time.UTC, testIdentity(t))
+		if !errors.Is(err, ErrNoZIDColumn) {
+			t.Fatalf("expected ErrNoZIDColumn for %q, got %v", csv, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/mailer"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+// how many members are queued per statement when notifying every member
+const membersPageSize = 500
+
+type notificationPayload struct {
+	Template string                  `json:"template"`
+	Data     mailer.NotificationData `json:"data"`
+}
+
+func newNotification(idempotencyKey, toEmail, template string, data mailer.NotificationData) (store.NewOutboxEmail, error) {
+	payload, err := json.Marshal(notificationPayload{Template: template, Data: data})
+	if err != nil {
+		return store.NewOutboxEmail{}, err
+	}
+
+	return store.NewOutboxEmail{
+		IdempotencyKey: idempotencyKey,
+		Kind:           store.EmailKindNotification,
+		Recipient:      toEmail,
+		Payload:        payload,
+	}, nil
+}
+
+// Queues a notification for the worker to send, so the request that triggered it isn't
+// kept waiting on the mail provider. An error means the notification couldn't be queued.
+func (o *Outbox) Notify(ctx context.Context, idempotencyKey, toEmail, template string, data mailer.NotificationData) error {
+	email, err := newNotification(idempotencyKey, toEmail, template, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = o.emails.EnqueueBatch(ctx, []store.NewOutboxEmail{email}, o.NowProvider())
+	return err
+}
+
+// Queues a notification for every member of an election, a page of members at a time.
+// Each member's idempotency key is keyPrefix followed by their zID, so notifying the same
+// members again with the same prefix queues nothing new. Members the identity provider
+// has no address for are skipped.
+func (o *Outbox) NotifyMembers(ctx context.Context, electionId, keyPrefix, template string, data mailer.NotificationData) (int, error) {
+	now := o.NowProvider()
+
+	queued := 0
+	after := ""
+	for {
+		members, err := o.elections.ListMembers(ctx, electionId, "", after, membersPageSize)
+		if err != nil {
+			return queued, err
+		}
+
+		emails := make([]store.NewOutboxEmail, 0, len(members))
+		for _, member := range members {
+			toEmail, err := o.ids.Address(member.Zid, member.Email)
+			if errors.Is(err, identity.ErrNoAddress) {
+				o.log.Warn("no email address to notify member", "zid", member.Zid, "election_id", electionId, "template", template)
+				continue
+			} else if err != nil {
+				return queued, err
+			}
+
+			email, err := newNotification(keyPrefix+member.Zid, toEmail, template, data)
+			if err != nil {
+				return queued, err
+			}
+			emails = append(emails, email)
+		}
+
+		n, err := o.emails.EnqueueBatch(ctx, emails, now)
+		queued += n
+		if err != nil {
+			return queued, err
+		}
+
+		if len(members) < membersPageSize {
+			return queued, nil
+		}
+		after = members[len(members)-1].Zid
+	}
+}
+
+// Notifies every member of an election that has just entered state, if members are told
+// about it. Voting can be reopened, so the time the state was entered is part of the
+// idempotency key. The transition has already happened, so failures are logged.
+func (o *Outbox) ElectionTransitioned(ctx context.Context, electionId string, state store.ElectionState) {
+	var template string
+	switch state {
+	case store.StateVotingOpen:
+		template = mailer.NotificationVotingOpen
+	case store.StateResults:
+		template = mailer.NotificationResultsPublished
+	default:
+		return
+	}
+
+	election, err := o.elections.GetElection(ctx, electionId)
+	if err != nil || election == nil {
+		o.log.Error("failed to get election to notify members", "error", err, "election_id", electionId, "state", state)
+		return
+	}
+
+	entered := election.VotingOpenAt
+	if state == store.StateResults {
+		entered = election.ResultsPublishedAt
+	}
+	if entered == nil {
+		o.log.Error("election has no time for the state it entered", "election_id", electionId, "state", state)
+		return
+	}
+
+	keyPrefix := fmt.Sprintf("%s:%s:%d:", template, electionId, entered.UnixNano())
+	queued, err := o.NotifyMembers(ctx, electionId, keyPrefix, template, mailer.NotificationData{
+		ElectionName: election.Name,
+	})
+	if err != nil {
+		o.log.Error("failed to notify members", "error", err, "election_id", electionId, "state", state, "queued", queued)
+		return
+	}
+	o.log.Info("notified members", "election_id", electionId, "state", state, "queued", queued)
+}
@@ -0,0 +1,219 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/mailer"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+var errExpired = errors.New("expired before it could be sent")
+
+// Sends emails, OTPs and notifications, through the email outbox. Each email is queued before it is sent, so one
+// that fails is retried with exponential backoff rather than lost, and is dead lettered
+// once it has failed too many times. Every API process runs a worker, claiming emails
+// makes sure each is only sent by one of them.
+type Outbox struct {
+	log       *slog.Logger
+	emails    store.EmailOutboxStore
+	mailer    mailer.Mailer
+	templates *mailer.Templates
+	dashboard store.DashboardStore
+	elections store.ElectionStore
+	ids       identity.Provider
+	cfg       config.OutboxConfig
+
+	NowProvider func() time.Time
+}
+
+func New(log *slog.Logger, emails store.EmailOutboxStore, mailer mailer.Mailer, templates *mailer.Templates, dashboard store.DashboardStore, elections store.ElectionStore, ids identity.Provider, cfg config.OutboxConfig) *Outbox {
+	return &Outbox{
+		log:       log,
+		emails:    emails,
+		mailer:    mailer,
+		templates: templates,
+		dashboard: dashboard,
+		elections: elections,
+		ids:       ids,
+		cfg:       cfg,
+
+		NowProvider: time.Now,
+	}
+}
+
+type otpPayload struct {
+	Zid  string `json:"zid"`
+	Code string `json:"code"`
+	Link string `json:"link,omitempty"`
+}
+
+// Queues an OTP email for zid and tries to send it straight away. An error means the
+// email couldn't be queued, if it was queued but couldn't be sent it is retried until
+// expiresAt.
+func (o *Outbox) SendOTP(ctx context.Context, idempotencyKey, zid, toEmail, code, link string, expiresAt time.Time) error {
+	payload, err := json.Marshal(otpPayload{Zid: zid, Code: code, Link: link})
+	if err != nil {
+		return err
+	}
+
+	return o.enqueue(ctx, store.NewOutboxEmail{
+		IdempotencyKey: idempotencyKey,
+		Kind:           store.EmailKindOTP,
+		Recipient:      toEmail,
+		Payload:        payload,
+		ExpiresAt:      &expiresAt,
+	})
+}
+
+func (o *Outbox) enqueue(ctx context.Context, email store.NewOutboxEmail) error {
+	queued, created, err := o.emails.Enqueue(ctx, email, o.NowProvider().Add(o.cfg.Lease))
+	if err != nil {
+		return err
+	}
+	if !created {
+		// queued by an earlier call, which is sending it
+		return nil
+	}
+
+	// the email is safely queued, so sending it shouldn't be cut short if the request is
+	o.deliver(context.WithoutCancel(ctx), *queued)
+	return nil
+}
+
+// Retries failed emails every interval until ctx is cancelled
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := o.Tick(ctx); err != nil && ctx.Err() == nil {
+			o.log.Error("failed to send queued emails", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sends every email that is due. An email that fails is rescheduled or dead lettered and
+// doesn't stop the rest.
+func (o *Outbox) Tick(ctx context.Context) error {
+	for {
+		now := o.NowProvider()
+		due, err := o.emails.ClaimDue(ctx, now, now.Add(o.cfg.Lease), o.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, email := range due {
+			o.deliver(ctx, email)
+		}
+		if len(due) < o.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// attempts to send a claimed email, recording the outcome. failures are logged, as the
+// email is still queued and will be retried
+func (o *Outbox) deliver(ctx context.Context, email store.OutboxEmail) {
+	if email.ExpiresAt != nil && !o.NowProvider().Before(*email.ExpiresAt) {
+		o.fail(ctx, email, errExpired)
+		return
+	}
+
+	if err := o.send(email); err != nil {
+		o.fail(ctx, email, err)
+		return
+	}
+
+	if err := o.emails.MarkSent(ctx, email.EmailID, o.NowProvider()); err != nil {
+		o.log.Error("failed to mark email as sent", "error", err, "email_id", email.EmailID)
+	}
+	o.recordOTPEvent(ctx, email, store.OTPEventSent)
+}
+
+func (o *Outbox) send(email store.OutboxEmail) error {
+	switch email.Kind {
+	case store.EmailKindOTP:
+		var payload otpPayload
+		if err := json.Unmarshal(email.Payload, &payload); err != nil {
+			return err
+		}
+		return o.mailer.SendOTP(email.Recipient, payload.Code, payload.Link)
+	case store.EmailKindNotification:
+		var payload notificationPayload
+		if err := json.Unmarshal(email.Payload, &payload); err != nil {
+			return err
+		}
+		msg, err := o.templates.Render(payload.Template, payload.Data)
+		if err != nil {
+			return err
+		}
+		return o.mailer.Send(email.Recipient, msg)
+	default:
+		return fmt.Errorf("unknown email kind %q", email.Kind)
+	}
+}
+
+// reschedules an email after a failed attempt, or dead letters it if it has failed too
+// many times or wouldn't be retried before it expires
+func (o *Outbox) fail(ctx context.Context, email store.OutboxEmail, sendErr error) {
+	attempts := email.Attempts + 1
+
+	var retryAt *time.Time
+	if !errors.Is(sendErr, errExpired) && attempts < o.cfg.MaxAttempts {
+		next := o.NowProvider().Add(o.Backoff(attempts))
+		if email.ExpiresAt == nil || next.Before(*email.ExpiresAt) {
+			retryAt = &next
+		}
+	}
+
+	if err := o.emails.MarkFailed(ctx, email.EmailID, sendErr, retryAt); err != nil {
+		o.log.Error("failed to mark email as failed", "error", err, "email_id", email.EmailID)
+		return
+	}
+
+	if retryAt == nil {
+		o.log.Error("dead lettered email", "error", sendErr, "email_id", email.EmailID, "kind", email.Kind, "attempts", attempts)
+		o.recordOTPEvent(ctx, email, store.OTPEventSendFailed)
+		return
+	}
+	o.log.Warn("failed to send email, will retry", "error", sendErr, "email_id", email.EmailID, "kind", email.Kind, "attempts", attempts, "retry_at", *retryAt)
+}
+
+// How long to wait before retrying an email that has failed attempts times
+func (o *Outbox) Backoff(attempts int) time.Duration {
+	delay := o.cfg.BaseDelay
+	for i := 1; i < attempts && delay < o.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, o.cfg.MaxDelay)
+}
+
+// records sent and dead lettered OTP emails for the admin dashboard. the dashboard is
+// informational, so a failure is logged
+func (o *Outbox) recordOTPEvent(ctx context.Context, email store.OutboxEmail, event store.OTPEvent) {
+	if email.Kind != store.EmailKindOTP {
+		return
+	}
+
+	var payload otpPayload
+	if err := json.Unmarshal(email.Payload, &payload); err != nil {
+		o.log.Error("failed to read OTP email payload", "error", err, "email_id", email.EmailID)
+		return
+	}
+	if err := o.dashboard.RecordOTPEvent(ctx, payload.Zid, event); err != nil {
+		o.log.Error("failed to record OTP event", "error", err, "event", event, "email_id", email.EmailID)
+	}
+}
@@ -0,0 +1,318 @@
+package outbox_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/mailer"
+	"github.com/linuxunsw/vote/backend/internal/outbox"
+	"github.com/linuxunsw/vote/backend/internal/store"
+	"github.com/linuxunsw/vote/backend/internal/store/pg"
+	"github.com/linuxunsw/vote/backend/internal/store/pg/harness"
+)
+
+func TestMain(m *testing.M) {
+	harness.HarnessMain(m)
+}
+
+// fails the first failures sends, then records the rest
+type flakyMailer struct {
+	mu       sync.Mutex
+	failures int
+	sent     []string
+}
+
+func (m *flakyMailer) SendOTP(toEmail, otpCode, link string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failures != 0 {
+		m.failures--
+		return errors.New("provider unavailable")
+	}
+	m.sent = append(m.sent, otpCode)
+	return nil
+}
+
+func (m *flakyMailer) Send(toEmail string, email *mailer.Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failures != 0 {
+		m.failures--
+		return errors.New("provider unavailable")
+	}
+	m.sent = append(m.sent, toEmail+": "+email.Subject)
+	return nil
+}
+
+func testConfig() config.OutboxConfig {
+	return config.OutboxConfig{
+		Interval:    time.Second,
+		BatchSize:   10,
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		Lease:       time.Minute,
+	}
+}
+
+func newOutbox(t *testing.T, sender *flakyMailer, now *time.Time) (*outbox.Outbox, store.EmailOutboxStore, store.ElectionStore) {
+	t.Helper()
+
+	pool := harness.EphemeralPool(t)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	nowProvider := func() time.Time { return *now }
+
+	emailStore := pg.NewPgEmailOutboxStore(pool)
+	emailStore.(*pg.PgEmailOutboxStore).NowProvider = nowProvider
+	dashboardStore := pg.NewPgDashboardStore(pool, config.Load().Dashboard)
+	identities, err := identity.New(config.Load().Identity)
+	if err != nil {
+		t.Fatalf("failed to create identity provider: %v", err)
+	}
+	electionStore := pg.NewPgElectionStore(pool, identities)
+
+	templates, err := mailer.NewTemplates(config.Load().Mailer.Branding)
+	if err != nil {
+		t.Fatalf("failed to parse email templates: %v", err)
+	}
+
+	ob := outbox.New(log, emailStore, sender, templates, dashboardStore, electionStore, identities, testConfig())
+	ob.NowProvider = nowProvider
+	return ob, emailStore, electionStore
+}
+
+func TestSendOTP(t *testing.T) {
+	ctx := t.Context()
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	mailer := &flakyMailer{}
+	ob, emails, _ := newOutbox(t, mailer, &now)
+
+	if err := ob.SendOTP(ctx, "otp:1", "z0000000", "z0000000@unsw.edu.au", "123456", "", now.Add(time.Hour)); err != nil {
+		t.Fatalf("SendOTP failed: %v", err)
+	}
+	// queuing the same email again doesn't send it twice
+	if err := ob.SendOTP(ctx, "otp:1", "z0000000", "z0000000@unsw.edu.au", "123456", "", now.Add(time.Hour)); err != nil {
+		t.Fatalf("SendOTP failed: %v", err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatalf("expected 1 email to be sent, got %d", len(mailer.sent))
+	}
+
+	sent, err := emails.ListEmails(ctx, store.EmailSent, 10)
+	if err != nil {
+		t.Fatalf("failed to list emails: %v", err)
+	}
+	if len(sent) != 1 || sent[0].Attempts != 1 || sent[0].Payload != nil {
+		t.Fatalf("expected 1 sent email with its payload cleared, got %+v", sent)
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	ctx := t.Context()
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	mailer := &flakyMailer{failures: 2}
+	ob, emails, _ := newOutbox(t, mailer, &now)
+
+	// the email is queued even though it can't be sent yet
+	if err := ob.SendOTP(ctx, "otp:1", "z0000000", "z0000000@unsw.edu.au", "123456", "", now.Add(time.Hour)); err != nil {
+		t.Fatalf("SendOTP failed: %v", err)
+	}
+
+	pending, _ := emails.ListEmails(ctx, store.EmailPending, 10)
+	if len(pending) != 1 || pending[0].Attempts != 1 || !pending[0].NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected a retry after 1 minute, got %+v", pending)
+	}
+
+	// not due yet
+	now = now.Add(30 * time.Second)
+	if err := ob.Tick(ctx); err != nil {
+		t.Fatalf("failed to tick: %v", err)
+	}
+	if pending, _ := emails.ListEmails(ctx, store.EmailPending, 10); pending[0].Attempts != 1 {
+		t.Fatalf("expected no new attempt, got %d attempts", pending[0].Attempts)
+	}
+
+	// fails again, so the delay doubles
+	now = now.Add(30 * time.Second)
+	if err := ob.Tick(ctx); err != nil {
+		t.Fatalf("failed to tick: %v", err)
+	}
+	pending, _ = emails.ListEmails(ctx, store.EmailPending, 10)
+	if len(pending) != 1 || pending[0].Attempts != 2 || !pending[0].NextAttemptAt.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("expected a retry after 2 minutes, got %+v", pending)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := ob.Tick(ctx); err != nil {
+		t.Fatalf("failed to tick: %v", err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatalf("expected the email to be sent, got %d", len(mailer.sent))
+	}
+	if sent, _ := emails.ListEmails(ctx, store.EmailSent, 10); len(sent) != 1 || sent[0].Attempts != 3 {
+		t.Fatalf("expected 1 email sent on its 3rd attempt, got %+v", sent)
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	ctx := t.Context()
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	sender := &flakyMailer{failures: 3}
+	ob, emails, _ := newOutbox(t, sender, &now)
+
+	data := mailer.NotificationData{ElectionName: "Test Election"}
+	if err := ob.Notify(ctx, "voting_open:1", "z0000000@unsw.edu.au", mailer.NotificationVotingOpen, data); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	for range 3 {
+		if err := ob.Tick(ctx); err != nil {
+			t.Fatalf("failed to tick: %v", err)
+		}
+		now = now.Add(time.Hour / 4)
+	}
+
+	dead, _ := emails.ListEmails(ctx, store.EmailDead, 10)
+	if len(dead) != 1 || dead[0].Attempts != 3 {
+		t.Fatalf("expected 1 email dead lettered after 3 attempts, got %+v", dead)
+	}
+
+	// dead emails aren't attempted again until they're retried
+	now = now.Add(time.Minute)
+	_ = ob.Tick(ctx)
+	if len(sender.sent) != 0 {
+		t.Fatalf("expected no emails to be sent, got %d", len(sender.sent))
+	}
+
+	retried, err := emails.RetryEmail(ctx, dead[0].EmailID, now)
+	if err != nil {
+		t.Fatalf("failed to retry email: %v", err)
+	}
+	if retried.Status != store.EmailPending || retried.Attempts != 0 {
+		t.Fatalf("expected a pending email with no attempts, got %+v", retried)
+	}
+	if err := ob.Tick(ctx); err != nil {
+		t.Fatalf("failed to tick: %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected the retried email to be sent, got %d", len(sender.sent))
+	}
+
+	if _, err := emails.RetryEmail(ctx, dead[0].EmailID, now); !errors.Is(err, store.ErrEmailNotRetryable) {
+		t.Fatalf("expected ErrEmailNotRetryable retrying a sent email, got %v", err)
+	}
+	if _, err := emails.RetryEmail(ctx, "not-a-uuid", now); !errors.Is(err, store.ErrEmailNotFound) {
+		t.Fatalf("expected ErrEmailNotFound, got %v", err)
+	}
+}
+
+func TestDeadLetterOTP(t *testing.T) {
+	ctx := t.Context()
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	sender := &flakyMailer{failures: 3}
+	ob, emails, _ := newOutbox(t, sender, &now)
+
+	if err := ob.SendOTP(ctx, "otp:1", "z0000000", "z0000000@unsw.edu.au", "123456", "", now.Add(time.Hour)); err != nil {
+		t.Fatalf("SendOTP failed: %v", err)
+	}
+	for range 2 {
+		now = now.Add(time.Hour / 4)
+		if err := ob.Tick(ctx); err != nil {
+			t.Fatalf("failed to tick: %v", err)
+		}
+	}
+
+	// the code isn't kept once it won't be sent, so it can't be retried either
+	dead, _ := emails.ListEmails(ctx, store.EmailDead, 10)
+	if len(dead) != 1 || dead[0].Payload != nil {
+		t.Fatalf("expected 1 dead email with its payload cleared, got %+v", dead)
+	}
+	if _, err := emails.RetryEmail(ctx, dead[0].EmailID, now); !errors.Is(err, store.ErrEmailNotRetryable) {
+		t.Fatalf("expected ErrEmailNotRetryable, got %v", err)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	ctx := t.Context()
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	mailer := &flakyMailer{failures: 1}
+	ob, emails, _ := newOutbox(t, mailer, &now)
+
+	// the retry would be after the code expires, so there's no point
+	if err := ob.SendOTP(ctx, "otp:1", "z0000000", "z0000000@unsw.edu.au", "123456", "", now.Add(30*time.Second)); err != nil {
+		t.Fatalf("SendOTP failed: %v", err)
+	}
+	dead, _ := emails.ListEmails(ctx, store.EmailDead, 10)
+	if len(dead) != 1 {
+		t.Fatalf("expected 1 dead email, got %d", len(dead))
+	}
+
+	// and expired emails can't be retried
+	now = now.Add(time.Minute)
+	if _, err := emails.RetryEmail(ctx, dead[0].EmailID, now); !errors.Is(err, store.ErrEmailNotRetryable) {
+		t.Fatalf("expected ErrEmailNotRetryable, got %v", err)
+	}
+}
+
+func TestNotifyMembers(t *testing.T) {
+	ctx := t.Context()
+	now := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	sender := &flakyMailer{}
+	ob, emails, elections := newOutbox(t, sender, &now)
+
+	electionId, err := elections.CreateElection(ctx, "Test Election", store.DefaultPositions(nil), false)
+	if err != nil {
+		t.Fatalf("failed to create election: %v", err)
+	}
+	if err := elections.SetMembers(ctx, electionId, []string{"z0000000", "z0000001", "z0000002"}); err != nil {
+		t.Fatalf("failed to set members: %v", err)
+	}
+
+	data := mailer.NotificationData{ElectionName: "Test Election"}
+	queued, err := ob.NotifyMembers(ctx, electionId, "voting_open:1:", mailer.NotificationVotingOpen, data)
+	if err != nil {
+		t.Fatalf("NotifyMembers failed: %v", err)
+	}
+	if queued != 3 {
+		t.Fatalf("expected 3 emails to be queued, got %d", queued)
+	}
+	// the same notification isn't queued twice
+	if queued, _ := ob.NotifyMembers(ctx, electionId, "voting_open:1:", mailer.NotificationVotingOpen, data); queued != 0 {
+		t.Fatalf("expected no emails to be queued, got %d", queued)
+	}
+
+	// notifications wait for the worker
+	if len(sender.sent) != 0 {
+		t.Fatalf("expected no emails to be sent yet, got %d", len(sender.sent))
+	}
+	if err := ob.Tick(ctx); err != nil {
+		t.Fatalf("failed to tick: %v", err)
+	}
+	if len(sender.sent) != 3 || sender.sent[0] != "z0000000@unsw.edu.au: Voting is now open for Test Election" {
+		t.Fatalf("expected 3 voting open emails, got %v", sender.sent)
+	}
+	if sent, _ := emails.ListEmails(ctx, store.EmailSent, 10); len(sent) != 3 || sent[0].Kind != store.EmailKindNotification {
+		t.Fatalf("expected 3 sent notifications, got %+v", sent)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	ob := outbox.New(nil, nil, nil, nil, nil, nil, nil, testConfig())
+
+	expected := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute}
+	for i, delay := range expected {
+		if got := ob.Backoff(i + 1); got != delay {
+			t.Fatalf("attempt %d: expected %s, got %s", i+1, delay, got)
+		}
+	}
+	if got := ob.Backoff(100); got != time.Hour {
+		t.Fatalf("expected backoff to be capped at 1h, got %s", got)
+	}
+}
@@ -0,0 +1,99 @@
+package receipt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+)
+
+// length of the random nonce mixed into each ballot hash, so a hash can't be
+// matched to a ballot by hashing every possible vote
+const NonceSize = 16
+
+var ErrInvalidSigningKey = errors.New("receipt signing key must be a base64 encoded ed25519 seed")
+
+// A voter's proof of what they submitted. Hash appears on the bulletin once results are
+// published, next to the ballot it was counted as.
+type Receipt struct {
+	ElectionID string
+	Nonce      []byte
+	Hash       []byte
+	Signature  []byte
+}
+
+// Hashes a ballot's positions as SHA-256(nonce || JSON of positions). Positions are
+// encoded with object keys sorted, so anyone holding the nonce can recompute the hash.
+func Hash(nonce []byte, positions map[string][]string) ([]byte, error) {
+	encoded, err := json.Marshal(positions)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	h.Write(nonce)
+	h.Write(encoded)
+	return h.Sum(nil), nil
+}
+
+// the message that is signed, binding the hash to the election it was cast in
+func message(electionID string, hash []byte) []byte {
+	return []byte("vote-receipt:" + electionID + ":" + hex.EncodeToString(hash))
+}
+
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// Creates a signer from a base64 encoded ed25519 seed. If seed is empty a new key is
+// generated, and receipts signed by it can't be verified once the server restarts.
+func NewSigner(seed string) (*Signer, error) {
+	if seed == "" {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return &Signer{key: key}, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil || len(raw) != ed25519.SeedSize {
+		return nil, ErrInvalidSigningKey
+	}
+	return &Signer{key: ed25519.NewKeyFromSeed(raw)}, nil
+}
+
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// Hashes positions under a fresh nonce and signs the result
+func (s *Signer) Issue(electionID string, positions map[string][]string) (*Receipt, error) {
+	nonce := make([]byte, NonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	hash, err := Hash(nonce, positions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Receipt{
+		ElectionID: electionID,
+		Nonce:      nonce,
+		Hash:       hash,
+		Signature:  ed25519.Sign(s.key, message(electionID, hash)),
+	}, nil
+}
+
+// Reports whether the receipt was signed by the holder of publicKey
+func Verify(publicKey ed25519.PublicKey, r Receipt) bool {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(publicKey, message(r.ElectionID, r.Hash), r.Signature)
+}
@@ -0,0 +1,66 @@
+package receipt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	nonce := []byte("0123456789abcdef")
+	a, err := Hash(nonce, map[string][]string{"president": {"a", "b"}, "secretary": {"RON"}})
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	b, _ := Hash(nonce, map[string][]string{"secretary": {"RON"}, "president": {"a", "b"}})
+	if !bytes.Equal(a, b) {
+		t.Fatalf("expected the hash to not depend on map order")
+	}
+
+	reordered, _ := Hash(nonce, map[string][]string{"president": {"b", "a"}, "secretary": {"RON"}})
+	if bytes.Equal(a, reordered) {
+		t.Fatalf("expected a different ranking to change the hash")
+	}
+	otherNonce, _ := Hash([]byte("fedcba9876543210"), map[string][]string{"president": {"a", "b"}, "secretary": {"RON"}})
+	if bytes.Equal(a, otherNonce) {
+		t.Fatalf("expected a different nonce to change the hash")
+	}
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	signer, err := NewSigner(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, ed25519.SeedSize)))
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+
+	positions := map[string][]string{"president": {"a"}}
+	r, err := signer.Issue("election", positions)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if len(r.Nonce) != NonceSize {
+		t.Fatalf("expected a %d byte nonce, got %d", NonceSize, len(r.Nonce))
+	}
+	if hash, _ := Hash(r.Nonce, positions); !bytes.Equal(hash, r.Hash) {
+		t.Fatalf("expected the receipt hash to match the ballot")
+	}
+	if !Verify(signer.PublicKey(), *r) {
+		t.Fatalf("expected the receipt to verify")
+	}
+
+	moved := *r
+	moved.ElectionID = "other"
+	if Verify(signer.PublicKey(), moved) {
+		t.Fatalf("expected a receipt for another election to fail verification")
+	}
+
+	other, _ := NewSigner("")
+	if Verify(other.PublicKey(), *r) {
+		t.Fatalf("expected verification with another key to fail")
+	}
+
+	if _, err := NewSigner("not a key"); err != ErrInvalidSigningKey {
+		t.Fatalf("expected ErrInvalidSigningKey, got %v", err)
+	}
+}
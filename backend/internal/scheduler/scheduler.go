@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/outbox"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+// Runs scheduled state transitions for the current election. Every API process runs a
+// scheduler, the scheduler lock makes sure only one of them acts at a time.
+type Scheduler struct {
+	log       *slog.Logger
+	schedules store.ScheduleStore
+	elections store.ElectionStore
+	audit     store.AuditStore
+	outbox    *outbox.Outbox
+	interval  time.Duration
+
+	NowProvider func() time.Time
+}
+
+func New(log *slog.Logger, schedules store.ScheduleStore, elections store.ElectionStore, audit store.AuditStore, outbox *outbox.Outbox, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		log:       log,
+		schedules: schedules,
+		elections: elections,
+		audit:     audit,
+		outbox:    outbox,
+		interval:  interval,
+
+		NowProvider: time.Now,
+	}
+}
+
+// Checks for due transitions every interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			s.log.Error("failed to run scheduled transitions", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Runs any transitions that are due, unless another process is already running them.
+// A transition that fails is recorded against the schedule and doesn't stop the rest.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	_, err := s.schedules.WithSchedulerLock(ctx, s.runDue)
+	return err
+}
+
+func (s *Scheduler) runDue(ctx context.Context) error {
+	election, err := s.elections.CurrentElection(ctx)
+	if err != nil {
+		return err
+	}
+	if election == nil {
+		return nil
+	}
+
+	now := s.NowProvider()
+	due, err := s.schedules.DueTransitions(ctx, election.ElectionID, now)
+	if err != nil {
+		return err
+	}
+
+	state := election.State
+	for _, transition := range due {
+		// the transition and its audit entry are committed together, so a transition that
+		// couldn't be recorded doesn't happen and is marked as failed
+		runErr := s.audit.Record(ctx, func(ctx context.Context) (store.NewAuditEntry, error) {
+			if err := s.elections.CurrentElectionSetState(ctx, string(transition.State)); err != nil {
+				return store.NewAuditEntry{}, err
+			}
+			if state == transition.State {
+				return store.NewAuditEntry{}, nil
+			}
+			return store.NewAuditEntry{
+				ElectionID: election.ElectionID,
+				Actor:      store.AuditActorScheduler,
+				Action:     store.AuditStateTransitioned,
+				Details: map[string]any{
+					"from":         state,
+					"to":           transition.State,
+					"scheduled_by": transition.CreatedBy,
+					"run_at":       transition.RunAt,
+				},
+			}, nil
+		})
+		if err := s.schedules.MarkRan(ctx, election.ElectionID, transition.State, now, runErr); err != nil {
+			return err
+		}
+		if runErr != nil {
+			s.log.Warn("scheduled transition failed", "error", runErr, "election_id", election.ElectionID, "state", transition.State, "scheduled_by", transition.CreatedBy)
+			continue
+		}
+
+		s.log.Info("ran scheduled transition", "election_id", election.ElectionID, "from", state, "to", transition.State, "scheduled_by", transition.CreatedBy)
+		if state != transition.State {
+			s.outbox.ElectionTransitioned(ctx, election.ElectionID, transition.State)
+		}
+		state = transition.State
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package scheduler_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/identity"
+	"github.com/linuxunsw/vote/backend/internal/mailer/mock_mailer"
+	"github.com/linuxunsw/vote/backend/internal/outbox"
+	"github.com/linuxunsw/vote/backend/internal/scheduler"
+	"github.com/linuxunsw/vote/backend/internal/store"
+	"github.com/linuxunsw/vote/backend/internal/store/pg"
+	"github.com/linuxunsw/vote/backend/internal/store/pg/harness"
+)
+
+func TestMain(m *testing.M) {
+	harness.HarnessMain(m)
+}
+
+func TestSchedulerTick(t *testing.T) {
+	ctx := t.Context()
+	pool := harness.EphemeralPool(t)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	identities, err := identity.New(config.Load().Identity)
+	if err != nil {
+		t.Fatalf("failed to create identity provider: %v", err)
+	}
+
+	electionStore := pg.NewPgElectionStore(pool, identities)
+	scheduleStore := pg.NewPgScheduleStore(pool)
+	auditStore := pg.NewPgAuditStore(pool)
+
+	electionId, err := electionStore.CreateElection(ctx, "Test Election", store.DefaultPositions(nil), false)
+	if err != nil {
+		t.Fatalf("failed to create election: %v", err)
+	}
+
+	start := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	err = scheduleStore.SetSchedule(ctx, electionId, []store.ScheduleEntry{
+		{State: store.StateNominationsOpen, RunAt: start},
+		{State: store.StateNominationsClosed, RunAt: start.Add(time.Hour)},
+		// can't jump from NOMINATIONS_CLOSED, so this fails
+		{State: store.StateVotingClosed, RunAt: start.Add(2 * time.Hour)},
+	}, "z1234567")
+	if err != nil {
+		t.Fatalf("failed to set schedule: %v", err)
+	}
+
+	now := start.Add(-time.Minute)
+	emailOutbox := outbox.New(log, pg.NewPgEmailOutboxStore(pool), mock_mailer.NewMockMailer(), nil, pg.NewPgDashboardStore(pool, config.Load().Dashboard), electionStore, identities, config.Load().Outbox)
+	sched := scheduler.New(log, scheduleStore, electionStore, auditStore, emailOutbox, time.Minute)
+	sched.NowProvider = func() time.Time { return now }
+
+	// nothing is due yet
+	if err := sched.Tick(ctx); err != nil {
+		t.Fatalf("failed to tick: %v", err)
+	}
+	election, _ := electionStore.CurrentElection(ctx)
+	if election.State != store.StateClosed {
+		t.Fatalf("expected CLOSED, got %s", election.State)
+	}
+
+	// both nomination transitions are due
+	now = start.Add(time.Hour)
+	if err := sched.Tick(ctx); err != nil {
+		t.Fatalf("failed to tick: %v", err)
+	}
+	election, _ = electionStore.CurrentElection(ctx)
+	if election.State != store.StateNominationsClosed {
+		t.Fatalf("expected NOMINATIONS_CLOSED, got %s", election.State)
+	}
+
+	now = start.Add(3 * time.Hour)
+	if err := sched.Tick(ctx); err != nil {
+		t.Fatalf("failed to tick: %v", err)
+	}
+	election, _ = electionStore.CurrentElection(ctx)
+	if election.State != store.StateNominationsClosed {
+		t.Fatalf("expected NOMINATIONS_CLOSED, got %s", election.State)
+	}
+
+	transitions, err := scheduleStore.GetSchedule(ctx, electionId)
+	if err != nil {
+		t.Fatalf("failed to get schedule: %v", err)
+	}
+	for _, transition := range transitions {
+		if transition.RanAt == nil {
+			t.Fatalf("expected every transition to have run, got %+v", transition)
+		}
+	}
+	if transitions[0].Error != nil || transitions[1].Error != nil {
+		t.Fatalf("expected the nomination transitions to succeed, got %+v", transitions)
+	}
+	if transitions[2].Error == nil {
+		t.Fatalf("expected jumping to VOTING_CLOSED to fail, got %+v", transitions[2])
+	}
+
+	entries, err := auditStore.List(ctx, 0, 100)
+	if err != nil {
+		t.Fatalf("failed to list audit log: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %+v", entries)
+	}
+	for _, entry := range entries {
+		if entry.Actor != store.AuditActorScheduler || entry.Action != store.AuditStateTransitioned {
+			t.Fatalf("expected a transition by the scheduler, got %+v", entry)
+		}
+		details := map[string]any{}
+		_ = json.Unmarshal(entry.Details, &details)
+		if details["scheduled_by"] != "z1234567" {
+			t.Fatalf("expected the transition to be scheduled by z1234567, got %v", details["scheduled_by"])
+		}
+	}
+}
+
+func TestSchedulerLock(t *testing.T) {
+	ctx := t.Context()
+	pool := harness.EphemeralPool(t)
+	scheduleStore := pg.NewPgScheduleStore(pool)
+
+	// a second process can't take the lock while it is held
+	ran, err := scheduleStore.WithSchedulerLock(ctx, func(ctx context.Context) error {
+		ran, err := scheduleStore.WithSchedulerLock(ctx, func(ctx context.Context) error {
+			t.Fatal("expected the lock to be held")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if ran {
+			t.Fatal("expected the nested call not to run")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to take lock: %v", err)
+	}
+	if !ran {
+		t.Fatal("expected the lock to be taken")
+	}
+}
@@ -0,0 +1,25 @@
+package store
+
+import "testing"
+
+func TestAdminRoleCan(t *testing.T) {
+	tests := []struct {
+		role       AdminRole
+		permission Permission
+		want       bool
+	}{
+		{RoleReturningOfficer, PermissionManageAdmins, true},
+		{RoleReturningOfficer, PermissionManageElections, true},
+		{RoleElectionManager, PermissionManageElections, true},
+		{RoleElectionManager, PermissionManageAdmins, false},
+		{RoleScrutineer, PermissionViewElections, true},
+		{RoleScrutineer, PermissionManageElections, false},
+		{AdminRole("SUPERUSER"), PermissionViewElections, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Can(tt.permission); got != tt.want {
+			t.Errorf("%s.Can(%s) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+type AdminRole string
+
+const (
+	// runs the election and decides who else can administer it
+	RoleReturningOfficer AdminRole = "RETURNING_OFFICER"
+	// runs the election day to day
+	RoleElectionManager AdminRole = "ELECTION_MANAGER"
+	// observes the election without being able to change it
+	RoleScrutineer AdminRole = "SCRUTINEER"
+)
+
+type Permission string
+
+const (
+	// view elections, their members, nominations, schedule, the dashboard and audit log
+	PermissionViewElections Permission = "VIEW_ELECTIONS"
+	// create elections and change their members, positions, state and schedule
+	PermissionManageElections Permission = "MANAGE_ELECTIONS"
+	// grant and revoke admin roles
+	PermissionManageAdmins Permission = "MANAGE_ADMINS"
+)
+
+var rolePermissions = map[AdminRole][]Permission{
+	RoleReturningOfficer: {PermissionViewElections, PermissionManageElections, PermissionManageAdmins},
+	RoleElectionManager:  {PermissionViewElections, PermissionManageElections},
+	RoleScrutineer:       {PermissionViewElections},
+}
+
+// Whether the role grants the permission
+func (r AdminRole) Can(permission Permission) bool {
+	return slices.Contains(rolePermissions[r], permission)
+}
+
+type Admin struct {
+	Zid       string    `db:"zid" json:"zid" example:"z1234567"`
+	Role      AdminRole `db:"role" json:"role" enum:"RETURNING_OFFICER,ELECTION_MANAGER,SCRUTINEER"`
+	GrantedBy string    `db:"granted_by" json:"granted_by" example:"z7654321" doc:"zID of the admin who granted the role, or \"config\" for admins from ADMIN_ZIDS"`
+	GrantedAt time.Time `db:"granted_at" json:"granted_at" format:"date-time"`
+}
+
+// The granted_by of admins bootstrapped from ADMIN_ZIDS
+const AdminGrantedByConfig = "config"
+
+var ErrAdminNotFound = errors.New("admin not found")
+var ErrAdminLastReturningOfficer = errors.New("there must be at least one returning officer")
+
+type AdminStore interface {
+	// Get an admin by zID, or nil if they have no role.
+	GetAdmin(ctx context.Context, zid string) (*Admin, error)
+
+	// Get every admin, ordered by zID.
+	ListAdmins(ctx context.Context) ([]Admin, error)
+
+	// Grant role to zid on behalf of the admin grantedBy, replacing any role they have.
+	// Does not validate the zID or role.
+	// Returns error ErrAdminLastReturningOfficer if this would leave no returning officers.
+	GrantRole(ctx context.Context, zid string, role AdminRole, grantedBy string) (*Admin, error)
+
+	// Revoke zid's role.
+	// Returns error ErrAdminNotFound if they have no role.
+	// Returns error ErrAdminLastReturningOfficer if this would leave no returning officers.
+	RevokeRole(ctx context.Context, zid string) error
+
+	// Grant role to each of zids if there are no admins yet, recorded as granted by
+	// AdminGrantedByConfig. Used to bootstrap admins from ADMIN_ZIDS, once there are
+	// admins they are managed with GrantRole and RevokeRole.
+	EnsureAdmins(ctx context.Context, zids []string, role AdminRole) error
+}
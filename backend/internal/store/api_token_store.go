@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+// API tokens start with this, so they can be told apart from session JWTs
+const APITokenPrefix = "vote_"
+
+// A long-lived token for scripts and services. Its scopes are the permissions it may use,
+// limited to those its creator's role still grants.
+type APIToken struct {
+	TokenID    string       `json:"token_id" format:"uuid" doc:"Token ID"`
+	Name       string       `json:"name" example:"email-nominations" doc:"What the token is used for"`
+	Scopes     []Permission `json:"scopes" doc:"Permissions the token may use"`
+	CreatedBy  string       `json:"created_by" example:"z1234567" doc:"zID of the admin who created the token, requests made with it act as them"`
+	CreatedAt  time.Time    `json:"created_at" format:"date-time"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty" format:"date-time" doc:"When the token stops working, if it expires"`
+	LastUsedAt *time.Time   `json:"last_used_at,omitempty" format:"date-time"`
+	RevokedAt  *time.Time   `json:"revoked_at,omitempty" format:"date-time"`
+}
+
+type NewAPIToken struct {
+	Name      string
+	Scopes    []Permission
+	CreatedBy string
+	ExpiresAt *time.Time
+}
+
+var ErrAPITokenNotFound = errors.New("api token not found")
+
+// Generates a new API token, returning it and the hash to store. The token itself is only
+// shown once, when it is created.
+func GenerateAPIToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	token := APITokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashAPIToken(token), nil
+}
+
+// Whether s looks like an API token rather than a session JWT
+func IsAPIToken(s string) bool {
+	return strings.HasPrefix(s, APITokenPrefix)
+}
+
+// Tokens are random, so a fast hash is enough to stop a database leak revealing them
+func HashAPIToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+type APITokenStore interface {
+	// Create a token stored as hash.
+	CreateAPIToken(ctx context.Context, token NewAPIToken, hash []byte) (*APIToken, error)
+
+	// Get the token with hash if it hasn't expired or been revoked, recording that it was
+	// used. Returns nil if there isn't one.
+	UseAPIToken(ctx context.Context, hash []byte) (*APIToken, error)
+
+	// Get every token, including expired and revoked ones, newest first.
+	ListAPITokens(ctx context.Context) ([]APIToken, error)
+
+	// Revoke a token.
+	// Returns error ErrAPITokenNotFound if it doesn't exist or is already revoked.
+	RevokeAPIToken(ctx context.Context, tokenId string) error
+}
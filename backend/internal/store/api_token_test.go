@@ -0,0 +1,29 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateAPIToken(t *testing.T) {
+	token, hash, err := GenerateAPIToken()
+	if err != nil {
+		t.Fatalf("GenerateAPIToken failed: %v", err)
+	}
+	if !IsAPIToken(token) {
+		t.Fatalf("expected %q to start with %q", token, APITokenPrefix)
+	}
+	if !bytes.Equal(hash, HashAPIToken(token)) {
+		t.Fatalf("expected the hash to match the token")
+	}
+
+	other, otherHash, _ := GenerateAPIToken()
+	if token == other || bytes.Equal(hash, otherHash) {
+		t.Fatalf("expected tokens to be unique")
+	}
+
+	// session JWTs are three base64url sections, which never start with the prefix
+	if IsAPIToken("eyJhbGciOiJFZERTQSJ9.e30.sig") {
+		t.Fatalf("expected a JWT to not be an API token")
+	}
+}
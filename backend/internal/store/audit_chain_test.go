@@ -0,0 +1,84 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func auditChain(n int) []AuditEntry {
+	electionId := "01996ae6-31e5-7bc6-bac4-399ffc8c80de"
+	entries := make([]AuditEntry, 0, n)
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		entry := AuditEntry{
+			Seq:        int64(i),
+			ElectionID: &electionId,
+			Actor:      "z0000000",
+			Action:     AuditStateTransitioned,
+			Details:    json.RawMessage(`{"from":"CLOSED","to":"NOMINATIONS_OPEN"}`),
+			CreatedAt:  time.Date(2024, 1, 15, 10, 30, i, 0, time.UTC),
+			PrevHash:   prevHash,
+		}
+		entry.Hash = entry.ComputeHash()
+		prevHash = entry.Hash
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	testCases := []struct {
+		name        string
+		tamper      func(entries []AuditEntry) []AuditEntry
+		expectedSeq int64
+	}{
+		{"Untouched chain", func(entries []AuditEntry) []AuditEntry { return entries }, 0},
+		{"Changed details", func(entries []AuditEntry) []AuditEntry {
+			entries[1].Details = json.RawMessage(`{"from":"CLOSED","to":"VOTING_OPEN"}`)
+			return entries
+		}, 2},
+		{"Changed actor with recomputed hash", func(entries []AuditEntry) []AuditEntry {
+			entries[1].Actor = "z9999999"
+			entries[1].Hash = entries[1].ComputeHash()
+			return entries
+		}, 3},
+		{"Removed entry", func(entries []AuditEntry) []AuditEntry {
+			return append(entries[:1], entries[2:]...)
+		}, 3},
+		{"Removed last entry", func(entries []AuditEntry) []AuditEntry {
+			return entries[:2]
+		}, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyAuditChain(0, "", tc.tamper(auditChain(3)))
+			if tc.expectedSeq == 0 {
+				if err != nil {
+					t.Fatalf("expected chain to verify, got %v", err)
+				}
+				return
+			}
+
+			var chainErr *AuditChainError
+			if !errors.As(err, &chainErr) {
+				t.Fatalf("expected an AuditChainError, got %v", err)
+			}
+			if chainErr.Seq != tc.expectedSeq {
+				t.Fatalf("expected entry %d to be broken, got %d", tc.expectedSeq, chainErr.Seq)
+			}
+		})
+	}
+}
+
+func TestVerifyAuditChainContinues(t *testing.T) {
+	entries := auditChain(4)
+	if err := VerifyAuditChain(2, entries[1].Hash, entries[2:]); err != nil {
+		t.Fatalf("expected the rest of the chain to verify, got %v", err)
+	}
+	if err := VerifyAuditChain(0, "", entries[2:]); err == nil {
+		t.Fatalf("expected a chain not starting at the first entry to fail")
+	}
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditElectionCreated     AuditAction = "ELECTION_CREATED"
+	AuditStateTransitioned   AuditAction = "STATE_TRANSITIONED"
+	AuditMembersSet          AuditAction = "MEMBERS_SET"
+	AuditPositionsSet        AuditAction = "POSITIONS_SET"
+	AuditNominationSubmitted AuditAction = "NOMINATION_SUBMITTED"
+	AuditNominationDeleted   AuditAction = "NOMINATION_DELETED"
+	AuditAdminLogin          AuditAction = "ADMIN_LOGIN"
+	AuditScheduleSet         AuditAction = "SCHEDULE_SET"
+	AuditMemberAdded         AuditAction = "MEMBER_ADDED"
+	AuditMemberRemoved       AuditAction = "MEMBER_REMOVED"
+	AuditAdminGranted        AuditAction = "ADMIN_GRANTED"
+	AuditAdminRevoked        AuditAction = "ADMIN_REVOKED"
+	AuditSessionsRevoked     AuditAction = "SESSIONS_REVOKED"
+	AuditAPITokenCreated     AuditAction = "API_TOKEN_CREATED"
+	AuditAPITokenRevoked     AuditAction = "API_TOKEN_REVOKED"
+	AuditEmailRetried        AuditAction = "EMAIL_RETRIED"
+)
+
+// An action to record in the audit log
+type NewAuditEntry struct {
+	// empty if the action isn't tied to an election
+	ElectionID string
+	// zID of whoever performed the action, or AuditActorScheduler
+	Actor   string
+	Action  AuditAction
+	Details map[string]any
+}
+
+// Each entry's hash covers its own fields and the previous entry's hash, so changing or
+// removing an entry breaks every hash after it.
+type AuditEntry struct {
+	Seq        int64           `db:"seq" json:"seq" doc:"Position in the log, starting from 1"`
+	ElectionID *string         `db:"election_id" json:"election_id,omitempty"`
+	Actor      string          `db:"actor" json:"actor" example:"z1234567"`
+	Action     AuditAction     `db:"action" json:"action" enum:"ELECTION_CREATED,STATE_TRANSITIONED,MEMBERS_SET,POSITIONS_SET,NOMINATION_SUBMITTED,NOMINATION_DELETED,ADMIN_LOGIN,SCHEDULE_SET,MEMBER_ADDED,MEMBER_REMOVED,ADMIN_GRANTED,ADMIN_REVOKED,SESSIONS_REVOKED,API_TOKEN_CREATED,API_TOKEN_REVOKED,EMAIL_RETRIED"`
+	Details    json.RawMessage `db:"details" json:"details" doc:"Action specific details"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at" format:"date-time" example:"2024-01-15T10:30:00Z"`
+	PrevHash   string          `db:"prev_hash" json:"prev_hash" doc:"Hash of the previous entry, empty for the first entry"`
+	Hash       string          `db:"hash" json:"hash" doc:"Hex encoded SHA-256 of this entry's fields and the previous entry's hash"`
+}
+
+// Computes the hash of an entry from its fields and PrevHash, ignoring Hash
+func (e AuditEntry) ComputeHash() string {
+	// encoded in a fixed field order, details are stored as the exact bytes that were hashed
+	encoded, _ := json.Marshal(struct {
+		Seq        int64           `json:"seq"`
+		ElectionID *string         `json:"election_id"`
+		Actor      string          `json:"actor"`
+		Action     AuditAction     `json:"action"`
+		Details    json.RawMessage `json:"details"`
+		CreatedAt  string          `json:"created_at"`
+		PrevHash   string          `json:"prev_hash"`
+	}{e.Seq, e.ElectionID, e.Actor, e.Action, e.Details, e.CreatedAt.UTC().Format(time.RFC3339Nano), e.PrevHash})
+
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+type AuditChainError struct {
+	Seq    int64
+	Reason string
+}
+
+func (e *AuditChainError) Error() string {
+	return fmt.Sprintf("audit log entry %d: %s", e.Seq, e.Reason)
+}
+
+// Checks that entries follow on from an entry with hash prevHash and sequence number
+// prevSeq, and that each entry's hash matches its contents. Use an empty prevHash and
+// a prevSeq of 0 to check from the start of the log. Returns an *AuditChainError
+// describing the first broken entry. Entries removed from the end of the log can't be
+// detected from the entries alone.
+func VerifyAuditChain(prevSeq int64, prevHash string, entries []AuditEntry) error {
+	for _, entry := range entries {
+		if entry.Seq != prevSeq+1 {
+			return &AuditChainError{Seq: entry.Seq, Reason: fmt.Sprintf("expected entry %d, entries are missing", prevSeq+1)}
+		}
+		if entry.PrevHash != prevHash {
+			return &AuditChainError{Seq: entry.Seq, Reason: "previous hash does not match the previous entry"}
+		}
+		if entry.ComputeHash() != entry.Hash {
+			return &AuditChainError{Seq: entry.Seq, Reason: "hash does not match the entry's contents"}
+		}
+		prevSeq = entry.Seq
+		prevHash = entry.Hash
+	}
+	return nil
+}
+
+type AuditStore interface {
+	// Append an entry to the end of the log, chained to the entry before it.
+	Append(ctx context.Context, entry NewAuditEntry) error
+
+	// Run action and append the entry it returns in one transaction, so the action only
+	// happens if it is recorded. Stores called with the context given to action take part
+	// in the transaction. Errors from action are returned as they are, and nothing is
+	// appended. An entry with no Action means nothing happened that needs recording.
+	Record(ctx context.Context, action func(ctx context.Context) (NewAuditEntry, error)) error
+
+	// Get up to limit entries with a sequence number greater than afterSeq, oldest first.
+	List(ctx context.Context, afterSeq int64, limit int) ([]AuditEntry, error)
+}
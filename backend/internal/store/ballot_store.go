@@ -2,28 +2,59 @@ package store
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// Choices that can be made for any position in place of a public nomination ID
+const (
+	// The voter chose not to vote for the position. Must be the only choice for that position.
+	ChoiceAbstain = "ABSTAIN"
+	// Re-open nominations. Ranked and counted like a candidate, if it is elected the
+	// position is left vacant and nominations for it are re-opened.
+	ChoiceRON = "RON"
+)
+
+// Positions map each position to public nomination IDs, ordered by preference
+// with the first entry being the voter's first preference. Either may also be
+// ChoiceAbstain or ChoiceRON.
 type SubmitBallot struct {
-	Positions map[string]string `db:"positions"`
+	Positions map[string][]string `db:"positions"`
+	// hash from the receipt given to the voter, see receipt.Hash
+	ReceiptHash []byte `db:"receipt_hash"`
+	// public key the receipt was signed with, kept with the election rather than the ballot
+	ReceiptKey []byte
 }
 
 type Ballot struct {
-	Positions map[string]string `db:"positions"`
+	// nil for a secret ballot once voting has closed, as it can no longer be found
+	Positions   map[string][]string `db:"positions"`
+	ReceiptHash []byte              `db:"receipt_hash"`
 
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
 }
 
+var ErrBallotNotReplaceable = errors.New("ballot can no longer be replaced")
+var ErrBallotSecretDestroyed = errors.New("ballot secret has been destroyed")
+
+// In a secret ballot election, who has voted is stored apart from the ballot contents,
+// which are keyed by an HMAC of the voter's zID under a per-election secret. The secret
+// only exists while voting is open, so afterwards a ballot cannot be linked to its voter.
+// If voting is reopened a new secret is created, and ballots cast under the old one can
+// no longer be replaced or deleted.
 type BallotStore interface {
 	// Submit or replace an existing ballot for the given election and voter zid.
 	// Does not perform validation of the submission.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	// Returns error ErrBallotNotReplaceable if the voter's secret ballot can no longer be found.
+	// Returns error ErrBallotSecretDestroyed if the election's ballot secret does not exist.
 	SubmitOrReplaceBallot(ctx context.Context, electionID string, zid string, submission SubmitBallot) error
 
 	// Get a ballot by election ID and voter zID. Returns nil if not found.
 	GetBallot(ctx context.Context, electionID string, zid string) (*Ballot, error)
 
 	// Delete a ballot by election ID and voter zID. Does nothing if the ballot doesn't exist.
+	// Returns error ErrBallotNotReplaceable if the voter's secret ballot can no longer be found.
 	TryDeleteBallot(ctx context.Context, electionID string, zid string) error
 }
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type OTPEvent string
+
+const (
+	// a code was emailed
+	OTPEventSent OTPEvent = "SENT"
+	// a code couldn't be queued, or the mailer failed to send it
+	OTPEventSendFailed OTPEvent = "SEND_FAILED"
+	// a code was requested too often, so none was sent
+	OTPEventRateLimited OTPEvent = "RATE_LIMITED"
+	// a code was requested for someone with no address to email it to
+	OTPEventNoAddress OTPEvent = "NO_ADDRESS"
+	// a code was accepted and a session issued
+	OTPEventVerified OTPEvent = "VERIFIED"
+	// a wrong, expired or exhausted code was submitted
+	OTPEventRejected OTPEvent = "REJECTED"
+)
+
+// Counts of OTP events over a recent window
+type OTPStats struct {
+	Sent        int `json:"sent" doc:"Codes emailed"`
+	SendFailed  int `json:"send_failed" doc:"Codes that couldn't be queued, or that the mailer failed to send"`
+	RateLimited int `json:"rate_limited" doc:"Codes not sent because they were requested too often"`
+	NoAddress   int `json:"no_address" doc:"Codes not sent because there was no address to email them to"`
+	Verified    int `json:"verified" doc:"Codes accepted"`
+	Rejected    int `json:"rejected" doc:"Wrong, expired or exhausted codes submitted"`
+}
+
+type DashboardStats struct {
+	Turnout Turnout `json:"turnout"`
+	// every position in the election, including those nobody has nominated for
+	Nominations    map[string]int `json:"nominations" doc:"Number of nominations for each position key"`
+	OTP            OTPStats       `json:"otp"`
+	ActiveSessions int            `json:"active_sessions" doc:"Number of people with a session that hasn't expired or been revoked"`
+}
+
+type DashboardStore interface {
+	// Record an OTP event for zid. Events older than the store's retention are deleted.
+	RecordOTPEvent(ctx context.Context, zid string, event OTPEvent) error
+
+	// Get live statistics for an election. OTP events are counted from otpSince, and
+	// sessions active at now are counted.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	GetStats(ctx context.Context, electionId string, otpSince time.Time, now time.Time) (*DashboardStats, error)
+}
@@ -7,8 +7,31 @@ import (
 )
 
 type ElectionMemberEntry struct {
-	ElectionID string `db:"election_id"`
-	Zid        string `db:"zid"`
+	ElectionID string  `db:"election_id"`
+	Zid        string  `db:"zid"`
+	Email      *string `db:"email"`
+}
+
+// A member with the details from a membership export
+type ElectionMember struct {
+	Zid                 string     `db:"zid" json:"zid" example:"z1234567"`
+	Name                *string    `db:"name" json:"name,omitempty" example:"John Doe"`
+	Email               *string    `db:"email" json:"email,omitempty" example:"john@example.com"`
+	MembershipExpiresAt *time.Time `db:"membership_expires_at" json:"membership_expires_at,omitempty" format:"date-time"`
+}
+
+// A member as listed for admins
+type ListedMember struct {
+	ElectionMember
+	AddedBy *string    `db:"added_by" json:"added_by,omitempty" example:"z1234567" doc:"zID of the admin who added the member. Only set for members added one at a time."`
+	AddedAt *time.Time `db:"added_at" json:"added_at,omitempty" format:"date-time" doc:"When the member was added. Only set for members added one at a time."`
+}
+
+// How an import changed the member list
+type MemberImportSummary struct {
+	Added   int `json:"added" doc:"Members not on the list before"`
+	Removed int `json:"removed" doc:"Members on the list before who weren't imported"`
+	Kept    int `json:"kept" doc:"Members already on the list, their details are updated"`
 }
 
 type ElectionState string
@@ -97,6 +120,9 @@ type Election struct {
 	State      ElectionState `db:"state"`
 	CreatedAt  time.Time     `db:"created_at"`
 
+	// ballots are stored apart from who cast them, see BallotStore
+	SecretBallot bool `db:"secret_ballot"`
+
 	NominationsOpenAt  *time.Time `db:"nominations_open_at"`
 	NominationsCloseAt *time.Time `db:"nominations_close_at"`
 	VotingOpenAt       *time.Time `db:"voting_open_at"`
@@ -130,6 +156,9 @@ var ErrElectionCreateAlreadyRunning = errors.New("election is already running")
 
 var ErrElectionNotFound = errors.New("election not found")
 
+var ErrMemberAlreadyExists = errors.New("already a member")
+var ErrMemberNotFound = errors.New("member not found")
+
 type ElectionStore interface {
 	// Sets members for the current election. This is a string array of zIDs.
 	// Returns error ErrElectionSetMembersFailedValidation and aborts if any zID
@@ -137,21 +166,60 @@ type ElectionStore interface {
 	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
 	SetMembers(ctx context.Context, electionId string, entries []string) error
 
+	// Replaces the member list for an election with members, storing their details. Members
+	// must already be validated and deduplicated. If dryRun is set nothing is changed, the
+	// summary describes what the import would do.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	ImportMembers(ctx context.Context, electionId string, members []ElectionMember, dryRun bool) (*MemberImportSummary, error)
+
+	// Add a single member to an election, recording the admin addedBy who added them.
+	// Does not validate the zID.
+	// Returns error ErrMemberAlreadyExists if they are already a member.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	AddMember(ctx context.Context, electionId string, member ElectionMember, addedBy string) (*ListedMember, error)
+
+	// Remove a single member from an election.
+	// Returns error ErrMemberNotFound if they aren't a member.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	RemoveMember(ctx context.Context, electionId string, zid string) error
+
+	// List up to limit members of an election ordered by zID, starting after the zID
+	// after. If search is set, only members whose zID, name or email contain it (ignoring
+	// case) are listed.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	ListMembers(ctx context.Context, electionId string, search string, after string, limit int) ([]ListedMember, error)
+
+	// Count the members of an election, filtered by search as in ListMembers.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	CountMembers(ctx context.Context, electionId string, search string) (int, error)
+
 	// Get data for member by zid in this election. Returns nil with no error if not found.
 	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
 	GetMember(ctx context.Context, electionId string, zid string) (*ElectionMemberEntry, error)
 
-	// Create a new election and return its ID. Returns error ErrElectionCreateAlreadyRunning if
+	// Create a new election with the given positions and return its ID. Does not validate
+	// position keys are unique. Returns error ErrElectionCreateAlreadyRunning if
 	// there is already an election in progress (not in RESULTS state).
-	CreateElection(ctx context.Context, name string) (string, error)
+	// Publishes a StateChange to CLOSED.
+	CreateElection(ctx context.Context, name string, positions []Position, secretBallot bool) (string, error)
 
 	// Get the current election, or nil if none exists.
 	CurrentElection(ctx context.Context) (*Election, error)
 
+	// Get any election by ID, including past elections, or nil if it doesn't exist.
+	GetElection(ctx context.Context, electionId string) (*Election, error)
+
+	// List up to limit elections, newest first. If before is set, only elections created
+	// before that one are listed.
+	// Returns error ErrElectionNotFound if the election referenced by before does not exist.
+	ListElections(ctx context.Context, before string, limit int) ([]Election, error)
+
 	// a string type for newState has been chosen to better communicate intent
 
 	// Sets the state of the current to newState. Does not assume newState is a valid
 	// transition. Performs the validation and state transition inside.
+	// For secret ballot elections, entering VOTING_OPEN creates the ballot secret and
+	// entering VOTING_CLOSED destroys it. Publishes a StateChange if the state changed.
 	// Returns error ErrElectionNotFound if the election no election is running.
 	//
 	// Can return the following errors on invalid transitions:
@@ -160,3 +228,16 @@ type ElectionStore interface {
 	//   ErrElectionTransitionCannotRegress
 	CurrentElectionSetState(ctx context.Context, newState string) error
 }
+
+// A change to the current election's state, published when it commits
+type StateChange struct {
+	ElectionID string        `json:"election_id"`
+	State      ElectionState `json:"state"`
+}
+
+type StateListener interface {
+	// Calls fn with every state change made by CreateElection or CurrentElectionSetState
+	// in any API process, until ctx is cancelled or the connection is lost. listening is
+	// called once changes are being received, changes made before then are missed.
+	ListenStateChanges(ctx context.Context, listening func(), fn func(StateChange)) error
+}
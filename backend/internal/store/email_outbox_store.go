@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// What an email is, which decides how its payload is rendered
+type EmailKind string
+
+const (
+	EmailKindOTP EmailKind = "OTP"
+	// rendered from one of the mailer's notification templates
+	EmailKindNotification EmailKind = "NOTIFICATION"
+)
+
+type EmailStatus string
+
+const (
+	EmailPending EmailStatus = "PENDING"
+	EmailSent    EmailStatus = "SENT"
+	// gave up after too many failed attempts, or expired before it could be sent
+	EmailDead EmailStatus = "DEAD"
+)
+
+var (
+	ErrEmailNotFound     = errors.New("email not found")
+	ErrEmailNotRetryable = errors.New("only dead emails that haven't expired can be retried, except OTPs")
+)
+
+type NewOutboxEmail struct {
+	// emails queued with a key that is already in the outbox are only sent once
+	IdempotencyKey string
+	Kind           EmailKind
+	Recipient      string
+	Payload        json.RawMessage
+	// if set, the email is dead lettered rather than sent after this
+	ExpiresAt *time.Time
+}
+
+// An email in the outbox. The payload isn't included in responses, as it may hold OTP
+// codes.
+type OutboxEmail struct {
+	EmailID        string          `db:"email_id" json:"email_id" format:"uuid"`
+	IdempotencyKey string          `db:"idempotency_key" json:"idempotency_key" doc:"Emails queued with the same key are only sent once"`
+	Kind           EmailKind       `db:"kind" json:"kind" enum:"OTP,NOTIFICATION"`
+	Recipient      string          `db:"recipient" json:"recipient" example:"z1234567@unsw.edu.au"`
+	Payload        json.RawMessage `db:"payload" json:"-"`
+	Status         EmailStatus     `db:"status" json:"status" enum:"PENDING,SENT,DEAD"`
+	Attempts       int             `db:"attempts" json:"attempts" doc:"Number of times sending has been attempted"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at" json:"next_attempt_at" format:"date-time" doc:"When a pending email will next be attempted"`
+	LastError      *string         `db:"last_error" json:"last_error,omitempty" doc:"Why the last attempt failed, if it did"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at" format:"date-time"`
+	ExpiresAt      *time.Time      `db:"expires_at" json:"expires_at,omitempty" format:"date-time" doc:"When the email stops being worth sending, if it does"`
+	SentAt         *time.Time      `db:"sent_at" json:"sent_at,omitempty" format:"date-time"`
+}
+
+type EmailOutboxStore interface {
+	// Queue an email, claimed by the caller until leaseUntil so it can try sending it
+	// straight away. If an email with the same idempotency key is already queued, that
+	// email is returned instead and created is false.
+	Enqueue(ctx context.Context, email NewOutboxEmail, leaseUntil time.Time) (queued *OutboxEmail, created bool, err error)
+
+	// Queue emails to be sent from notBefore, skipping any whose idempotency key is already
+	// queued. Returns how many were queued.
+	EnqueueBatch(ctx context.Context, emails []NewOutboxEmail, notBefore time.Time) (int, error)
+
+	// Claim up to limit pending emails due at or before now, oldest first. Claimed emails
+	// aren't due again until leaseUntil, so other processes don't send them too.
+	ClaimDue(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]OutboxEmail, error)
+
+	// Record that an email was sent, clearing its payload.
+	// Returns error ErrEmailNotFound if the email referenced by ID does not exist.
+	MarkSent(ctx context.Context, emailId string, sentAt time.Time) error
+
+	// Record a failed attempt to send an email. It is attempted again at retryAt, or dead
+	// lettered if retryAt is nil. Dead lettered OTP emails have their payload cleared.
+	// Returns error ErrEmailNotFound if the email referenced by ID does not exist.
+	MarkFailed(ctx context.Context, emailId string, sendErr error, retryAt *time.Time) error
+
+	// List up to limit emails with a status, newest first.
+	ListEmails(ctx context.Context, status EmailStatus, limit int) ([]OutboxEmail, error)
+
+	// Queue a dead email to be attempted again at now, resetting its attempts.
+	// Returns error ErrEmailNotFound if the email referenced by ID does not exist, or
+	// ErrEmailNotRetryable if it isn't dead, has expired or its payload has been cleared.
+	RetryEmail(ctx context.Context, emailId string, now time.Time) (*OutboxEmail, error)
+}
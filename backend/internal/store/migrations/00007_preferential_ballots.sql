@@ -0,0 +1,24 @@
+-- +goose Up
+-- {"position": "nomination_id"} -> {"position": ["nomination_id", ...]}
+update ballots
+set positions = (
+    select coalesce(jsonb_object_agg(key, jsonb_build_array(value)), '{}'::jsonb)
+    from jsonb_each(positions)
+)
+where exists (
+    select 1 from jsonb_each(positions)
+    where jsonb_typeof(value) = 'string'
+);
+
+-- +goose Down
+-- only the first preference can be kept
+update ballots
+set positions = (
+    select coalesce(jsonb_object_agg(key, value -> 0), '{}'::jsonb)
+    from jsonb_each(positions)
+    where jsonb_array_length(value) > 0
+)
+where exists (
+    select 1 from jsonb_each(positions)
+    where jsonb_typeof(value) = 'array'
+);
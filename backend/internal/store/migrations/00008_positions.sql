@@ -0,0 +1,30 @@
+-- +goose Up
+create table positions (
+    election_id uuid,
+    key text,
+
+    display_name text not null,
+    seats integer not null default 1 check (seats > 0),
+    description text not null default '',
+
+    -- order the positions were defined in, for ballots and forms
+    sort_order integer not null,
+
+    primary key (election_id, key)
+);
+
+-- existing elections used the fixed set of executive roles
+insert into positions (election_id, key, display_name, seats, sort_order)
+select elections.election_id, defaults.key, defaults.display_name, 1, defaults.sort_order
+from elections
+cross join (values
+    ('president', 'President', 0),
+    ('secretary', 'Secretary', 1),
+    ('treasurer', 'Treasurer', 2),
+    ('arc_delegate', 'Arc Delegate', 3),
+    ('edi_officer', 'EDI Officer', 4),
+    ('grievance_officer', 'Grievance Officer', 5)
+) as defaults (key, display_name, sort_order);
+
+-- +goose Down
+drop table positions;
@@ -0,0 +1,41 @@
+-- +goose Up
+alter table elections
+    add column secret_ballot boolean not null default false;
+
+-- key for the tokens secret ballots are stored under. exists only while voting is
+-- open, once it is deleted tokens can no longer be linked back to a voter
+create table ballot_secrets (
+    election_id uuid primary key,
+    secret bytea not null
+);
+
+-- who has voted in a secret ballot election, but not how
+create table ballot_voters (
+    election_id uuid,
+    zid text,
+
+    -- false once the secret the ballot was stored under has been destroyed
+    replaceable boolean not null default true,
+
+    created_at timestamptz not null,
+    updated_at timestamptz not null,
+
+    primary key (election_id, zid)
+);
+
+-- ballot contents in a secret ballot election, keyed by hmac(secret, zid)
+create table secret_ballots (
+    election_id uuid,
+    token bytea,
+
+    -- {"position": ["nomination_id", ...]}
+    positions jsonb not null,
+
+    primary key (election_id, token)
+);
+
+-- +goose Down
+drop table secret_ballots;
+drop table ballot_voters;
+drop table ballot_secrets;
+alter table elections drop column secret_ballot;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- sha256(nonce || positions), listed on the bulletin so voters can find their ballot.
+-- null for ballots cast before receipts were issued
+alter table ballots add column receipt_hash bytea;
+alter table secret_ballots add column receipt_hash bytea;
+
+-- +goose Down
+alter table secret_ballots drop column receipt_hash;
+alter table ballots drop column receipt_hash;
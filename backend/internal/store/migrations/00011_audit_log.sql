@@ -0,0 +1,34 @@
+-- +goose Up
+create table audit_log (
+    seq bigint primary key,
+
+    election_id uuid,
+    actor text not null,
+    action text not null,
+    -- kept as text so the exact bytes that were hashed are preserved
+    details text not null,
+
+    created_at timestamptz not null,
+
+    -- hex encoded sha256, see store.AuditEntry
+    prev_hash text not null,
+    hash text not null unique
+);
+
+-- entries are only ever appended
+-- +goose StatementBegin
+create function audit_log_append_only() returns trigger as $$
+begin
+    raise exception 'audit_log is append only';
+end;
+$$ language plpgsql;
+-- +goose StatementEnd
+
+create trigger audit_log_append_only
+before update or delete on audit_log
+for each row execute function audit_log_append_only();
+
+-- +goose Down
+drop trigger audit_log_append_only on audit_log;
+drop function audit_log_append_only();
+drop table audit_log;
@@ -0,0 +1,24 @@
+-- +goose Up
+create table election_schedules (
+    election_id uuid references elections (election_id) on delete cascade,
+    state election_status,
+
+    run_at timestamptz not null,
+
+    -- zID of the admin who scheduled the transition
+    created_by text not null,
+    created_at timestamptz not null,
+
+    -- set once the scheduler has attempted the transition
+    ran_at timestamptz,
+    -- why the transition failed, null if it succeeded
+    error text,
+
+    primary key (election_id, state)
+);
+
+create index election_schedules_pending on election_schedules (election_id, run_at)
+where ran_at is null;
+
+-- +goose Down
+drop table election_schedules;
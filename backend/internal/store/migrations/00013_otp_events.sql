@@ -0,0 +1,12 @@
+-- +goose Up
+-- sign in attempts, for the admin dashboard
+create table otp_events (
+    zid text not null,
+    event text not null,
+    created_at timestamptz not null
+);
+
+create index otp_events_created_at on otp_events (created_at);
+
+-- +goose Down
+drop table otp_events;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- details from membership exports, not set for members added by zID alone
+alter table election_member_list
+    add column name text,
+    add column email text,
+    add column membership_expires_at timestamptz;
+
+-- +goose Down
+alter table election_member_list
+    drop column name,
+    drop column email,
+    drop column membership_expires_at;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- only set for members added one at a time, removals are kept in the audit log
+alter table election_member_list
+    add column added_by text,
+    add column added_at timestamptz;
+
+-- +goose Down
+alter table election_member_list
+    drop column added_by,
+    drop column added_at;
@@ -0,0 +1,19 @@
+-- +goose Up
+create type admin_role as enum (
+    'RETURNING_OFFICER',
+    'ELECTION_MANAGER',
+    'SCRUTINEER'
+);
+
+create table admins (
+    zid text primary key,
+    role admin_role not null,
+
+    -- zid of the admin who granted the role, or 'config' for ADMIN_ZIDS
+    granted_by text not null,
+    granted_at timestamptz not null
+);
+
+-- +goose Down
+drop table admins;
+drop type admin_role;
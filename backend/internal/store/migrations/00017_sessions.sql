@@ -0,0 +1,16 @@
+-- +goose Up
+-- one row per issued JWT, its session_id is the token's jti
+create table sessions (
+    session_id uuid primary key,
+    zid text not null,
+
+    created_at timestamptz not null,
+    expires_at timestamptz not null,
+    revoked_at timestamptz
+);
+
+create index sessions_zid_idx on sessions (zid);
+
+-- +goose Down
+drop index sessions_zid_idx;
+drop table sessions;
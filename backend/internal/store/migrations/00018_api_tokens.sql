@@ -0,0 +1,17 @@
+-- +goose Up
+-- long-lived tokens for scripts and services, only the sha-256 of each token is kept
+create table api_tokens (
+    token_id uuid primary key,
+    name text not null,
+    token_hash bytea not null unique,
+    scopes text[] not null,
+
+    created_by text not null,
+    created_at timestamptz not null,
+    expires_at timestamptz,
+    last_used_at timestamptz,
+    revoked_at timestamptz
+);
+
+-- +goose Down
+drop table api_tokens;
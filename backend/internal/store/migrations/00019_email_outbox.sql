@@ -0,0 +1,34 @@
+-- +goose Up
+create type email_status as enum ('PENDING', 'SENT', 'DEAD');
+
+-- emails are queued here before they're sent, so they're retried if the mailer fails
+create table email_outbox (
+    email_id uuid primary key,
+    -- the same email queued twice is only sent once
+    idempotency_key text not null unique,
+    kind text not null,
+    recipient text not null,
+    -- what the email is rendered from, cleared once it has been sent as it may hold OTP codes
+    payload jsonb,
+
+    status email_status not null default 'PENDING',
+    attempts int not null default 0,
+    -- pending emails are sent once this passes. while an email is being sent it is pushed
+    -- forward, so other processes don't send it too
+    next_attempt_at timestamptz not null,
+    last_error text,
+
+    created_at timestamptz not null,
+    -- emails that aren't sent by then are dead lettered, e.g when their OTP has expired
+    expires_at timestamptz,
+    sent_at timestamptz
+);
+
+create index email_outbox_due on email_outbox (next_attempt_at)
+where status = 'PENDING';
+
+create index email_outbox_status on email_outbox (status, created_at);
+
+-- +goose Down
+drop table email_outbox;
+drop type email_status;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- public key the election's receipts were signed with, set by its first ballot, so its
+-- bulletin can still be checked once the server signs with a different key
+alter table elections
+    add column receipt_public_key bytea;
+
+-- +goose Down
+alter table elections drop column receipt_public_key;
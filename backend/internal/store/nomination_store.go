@@ -12,7 +12,7 @@ type Nomination struct {
 	CandidateName      string    `db:"candidate_name" json:"candidate_name" example:"John Doe"`
 	ContactEmail       string    `db:"contact_email" json:"contact_email" example:"john@example.com"`
 	DiscordUsername    string    `db:"discord_username" json:"discord_username" example:"johndoe"`
-	ExecutiveRoles     []string  `db:"executive_roles" json:"executive_roles" minItems:"1" maxItems:"6" uniqueItems:"true" enum:"president,secretary,treasurer,arc_delegate,edi_officer,grievance_officer" example:"[\"president\", \"secretary\"]"`
+	ExecutiveRoles     []string  `db:"executive_roles" json:"executive_roles" minItems:"1" uniqueItems:"true" doc:"Keys of the positions being nominated for" example:"[\"president\", \"secretary\"]"`
 	CandidateStatement string    `db:"candidate_statement" json:"candidate_statement" example:"I am running for president because..."`
 	URL                *string   `db:"url" json:"url,omitempty" example:"https://johndoe.com"`
 	CreatedAt          time.Time `db:"created_at" json:"created_at" format:"date-time" example:"2024-01-15T10:30:00Z"`
@@ -32,7 +32,7 @@ type SubmitNomination struct {
 	CandidateName      string   `db:"candidate_name" json:"candidate_name" minLength:"2" maxLength:"100" example:"John Doe"`
 	ContactEmail       string   `db:"contact_email" json:"contact_email" format:"email" example:"john@example.com"`
 	DiscordUsername    string   `db:"discord_username" json:"discord_username" minLength:"2" maxLength:"32" example:"johndoe"`
-	ExecutiveRoles     []string `db:"executive_roles" json:"executive_roles" minItems:"1" maxItems:"6" uniqueItems:"true" enum:"president,secretary,treasurer,arc_delegate,edi_officer,grievance_officer" example:"[\"president\", \"secretary\"]"`
+	ExecutiveRoles     []string `db:"executive_roles" json:"executive_roles" minItems:"1" uniqueItems:"true" doc:"Keys of the positions being nominated for" example:"[\"president\", \"secretary\"]"`
 	CandidateStatement string   `db:"candidate_statement" json:"candidate_statement" required:"true" minLength:"50" maxLength:"2000" example:"I am running for president because..."`
 	URL                *string  `db:"url" json:"url,omitempty" format:"uri" required:"false" example:"https://johndoe.com"`
 }
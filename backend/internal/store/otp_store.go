@@ -39,6 +39,15 @@ type OTPStore interface {
 	// Validates a plaintext code with the entry in the database.
 	ValidateAndConsume(ctx context.Context, zid string, code string) (valid bool, reason OTPValidate, err error)
 
+	// Signs a single-use token for a magic link that can be used instead of code. The
+	// link shares code's entry, so has the same expiry and attempts, and stops working
+	// once either is used or a new code is created.
+	LinkToken(zid string, code string) string
+
+	// Validates a token from LinkToken like ValidateAndConsume, returning the zid it
+	// was signed for.
+	ValidateLinkAndConsume(ctx context.Context, token string) (zid string, valid bool, reason OTPValidate, err error)
+
 	// Consumes an OTP code owned by zid unconditionally. Clears ratelimits.
 	ConsumeIfExists(ctx context.Context, zid string) error
 }
@@ -0,0 +1,163 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type PgAdminStore struct {
+	// *pgx.Pool
+	pool PgxPoolIface
+
+	NowProvider func() time.Time
+}
+
+func NewPgAdminStore(pool PgxPoolIface) store.AdminStore {
+	return &PgAdminStore{
+		pool: pool,
+
+		NowProvider: time.Now,
+	}
+}
+
+func (st *PgAdminStore) GetAdmin(ctx context.Context, zid string) (*store.Admin, error) {
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select zid, role, granted_by, granted_at from admins
+		where zid = $1
+	`, zid)
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[store.Admin])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &admin, nil
+}
+
+func (st *PgAdminStore) ListAdmins(ctx context.Context) ([]store.Admin, error) {
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select zid, role, granted_by, granted_at from admins
+		order by zid
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[store.Admin])
+}
+
+// returns ErrAdminLastReturningOfficer if zid is the only returning officer. locks the
+// returning officers until tx ends, so two of them can't remove each other at once
+func assertNotLastReturningOfficer(ctx context.Context, tx pgx.Tx, zid string) error {
+	rows, err := tx.Query(ctx, `
+		select zid from admins
+		where role = 'RETURNING_OFFICER'
+		for update
+	`)
+	if err != nil {
+		return err
+	}
+
+	officers, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+
+	if len(officers) == 1 && officers[0] == zid {
+		return store.ErrAdminLastReturningOfficer
+	}
+	return nil
+}
+
+func (st *PgAdminStore) GrantRole(ctx context.Context, zid string, role store.AdminRole, grantedBy string) (*store.Admin, error) {
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if role != store.RoleReturningOfficer {
+		// ErrAdminLastReturningOfficer
+		if err := assertNotLastReturningOfficer(ctx, tx, zid); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.Query(ctx, `
+		insert into admins (zid, role, granted_by, granted_at)
+		values ($1, $2, $3, $4)
+		on conflict (zid) do update
+		set role = excluded.role,
+			granted_by = excluded.granted_by,
+			granted_at = excluded.granted_at
+		returning zid, role, granted_by, granted_at
+	`, zid, role, grantedBy, st.NowProvider())
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[store.Admin])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
+
+func (st *PgAdminStore) RevokeRole(ctx context.Context, zid string) error {
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// ErrAdminLastReturningOfficer
+	if err := assertNotLastReturningOfficer(ctx, tx, zid); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		delete from admins
+		where zid = $1
+	`, zid)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return store.ErrAdminNotFound
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (st *PgAdminStore) EnsureAdmins(ctx context.Context, zids []string, role store.AdminRole) error {
+	if len(zids) == 0 {
+		return nil
+	}
+
+	// only while there are no admins, so roles revoked since aren't granted again on the
+	// next startup. conflicts are processes seeding at the same time
+	_, err := connFor(ctx, st.pool).Exec(ctx, `
+		insert into admins (zid, role, granted_by, granted_at)
+		select zid, $2, $3, $4 from unnest($1::text[]) as zid
+		where not exists (select 1 from admins)
+		on conflict (zid) do nothing
+	`, zids, role, store.AdminGrantedByConfig, st.NowProvider())
+	return err
+}
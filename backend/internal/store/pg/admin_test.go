@@ -0,0 +1,34 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/linuxunsw/vote/backend/internal/store"
+	"github.com/linuxunsw/vote/backend/internal/store/pg/harness"
+)
+
+func TestEnsureAdminsOnlyBootstraps(t *testing.T) {
+	pool := harness.EphemeralPool(t)
+	adminStore := NewPgAdminStore(pool)
+	ctx := t.Context()
+
+	zids := []string{"z0000000", "z0000001"}
+	if err := adminStore.EnsureAdmins(ctx, zids, store.RoleReturningOfficer); err != nil {
+		t.Fatalf("EnsureAdmins failed: %v", err)
+	}
+	if err := adminStore.RevokeRole(ctx, "z0000001"); err != nil {
+		t.Fatalf("RevokeRole failed: %v", err)
+	}
+
+	// as on the next startup, the revoked admin isn't granted their role again
+	if err := adminStore.EnsureAdmins(ctx, zids, store.RoleReturningOfficer); err != nil {
+		t.Fatalf("EnsureAdmins failed: %v", err)
+	}
+	admins, err := adminStore.ListAdmins(ctx)
+	if err != nil {
+		t.Fatalf("ListAdmins failed: %v", err)
+	}
+	if len(admins) != 1 || admins[0].Zid != "z0000000" || admins[0].GrantedBy != store.AdminGrantedByConfig {
+		t.Fatalf("expected only z0000000 to be an admin, got %+v", admins)
+	}
+}
@@ -0,0 +1,143 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type PgAPITokenStore struct {
+	// *pgx.Pool
+	pool PgxPoolIface
+
+	NowProvider func() time.Time
+}
+
+func NewPgAPITokenStore(pool PgxPoolIface) store.APITokenStore {
+	return &PgAPITokenStore{
+		pool: pool,
+
+		NowProvider: time.Now,
+	}
+}
+
+// scopes are stored as text[]
+type apiTokenRow struct {
+	TokenID    string     `db:"token_id"`
+	Name       string     `db:"name"`
+	Scopes     []string   `db:"scopes"`
+	CreatedBy  string     `db:"created_by"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  *time.Time `db:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+func (r apiTokenRow) toAPIToken() store.APIToken {
+	scopes := make([]store.Permission, len(r.Scopes))
+	for i, scope := range r.Scopes {
+		scopes[i] = store.Permission(scope)
+	}
+
+	return store.APIToken{
+		TokenID:    r.TokenID,
+		Name:       r.Name,
+		Scopes:     scopes,
+		CreatedBy:  r.CreatedBy,
+		CreatedAt:  r.CreatedAt,
+		ExpiresAt:  r.ExpiresAt,
+		LastUsedAt: r.LastUsedAt,
+		RevokedAt:  r.RevokedAt,
+	}
+}
+
+const apiTokenColumns = `token_id::text, name, scopes, created_by, created_at, expires_at, last_used_at, revoked_at`
+
+func (st *PgAPITokenStore) CreateAPIToken(ctx context.Context, token store.NewAPIToken, hash []byte) (*store.APIToken, error) {
+	scopes := make([]string, len(token.Scopes))
+	for i, scope := range token.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		insert into api_tokens (token_id, name, token_hash, scopes, created_by, created_at, expires_at)
+		values ($1, $2, $3, $4, $5, $6, $7)
+		returning `+apiTokenColumns,
+		uuid.NewString(), token.Name, hash, scopes, token.CreatedBy, st.NowProvider(), token.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[apiTokenRow])
+	if err != nil {
+		return nil, err
+	}
+
+	created := row.toAPIToken()
+	return &created, nil
+}
+
+func (st *PgAPITokenStore) UseAPIToken(ctx context.Context, hash []byte) (*store.APIToken, error) {
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		update api_tokens set last_used_at = $2
+		where token_hash = $1 and revoked_at is null and (expires_at is null or expires_at > $2)
+		returning `+apiTokenColumns,
+		hash, st.NowProvider())
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[apiTokenRow])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	token := row.toAPIToken()
+	return &token, nil
+}
+
+func (st *PgAPITokenStore) ListAPITokens(ctx context.Context) ([]store.APIToken, error) {
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select `+apiTokenColumns+` from api_tokens
+		order by created_at desc, token_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenRows, err := pgx.CollectRows(rows, pgx.RowToStructByPos[apiTokenRow])
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]store.APIToken, len(tokenRows))
+	for i, row := range tokenRows {
+		tokens[i] = row.toAPIToken()
+	}
+	return tokens, nil
+}
+
+func (st *PgAPITokenStore) RevokeAPIToken(ctx context.Context, tokenId string) error {
+	if _, err := uuid.Parse(tokenId); err != nil {
+		return store.ErrAPITokenNotFound
+	}
+
+	tag, err := connFor(ctx, st.pool).Exec(ctx, `
+		update api_tokens set revoked_at = $2
+		where token_id = $1 and revoked_at is null
+	`, tokenId, st.NowProvider())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return store.ErrAPITokenNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type PgAuditStore struct {
+	// *pgx.Pool
+	pool PgxPoolIface
+
+	NowProvider func() time.Time
+}
+
+func NewPgAuditStore(pool PgxPoolIface) store.AuditStore {
+	return &PgAuditStore{
+		pool: pool,
+
+		NowProvider: time.Now,
+	}
+}
+
+// serialises appends, so each entry is chained to the one before it
+const auditLockKey = 0x61756469
+
+func (st *PgAuditStore) Append(ctx context.Context, newEntry store.NewAuditEntry) error {
+	details := newEntry.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+	encodedDetails, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// held until the outermost transaction ends, so entries are chained in commit order
+	if _, err := tx.Exec(ctx, `select pg_advisory_xact_lock($1)`, auditLockKey); err != nil {
+		return err
+	}
+
+	entry := store.AuditEntry{
+		Seq:     1,
+		Actor:   newEntry.Actor,
+		Action:  newEntry.Action,
+		Details: encodedDetails,
+		// postgres only keeps microseconds, the hash must match what is stored
+		CreatedAt: st.NowProvider().UTC().Truncate(time.Microsecond),
+	}
+	if newEntry.ElectionID != "" {
+		// hashed as List reads it back from postgres, in lowercase
+		electionId, err := uuid.Parse(newEntry.ElectionID)
+		if err != nil {
+			return fmt.Errorf("audit entry election id %q: %w", newEntry.ElectionID, err)
+		}
+		canonical := electionId.String()
+		entry.ElectionID = &canonical
+	}
+
+	var lastSeq int64
+	var lastHash string
+	err = tx.QueryRow(ctx, `
+		select seq, hash from audit_log
+		order by seq desc
+		limit 1
+	`).Scan(&lastSeq, &lastHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	entry.Seq = lastSeq + 1
+	entry.PrevHash = lastHash
+	entry.Hash = entry.ComputeHash()
+
+	_, err = tx.Exec(ctx, `
+		insert into audit_log (seq, election_id, actor, action, details, created_at, prev_hash, hash)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, entry.Seq, entry.ElectionID, entry.Actor, entry.Action, string(entry.Details), entry.CreatedAt, entry.PrevHash, entry.Hash)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (st *PgAuditStore) Record(ctx context.Context, action func(ctx context.Context) (store.NewAuditEntry, error)) error {
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txCtx := withTx(ctx, tx)
+	entry, err := action(txCtx)
+	if err != nil {
+		return err
+	}
+	if entry.Action != "" {
+		if err := st.Append(txCtx, entry); err != nil {
+			return fmt.Errorf("audit log: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (st *PgAuditStore) List(ctx context.Context, afterSeq int64, limit int) ([]store.AuditEntry, error) {
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select seq, election_id::text, actor, action, details, created_at, prev_hash, hash
+		from audit_log
+		where seq > $1
+		order by seq asc
+		limit $2
+	`, afterSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[store.AuditEntry])
+}
@@ -0,0 +1,68 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/linuxunsw/vote/backend/internal/store"
+	"github.com/linuxunsw/vote/backend/internal/store/pg/harness"
+)
+
+func TestAuditRecordRollsBack(t *testing.T) {
+	pool := harness.EphemeralPool(t)
+	adminStore := NewPgAdminStore(pool)
+	auditStore := NewPgAuditStore(pool)
+	ctx := t.Context()
+
+	// the entry can't be appended, so the grant is undone
+	err := auditStore.Record(ctx, func(ctx context.Context) (store.NewAuditEntry, error) {
+		_, err := adminStore.GrantRole(ctx, "z0000000", store.RoleReturningOfficer, "z0000001")
+		return store.NewAuditEntry{
+			ElectionID: "not-a-uuid",
+			Action:     store.AuditAdminGranted,
+		}, err
+	})
+	if err == nil {
+		t.Fatal("expected Record to fail")
+	}
+
+	admin, err := adminStore.GetAdmin(ctx, "z0000000")
+	if err != nil {
+		t.Fatalf("GetAdmin failed: %v", err)
+	}
+	if admin != nil {
+		t.Fatalf("expected the grant to be rolled back, got %+v", admin)
+	}
+	entries, err := auditStore.List(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no audit entries, got %+v", entries)
+	}
+
+	err = auditStore.Record(ctx, func(ctx context.Context) (store.NewAuditEntry, error) {
+		_, err := adminStore.GrantRole(ctx, "z0000000", store.RoleReturningOfficer, "z0000001")
+		return store.NewAuditEntry{
+			Actor:  "z0000001",
+			Action: store.AuditAdminGranted,
+		}, err
+	})
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	admin, err = adminStore.GetAdmin(ctx, "z0000000")
+	if err != nil {
+		t.Fatalf("GetAdmin failed: %v", err)
+	}
+	if admin == nil {
+		t.Fatal("expected z0000000 to be an admin")
+	}
+	entries, err = auditStore.List(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %+v", entries)
+	}
+}
@@ -2,7 +2,11 @@ package pg
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -24,53 +28,253 @@ func NewPgBallotStore(pool PgxPoolIface) store.BallotStore {
 	}
 }
 
+// the token a voter's secret ballot is stored under
+func ballotToken(secret []byte, zid string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(zid))
+	return mac.Sum(nil)
+}
+
+// creates the ballot secret when voting opens and destroys it when voting closes.
+// ballots stored under a destroyed secret can't be found again, so they are no
+// longer replaceable
+func transitionBallotSecret(ctx context.Context, tx pgx.Tx, electionId string, newState store.ElectionState) error {
+	switch newState {
+	case store.StateVotingOpen:
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			insert into ballot_secrets (election_id, secret)
+			values ($1, $2)
+			on conflict (election_id) do nothing
+		`, electionId, secret)
+		return err
+	case store.StateVotingClosed:
+		_, err := tx.Exec(ctx, `
+			delete from ballot_secrets
+			where election_id = $1
+		`, electionId)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			update ballot_voters
+			set replaceable = false
+			where election_id = $1
+		`, electionId)
+		return err
+	}
+	return nil
+}
+
+// returns whether the election uses secret ballots, and the ballot secret if it still exists
+func ballotSecret(ctx context.Context, tx pgx.Tx, electionID string) (bool, []byte, error) {
+	var secretBallot bool
+	var secret []byte
+	err := tx.QueryRow(ctx, `
+		select elections.secret_ballot, ballot_secrets.secret
+		from elections
+		left join ballot_secrets on ballot_secrets.election_id = elections.election_id
+		where elections.election_id = $1
+	`, electionID).Scan(&secretBallot, &secret)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil, store.ErrElectionNotFound
+	} else if err != nil {
+		return false, nil, err
+	}
+	return secretBallot, secret, nil
+}
+
 func (b *PgBallotStore) SubmitOrReplaceBallot(ctx context.Context, electionID string, zid string, submission store.SubmitBallot) error {
 	submissionObject, err := json.Marshal(submission.Positions)
 	if err != nil {
 		return err
 	}
-	
-	// Upsert the ballot
-	_, err = b.pool.Exec(ctx, `
-		insert into ballots (election_id, zid, positions, created_at, updated_at)
-		values ($1, $2, $3, $4, $4)
+
+	tx, err := connFor(ctx, b.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	secretBallot, secret, err := ballotSecret(ctx, tx, electionID)
+	if err != nil {
+		return err
+	}
+
+	// only the first ballot writes it, so elections aren't locked by every vote
+	_, err = tx.Exec(ctx, `
+		update elections
+		set receipt_public_key = $2
+		where election_id = $1 and receipt_public_key is null
+	`, electionID, submission.ReceiptKey)
+	if err != nil {
+		return err
+	}
+
+	if !secretBallot {
+		// Upsert the ballot
+		_, err = tx.Exec(ctx, `
+			insert into ballots (election_id, zid, positions, receipt_hash, created_at, updated_at)
+			values ($1, $2, $3, $4, $5, $5)
+			on conflict (election_id, zid) do update
+				set positions = $3, receipt_hash = $4, updated_at = $5
+		`, electionID, zid, string(submissionObject), submission.ReceiptHash, b.NowProvider())
+		if err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}
+
+	if secret == nil {
+		return store.ErrBallotSecretDestroyed
+	}
+
+	// record the voter, the ballot is only replaced if their previous one can be found
+	var replaceable bool
+	err = tx.QueryRow(ctx, `
+		insert into ballot_voters (election_id, zid, created_at, updated_at)
+		values ($1, $2, $3, $3)
 		on conflict (election_id, zid) do update
-			set positions = $3, updated_at = $4
-	`, electionID, zid, string(submissionObject), b.NowProvider())
+			set updated_at = $3
+		returning replaceable
+	`, electionID, zid, b.NowProvider()).Scan(&replaceable)
 	if err != nil {
 		return err
 	}
+	if !replaceable {
+		return store.ErrBallotNotReplaceable
+	}
 
-	return nil
+	_, err = tx.Exec(ctx, `
+		insert into secret_ballots (election_id, token, positions, receipt_hash)
+		values ($1, $2, $3, $4)
+		on conflict (election_id, token) do update
+			set positions = $3, receipt_hash = $4
+	`, electionID, ballotToken(secret, zid), string(submissionObject), submission.ReceiptHash)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (b *PgBallotStore) GetBallot(ctx context.Context, electionID string, zid string) (*store.Ballot, error) {
-	rows, err := b.pool.Query(ctx, `
-		select positions, created_at, updated_at
-		from ballots
-		where election_id = $1 and zid = $2
-	`, electionID, zid)
+	tx, err := connFor(ctx, b.pool).Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
-	ballot, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[store.Ballot])
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
+	secretBallot, secret, err := ballotSecret(ctx, tx, electionID)
+	if errors.Is(err, store.ErrElectionNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !secretBallot {
+		rows, err := tx.Query(ctx, `
+			select positions, receipt_hash, created_at, updated_at
+			from ballots
+			where election_id = $1 and zid = $2
+		`, electionID, zid)
+		if err != nil {
+			return nil, err
 		}
+
+		ballot, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[store.Ballot])
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return &ballot, nil
+	}
+
+	ballot := store.Ballot{}
+	err = tx.QueryRow(ctx, `
+		select created_at, updated_at
+		from ballot_voters
+		where election_id = $1 and zid = $2
+	`, electionID, zid).Scan(&ballot.CreatedAt, &ballot.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		// voting has closed, the contents can no longer be found
+		return &ballot, nil
+	}
+
+	err = tx.QueryRow(ctx, `
+		select positions, receipt_hash from secret_ballots
+		where election_id = $1 and token = $2
+	`, electionID, ballotToken(secret, zid)).Scan(&ballot.Positions, &ballot.ReceiptHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		// no rows if it was cast under a secret that has since been destroyed
 		return nil, err
 	}
 	return &ballot, nil
 }
 
 func (b *PgBallotStore) TryDeleteBallot(ctx context.Context, electionID string, zid string) error {
-	_, err := b.pool.Exec(ctx, `
-		delete from ballots
+	tx, err := connFor(ctx, b.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	secretBallot, secret, err := ballotSecret(ctx, tx, electionID)
+	if errors.Is(err, store.ErrElectionNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !secretBallot {
+		_, err = tx.Exec(ctx, `
+			delete from ballots
+			where election_id = $1 and zid = $2
+		`, electionID, zid)
+		if err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}
+
+	var replaceable bool
+	err = tx.QueryRow(ctx, `
+		delete from ballot_voters
 		where election_id = $1 and zid = $2
-	`, electionID, zid)
+		returning replaceable
+	`, electionID, zid).Scan(&replaceable)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !replaceable || secret == nil {
+		return store.ErrBallotNotReplaceable
+	}
+
+	_, err = tx.Exec(ctx, `
+		delete from secret_ballots
+		where election_id = $1 and token = $2
+	`, electionID, ballotToken(secret, zid))
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return tx.Commit(ctx)
 }
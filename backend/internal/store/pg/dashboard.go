@@ -0,0 +1,146 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+	"github.com/linuxunsw/vote/backend/internal/tally"
+)
+
+type PgDashboardStore struct {
+	// *pgx.Pool
+	pool PgxPoolIface
+
+	otpRetention time.Duration
+
+	NowProvider func() time.Time
+}
+
+func NewPgDashboardStore(pool PgxPoolIface, cfg config.DashboardConfig) store.DashboardStore {
+	return &PgDashboardStore{
+		pool: pool,
+
+		otpRetention: cfg.OTPRetention,
+
+		NowProvider: time.Now,
+	}
+}
+
+type otpEventCount struct {
+	Event store.OTPEvent `db:"event"`
+	Count int            `db:"count"`
+}
+
+type nominationCount struct {
+	Position string `db:"position"`
+	Count    int    `db:"count"`
+}
+
+func (st *PgDashboardStore) RecordOTPEvent(ctx context.Context, zid string, event store.OTPEvent) error {
+	now := st.NowProvider()
+	// events are only counted over a recent window, so old ones are pruned as new ones
+	// come in rather than kept forever
+	_, err := connFor(ctx, st.pool).Exec(ctx, `
+		with pruned as (
+			delete from otp_events
+			where created_at < $4
+		)
+		insert into otp_events (zid, event, created_at)
+		values ($1, $2, $3)
+	`, zid, event, now, now.Add(-st.otpRetention))
+	return err
+}
+
+func (st *PgDashboardStore) GetStats(ctx context.Context, electionId string, otpSince time.Time, now time.Time) (*store.DashboardStats, error) {
+	// ErrElectionNotFound
+	if err := assertElectionExists(ctx, connFor(ctx, st.pool), electionId); err != nil {
+		return nil, err
+	}
+
+	var ballotsCast, eligibleVoters int
+	err := connFor(ctx, st.pool).QueryRow(ctx, `
+		select
+			(select count(*) from ballots where election_id = $1)
+				+ (select count(*) from secret_ballots where election_id = $1),
+			(select count(*) from election_member_list where election_id = $1)
+	`, electionId).Scan(&ballotsCast, &eligibleVoters)
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := getPositions(ctx, connFor(ctx, st.pool), electionId)
+	if err != nil {
+		return nil, err
+	}
+	nominations := make(map[string]int, len(positions))
+	for _, position := range positions {
+		nominations[position.Key] = 0
+	}
+
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select role as position, count(*) as count
+		from nominations, unnest(executive_roles) as role
+		where election_id = $1
+		group by role
+	`, electionId)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := pgx.CollectRows(rows, pgx.RowToStructByName[nominationCount])
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		nominations[count.Position] = count.Count
+	}
+
+	rows, err = connFor(ctx, st.pool).Query(ctx, `
+		select event, count(*) as count
+		from otp_events
+		where created_at >= $1
+		group by event
+	`, otpSince)
+	if err != nil {
+		return nil, err
+	}
+	events, err := pgx.CollectRows(rows, pgx.RowToStructByName[otpEventCount])
+	if err != nil {
+		return nil, err
+	}
+	var otp store.OTPStats
+	for _, event := range events {
+		switch event.Event {
+		case store.OTPEventSent:
+			otp.Sent = event.Count
+		case store.OTPEventSendFailed:
+			otp.SendFailed = event.Count
+		case store.OTPEventRateLimited:
+			otp.RateLimited = event.Count
+		case store.OTPEventNoAddress:
+			otp.NoAddress = event.Count
+		case store.OTPEventVerified:
+			otp.Verified = event.Count
+		case store.OTPEventRejected:
+			otp.Rejected = event.Count
+		}
+	}
+
+	var activeSessions int
+	err = connFor(ctx, st.pool).QueryRow(ctx, `
+		select count(distinct zid) from sessions
+		where revoked_at is null and expires_at > $1
+	`, now).Scan(&activeSessions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &store.DashboardStats{
+		Turnout:        tally.NewTurnout(ballotsCast, eligibleVoters),
+		Nominations:    nominations,
+		OTP:            otp,
+		ActiveSessions: activeSessions,
+	}, nil
+}
@@ -0,0 +1,45 @@
+package pg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/linuxunsw/vote/backend/internal/config"
+	"github.com/linuxunsw/vote/backend/internal/store"
+	"github.com/linuxunsw/vote/backend/internal/store/pg/harness"
+)
+
+func TestRecordOTPEventPrunes(t *testing.T) {
+	pool := harness.EphemeralPool(t)
+	dashboardStore := NewPgDashboardStore(pool, config.DashboardConfig{OTPRetention: time.Hour}).(*PgDashboardStore)
+	ctx := t.Context()
+
+	now := time.Now()
+	dashboardStore.NowProvider = func() time.Time { return now }
+	if err := dashboardStore.RecordOTPEvent(ctx, "z0000000", store.OTPEventSent); err != nil {
+		t.Fatalf("RecordOTPEvent failed: %v", err)
+	}
+
+	// the first event is now older than the retention, so is deleted
+	now = now.Add(2 * time.Hour)
+	if err := dashboardStore.RecordOTPEvent(ctx, "z0000001", store.OTPEventRateLimited); err != nil {
+		t.Fatalf("RecordOTPEvent failed: %v", err)
+	}
+
+	var zids []string
+	rows, err := pool.Query(ctx, `select zid from otp_events`)
+	if err != nil {
+		t.Fatalf("failed to query OTP events: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var zid string
+		if err := rows.Scan(&zid); err != nil {
+			t.Fatalf("failed to scan OTP event: %v", err)
+		}
+		zids = append(zids, zid)
+	}
+	if len(zids) != 1 || zids[0] != "z0000001" {
+		t.Fatalf("expected only z0000001's event to be kept, got %v", zids)
+	}
+}
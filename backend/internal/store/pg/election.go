@@ -4,17 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"regexp"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/linuxunsw/vote/backend/internal/identity"
 	"github.com/linuxunsw/vote/backend/internal/store"
 )
 
 type PgElectionStore struct {
 	// *pgx.Pool
 	pool PgxPoolIface
+	// validates zIDs in member lists
+	ids identity.Provider
 
 	NowProvider func() time.Time
 	v7Provider  func() (uuid.UUID, error)
@@ -24,23 +26,20 @@ type query interface {
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 }
 
-func NewPgElectionStore(pool PgxPoolIface) store.ElectionStore {
+func NewPgElectionStore(pool PgxPoolIface, ids identity.Provider) store.ElectionStore {
 	return &PgElectionStore{
 		pool: pool,
+		ids:  ids,
 
 		NowProvider: time.Now,
 		v7Provider:  uuid.NewV7,
 	}
 }
 
-var (
-	zIDRegex = regexp.MustCompile(`^z[0-9]{7}$`)
-)
-
-func deduplicateAndVerifyEntries(entries []string, electionId string) (deduplicated []store.ElectionMemberEntry, valid bool) {
+func deduplicateAndVerifyEntries(ids identity.Provider, entries []string, electionId string) (deduplicated []store.ElectionMemberEntry, valid bool) {
 	dedupEntries := make(map[string]store.ElectionMemberEntry)
 	for _, zid := range entries {
-		if !zIDRegex.MatchString(zid) {
+		if !ids.Valid(zid) {
 			return nil, false
 		}
 
@@ -60,7 +59,7 @@ func deduplicateAndVerifyEntries(entries []string, electionId string) (deduplica
 	return dedupEntriesList, true
 }
 
-func (st *PgElectionStore) assertElectionExists(ctx context.Context, tx query, electionId string) error {
+func assertElectionExists(ctx context.Context, tx query, electionId string) error {
 	rows, err := tx.Query(ctx, `
 		select 1 as exists from elections
 		where election_id = $1
@@ -80,7 +79,7 @@ func (st *PgElectionStore) assertElectionExists(ctx context.Context, tx query, e
 }
 
 func (st *PgElectionStore) SetMembers(ctx context.Context, electionId string, entries []string) error {
-	tx, err := st.pool.Begin(ctx)
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -89,11 +88,11 @@ func (st *PgElectionStore) SetMembers(ctx context.Context, electionId string, en
 	}()
 
 	// ErrElectionNotFound
-	if err := st.assertElectionExists(ctx, tx, electionId); err != nil {
+	if err := assertElectionExists(ctx, tx, electionId); err != nil {
 		return err
 	}
 
-	deduplicatedEntries, valid := deduplicateAndVerifyEntries(entries, electionId)
+	deduplicatedEntries, valid := deduplicateAndVerifyEntries(st.ids, entries, electionId)
 	if !valid {
 		return store.ErrElectionSetMembersFailedValidation
 	}
@@ -157,14 +156,101 @@ func (st *PgElectionStore) SetMembers(ctx context.Context, electionId string, en
 	return nil
 }
 
+func (st *PgElectionStore) ImportMembers(ctx context.Context, electionId string, members []store.ElectionMember, dryRun bool) (*store.MemberImportSummary, error) {
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// ErrElectionNotFound
+	if err := assertElectionExists(ctx, tx, electionId); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		create temp table temp_election_member_import (
+			zid text primary key,
+			name text,
+			email text,
+			membership_expires_at timestamptz
+		) on commit drop;
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"temp_election_member_import"},
+		[]string{"zid", "name", "email", "membership_expires_at"},
+		pgx.CopyFromSlice(len(members), func(i int) ([]interface{}, error) {
+			member := members[i]
+			return []interface{}{
+				member.Zid,
+				member.Name,
+				member.Email,
+				member.MembershipExpiresAt,
+			}, nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &store.MemberImportSummary{}
+	err = tx.QueryRow(ctx, `
+		select count(*) from temp_election_member_import
+		where zid not in (select zid from election_member_list where election_id = $1)
+	`, electionId).Scan(&summary.Added)
+	if err != nil {
+		return nil, err
+	}
+	summary.Kept = len(members) - summary.Added
+
+	tag, err := tx.Exec(ctx, `
+		delete from election_member_list
+		where election_id = $1
+		and zid not in (select zid from temp_election_member_import)
+	`, electionId)
+	if err != nil {
+		return nil, err
+	}
+	summary.Removed = int(tag.RowsAffected())
+
+	if dryRun {
+		// rolled back
+		return summary, nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		insert into election_member_list (election_id, zid, name, email, membership_expires_at)
+		select $1, zid, name, email, membership_expires_at from temp_election_member_import
+		on conflict (election_id, zid) do update
+		set name = excluded.name,
+			email = excluded.email,
+			membership_expires_at = excluded.membership_expires_at
+	`, electionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
 func (st *PgElectionStore) GetMember(ctx context.Context, electionId string, zid string) (*store.ElectionMemberEntry, error) {
 	// ErrElectionNotFound
-	if err := st.assertElectionExists(ctx, st.pool, electionId); err != nil {
+	if err := assertElectionExists(ctx, connFor(ctx, st.pool), electionId); err != nil {
 		return nil, err
 	}
 
-	rows, err := st.pool.Query(ctx, `
-		select * from election_member_list
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select election_id, zid, email from election_member_list
 		where election_id = $1 and zid = $2
 	`, electionId, zid)
 	if err != nil {
@@ -181,10 +267,128 @@ func (st *PgElectionStore) GetMember(ctx context.Context, electionId string, zid
 	return &entry, nil
 }
 
-func (st *PgElectionStore) CreateElection(ctx context.Context, name string) (string, error) {
+func (st *PgElectionStore) AddMember(ctx context.Context, electionId string, member store.ElectionMember, addedBy string) (*store.ListedMember, error) {
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// ErrElectionNotFound
+	if err := assertElectionExists(ctx, tx, electionId); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		insert into election_member_list (election_id, zid, name, email, membership_expires_at, added_by, added_at)
+		values ($1, $2, $3, $4, $5, $6, $7)
+		on conflict (election_id, zid) do nothing
+		returning zid, name, email, membership_expires_at, added_by, added_at
+	`, electionId, member.Zid, member.Name, member.Email, member.MembershipExpiresAt, addedBy, st.NowProvider())
+	if err != nil {
+		return nil, err
+	}
+
+	listed, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[store.ListedMember])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, store.ErrMemberAlreadyExists
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &listed, nil
+}
+
+func (st *PgElectionStore) RemoveMember(ctx context.Context, electionId string, zid string) error {
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// ErrElectionNotFound
+	if err := assertElectionExists(ctx, tx, electionId); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		delete from election_member_list
+		where election_id = $1 and zid = $2
+	`, electionId, zid)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return store.ErrMemberNotFound
+	}
+
+	return tx.Commit(ctx)
+}
+
+// matches members whose zid, name or email contains $2, or every member if it's empty.
+// strpos avoids having to escape like patterns
+const memberSearchCondition = `
+	($2 = ''
+		or strpos(zid, lower($2)) > 0
+		or strpos(lower(coalesce(name, '')), lower($2)) > 0
+		or strpos(lower(coalesce(email, '')), lower($2)) > 0)
+`
+
+func (st *PgElectionStore) ListMembers(ctx context.Context, electionId string, search string, after string, limit int) ([]store.ListedMember, error) {
+	// ErrElectionNotFound
+	if err := assertElectionExists(ctx, connFor(ctx, st.pool), electionId); err != nil {
+		return nil, err
+	}
+
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select zid, name, email, membership_expires_at, added_by, added_at
+		from election_member_list
+		where election_id = $1 and zid > $3 and`+memberSearchCondition+`
+		order by zid
+		limit $4
+	`, electionId, search, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[store.ListedMember])
+}
+
+func (st *PgElectionStore) CountMembers(ctx context.Context, electionId string, search string) (int, error) {
+	// ErrElectionNotFound
+	if err := assertElectionExists(ctx, connFor(ctx, st.pool), electionId); err != nil {
+		return 0, err
+	}
+
+	var count int
+	err := connFor(ctx, st.pool).QueryRow(ctx, `
+		select count(*) from election_member_list
+		where election_id = $1 and`+memberSearchCondition, electionId, search).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (st *PgElectionStore) CreateElection(ctx context.Context, name string, positions []store.Position, secretBallot bool) (string, error) {
 	now := st.NowProvider()
 
-	current, err := st.CurrentElection(ctx)
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	current, err := st.currentElection(ctx, tx)
 	if err != nil {
 		return "", err
 	}
@@ -197,13 +401,25 @@ func (st *PgElectionStore) CreateElection(ctx context.Context, name string) (str
 		return "", err
 	}
 
-	_, err = st.pool.Exec(ctx, `
-		insert into elections (election_id, name, state, created_at)
-		values ($1, $2, 'CLOSED', $3)
-	`, electionId, name, now)
+	_, err = tx.Exec(ctx, `
+		insert into elections (election_id, name, state, created_at, secret_ballot)
+		values ($1, $2, 'CLOSED', $3, $4)
+	`, electionId, name, now, secretBallot)
 	if err != nil {
 		return "", err
 	}
+
+	if err := insertPositions(ctx, tx, electionId.String(), positions); err != nil {
+		return "", err
+	}
+
+	if err := notifyStateChange(ctx, tx, electionId.String(), store.StateClosed); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
 	return electionId.String(), nil
 }
 
@@ -234,11 +450,16 @@ func (st *PgElectionStore) currentElection(ctx context.Context, tx query) (*stor
 }
 
 func (st *PgElectionStore) CurrentElection(ctx context.Context) (*store.Election, error) {
-	return st.currentElection(ctx, st.pool)
+	return st.currentElection(ctx, connFor(ctx, st.pool))
 }
 
-/* func (st *pgElectionStore) GetElection(ctx context.Context, electionId string) (*store.Election, error) {
-	rows, err := st.pool.Query(ctx, `
+func (st *PgElectionStore) GetElection(ctx context.Context, electionId string) (*store.Election, error) {
+	// not a uuid, so can't exist
+	if _, err := uuid.Parse(electionId); err != nil {
+		return nil, nil
+	}
+
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
 		select * from elections
 		where election_id = $1
 	`, electionId)
@@ -254,7 +475,43 @@ func (st *PgElectionStore) CurrentElection(ctx context.Context) (*store.Election
 	}
 
 	return &election, nil
-} */
+}
+
+func (st *PgElectionStore) ListElections(ctx context.Context, before string, limit int) ([]store.Election, error) {
+	var beforeId *uuid.UUID
+	if before != "" {
+		id, err := uuid.Parse(before)
+		if err != nil {
+			return nil, store.ErrElectionNotFound
+		}
+		beforeId = &id
+	}
+
+	// ties on created_at are broken by id so every election appears on exactly one page
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select * from elections
+		where $1::uuid is null
+		or (created_at, election_id) < (select created_at, election_id from elections where election_id = $1)
+		order by created_at desc, election_id desc
+		limit $2
+	`, beforeId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	elections, err := pgx.CollectRows(rows, pgx.RowToStructByName[store.Election])
+	if err != nil {
+		return nil, err
+	}
+	if len(elections) == 0 && beforeId != nil {
+		// distinguish an unknown cursor from the last page
+		if err := assertElectionExists(ctx, connFor(ctx, st.pool), before); err != nil {
+			return nil, err
+		}
+	}
+
+	return elections, nil
+}
 
 // timestamp fields will only be set once
 var timestampTransitionAway = map[store.ElectionState]pgx.Identifier{
@@ -269,7 +526,7 @@ var timestampTransitionAway = map[store.ElectionState]pgx.Identifier{
 }
 
 func (st *PgElectionStore) CurrentElectionSetState(ctx context.Context, newStateString string) error {
-	tx, err := st.pool.Begin(ctx)
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -308,6 +565,12 @@ func (st *PgElectionStore) CurrentElectionSetState(ctx context.Context, newState
 		return err
 	}
 
+	if current.SecretBallot {
+		if err := transitionBallotSecret(ctx, tx, current.ElectionID, newState); err != nil {
+			return err
+		}
+	}
+
 	if timestampIdent != nil {
 		// pgx.Identifier does perform sanitisation
 		sql := fmt.Sprintf(`
@@ -321,6 +584,10 @@ func (st *PgElectionStore) CurrentElectionSetState(ctx context.Context, newState
 		}
 	}
 
+	if err := notifyStateChange(ctx, tx, current.ElectionID, newState); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return err
 	}
@@ -0,0 +1,209 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type PgEmailOutboxStore struct {
+	// *pgx.Pool
+	pool PgxPoolIface
+
+	NowProvider func() time.Time
+}
+
+func NewPgEmailOutboxStore(pool PgxPoolIface) store.EmailOutboxStore {
+	return &PgEmailOutboxStore{
+		pool: pool,
+
+		NowProvider: time.Now,
+	}
+}
+
+const emailOutboxColumns = `email_id::text, idempotency_key, kind, recipient, payload, status::text, attempts, next_attempt_at, last_error, created_at, expires_at, sent_at`
+
+func (st *PgEmailOutboxStore) Enqueue(ctx context.Context, email store.NewOutboxEmail, leaseUntil time.Time) (*store.OutboxEmail, bool, error) {
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		insert into email_outbox (email_id, idempotency_key, kind, recipient, payload, next_attempt_at, created_at, expires_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)
+		on conflict (idempotency_key) do nothing
+		returning `+emailOutboxColumns,
+		uuid.NewString(), email.IdempotencyKey, email.Kind, email.Recipient, email.Payload, leaseUntil, st.NowProvider(), email.ExpiresAt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	queued, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[store.OutboxEmail])
+	if err == nil {
+		return &queued, true, nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, err
+	}
+
+	// already queued
+	rows, err = connFor(ctx, st.pool).Query(ctx, `
+		select `+emailOutboxColumns+` from email_outbox
+		where idempotency_key = $1
+	`, email.IdempotencyKey)
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[store.OutboxEmail])
+	if err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+func (st *PgEmailOutboxStore) EnqueueBatch(ctx context.Context, emails []store.NewOutboxEmail, notBefore time.Time) (int, error) {
+	if len(emails) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, len(emails))
+	keys := make([]string, len(emails))
+	kinds := make([]string, len(emails))
+	recipients := make([]string, len(emails))
+	payloads := make([]string, len(emails))
+	expiries := make([]*time.Time, len(emails))
+	for i, email := range emails {
+		ids[i] = uuid.NewString()
+		keys[i] = email.IdempotencyKey
+		kinds[i] = string(email.Kind)
+		recipients[i] = email.Recipient
+		payloads[i] = string(email.Payload)
+		expiries[i] = email.ExpiresAt
+	}
+
+	// one statement however many emails there are, e.g when every member is notified
+	tag, err := connFor(ctx, st.pool).Exec(ctx, `
+		insert into email_outbox (email_id, idempotency_key, kind, recipient, payload, next_attempt_at, created_at, expires_at)
+		select id::uuid, key, kind, recipient, payload::jsonb, $7, $8, expires_at
+		from unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::timestamptz[])
+			as e (id, key, kind, recipient, payload, expires_at)
+		on conflict (idempotency_key) do nothing
+	`, ids, keys, kinds, recipients, payloads, expiries, notBefore, st.NowProvider())
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+func (st *PgEmailOutboxStore) ClaimDue(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]store.OutboxEmail, error) {
+	// skip locked lets processes claiming at the same time each take different emails
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		update email_outbox set next_attempt_at = $2
+		where email_id in (
+			select email_id from email_outbox
+			where status = 'PENDING' and next_attempt_at <= $1
+			order by next_attempt_at
+			limit $3
+			for update skip locked
+		)
+		returning `+emailOutboxColumns,
+		now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[store.OutboxEmail])
+}
+
+func (st *PgEmailOutboxStore) MarkSent(ctx context.Context, emailId string, sentAt time.Time) error {
+	if _, err := uuid.Parse(emailId); err != nil {
+		return store.ErrEmailNotFound
+	}
+
+	tag, err := connFor(ctx, st.pool).Exec(ctx, `
+		update email_outbox
+		set status = 'SENT', sent_at = $2, attempts = attempts + 1, payload = null, last_error = null
+		where email_id = $1
+	`, emailId, sentAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return store.ErrEmailNotFound
+	}
+
+	return nil
+}
+
+func (st *PgEmailOutboxStore) MarkFailed(ctx context.Context, emailId string, sendErr error, retryAt *time.Time) error {
+	if _, err := uuid.Parse(emailId); err != nil {
+		return store.ErrEmailNotFound
+	}
+
+	tag, err := connFor(ctx, st.pool).Exec(ctx, `
+		update email_outbox
+		set attempts = attempts + 1,
+			last_error = $2,
+			status = case when $3::timestamptz is null then 'DEAD' else 'PENDING' end::email_status,
+			next_attempt_at = coalesce($3, next_attempt_at),
+			-- a dead OTP won't be sent, so its code isn't kept
+			payload = case when $3::timestamptz is null and kind = $4 then null else payload end
+		where email_id = $1
+	`, emailId, sendErr.Error(), retryAt, string(store.EmailKindOTP))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return store.ErrEmailNotFound
+	}
+
+	return nil
+}
+
+func (st *PgEmailOutboxStore) ListEmails(ctx context.Context, status store.EmailStatus, limit int) ([]store.OutboxEmail, error) {
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select `+emailOutboxColumns+` from email_outbox
+		where status = $1
+		order by created_at desc, email_id
+		limit $2
+	`, string(status), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[store.OutboxEmail])
+}
+
+func (st *PgEmailOutboxStore) RetryEmail(ctx context.Context, emailId string, now time.Time) (*store.OutboxEmail, error) {
+	if _, err := uuid.Parse(emailId); err != nil {
+		return nil, store.ErrEmailNotFound
+	}
+
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		update email_outbox
+		set status = 'PENDING', attempts = 0, next_attempt_at = $2
+		where email_id = $1 and status = 'DEAD' and payload is not null and (expires_at is null or expires_at > $2)
+		returning `+emailOutboxColumns,
+		emailId, now)
+	if err != nil {
+		return nil, err
+	}
+
+	email, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[store.OutboxEmail])
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+		err := connFor(ctx, st.pool).QueryRow(ctx, `select exists (select 1 from email_outbox where email_id = $1)`, emailId).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, store.ErrEmailNotRetryable
+		}
+		return nil, store.ErrEmailNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &email, nil
+}
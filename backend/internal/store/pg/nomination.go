@@ -34,7 +34,7 @@ func (st *PgNominationStore) SubmitOrReplaceNomination(ctx context.Context, elec
 		return "", err
 	}
 
-	_, err = st.pool.Exec(ctx, `
+	_, err = connFor(ctx, st.pool).Exec(ctx, `
 		insert into nominations (
 			nomination_id,
 		
@@ -74,7 +74,7 @@ func (st *PgNominationStore) SubmitOrReplaceNomination(ctx context.Context, elec
 }
 
 func (st *PgNominationStore) GetNomination(ctx context.Context, electionID string, candidateZid string) (*store.Nomination, error) {
-	rows, err := st.pool.Query(ctx, `
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
 		select * from nominations
 		where election_id = $1 and candidate_zid = $2
 	`, electionID, candidateZid)
@@ -94,7 +94,7 @@ func (st *PgNominationStore) GetNomination(ctx context.Context, electionID strin
 }
 
 func (st *PgNominationStore) GetNominationByPublicId(ctx context.Context, nominationId string) (*store.Nomination, error) {
-	rows, err := st.pool.Query(ctx, `
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
 		select * from nominations
 		where nomination_id = $1
 	`, nominationId)
@@ -114,7 +114,7 @@ func (st *PgNominationStore) GetNominationByPublicId(ctx context.Context, nomina
 }
 
 func (st *PgNominationStore) GetElectionNominations(ctx context.Context, electionId string) ([]store.Nomination, error) {
-	rows, err := st.pool.Query(ctx, `
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
 		select * from nominations
 		where election_id = $1
 		order by created_at asc
@@ -133,7 +133,7 @@ func (st *PgNominationStore) GetElectionNominations(ctx context.Context, electio
 }
 
 func (st *PgNominationStore) TryDeleteNomination(ctx context.Context, electionID string, candidateZid string) error {
-	_, err := st.pool.Exec(ctx, `
+	_, err := connFor(ctx, st.pool).Exec(ctx, `
 		delete from nominations
 		where election_id = $1 and candidate_zid = $2
 	`, electionID, candidateZid)
@@ -4,8 +4,10 @@ import (
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/linuxunsw/vote/backend/internal/config"
@@ -61,7 +63,7 @@ type otpRatelimit struct {
 }
 
 func (st *PgOTPStore) CreateOrReplace(ctx context.Context, zid string, code string) error {
-	tx, err := st.pool.Begin(ctx)
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -72,7 +74,7 @@ func (st *PgOTPStore) CreateOrReplace(ctx context.Context, zid string, code stri
 	codeHash := st.hashCode(code)
 	now := st.NowProvider()
 
-	ratelimitRows, err := st.pool.Query(ctx, `
+	ratelimitRows, err := connFor(ctx, st.pool).Query(ctx, `
 		select * from otp_ratelimit where zid = $1
 	`, zid)
 	if err != nil {
@@ -82,7 +84,7 @@ func (st *PgOTPStore) CreateOrReplace(ctx context.Context, zid string, code stri
 	ratelimitEntry, err := pgx.CollectOneRow(ratelimitRows, pgx.RowToStructByName[otpRatelimit])
 	if errors.Is(err, pgx.ErrNoRows) {
 		// no rate limit, reset the window
-		_, err = st.pool.Exec(ctx, `
+		_, err = connFor(ctx, st.pool).Exec(ctx, `
 			insert into otp_ratelimit (zid, count, window_start)
 			values ($1, 0, $2)
 		`, zid, now)
@@ -99,7 +101,7 @@ func (st *PgOTPStore) CreateOrReplace(ctx context.Context, zid string, code stri
 			}
 
 			ratelimitEntry.Count++
-			_, err := st.pool.Exec(ctx, `
+			_, err := connFor(ctx, st.pool).Exec(ctx, `
 				update otp_ratelimit set count = $2 where zid = $1
 			`, zid, ratelimitEntry.Count)
 			if err != nil {
@@ -107,7 +109,7 @@ func (st *PgOTPStore) CreateOrReplace(ctx context.Context, zid string, code stri
 			}
 		} else {
 			// reset the ratelimit window
-			_, err = st.pool.Exec(ctx, `
+			_, err = connFor(ctx, st.pool).Exec(ctx, `
 				update otp_ratelimit
 				set count = 0, window_start = $2
 				where zid = $1
@@ -118,7 +120,7 @@ func (st *PgOTPStore) CreateOrReplace(ctx context.Context, zid string, code stri
 		}
 	}
 
-	_, err = st.pool.Exec(ctx, `
+	_, err = connFor(ctx, st.pool).Exec(ctx, `
 		insert into otp (zid, code_hash, retry_amount, created_at)
 		values ($1, $2, 0, $3)
 		on conflict (zid) do update set
@@ -138,7 +140,7 @@ func (st *PgOTPStore) CreateOrReplace(ctx context.Context, zid string, code stri
 }
 
 func (st *PgOTPStore) Active(ctx context.Context, zid string) (*store.OTPEntry, error) {
-	rows, err := st.pool.Query(ctx, `
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
 		select * from otp
 		where zid = $1
 	`, zid)
@@ -158,7 +160,7 @@ func (st *PgOTPStore) Active(ctx context.Context, zid string) (*store.OTPEntry,
 }
 
 func (st *PgOTPStore) activeForUpdate(ctx context.Context, zid string) (*store.OTPEntry, error) {
-	rows, err := st.pool.Query(ctx, `
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
 		select * from otp
 		where zid = $1
 		for update
@@ -177,7 +179,48 @@ func (st *PgOTPStore) activeForUpdate(ctx context.Context, zid string) (*store.O
 }
 
 func (st *PgOTPStore) ValidateAndConsume(ctx context.Context, zid string, code string) (valid bool, reason store.OTPValidate, err error) {
-	tx, err := st.pool.Begin(ctx)
+	return st.validateAndConsume(ctx, zid, func(otp *store.OTPEntry) bool {
+		return st.hashCompare(code, otp.CodeHash)
+	})
+}
+
+// signs the link for an entry. the signature covers the code's hash, so it changes
+// whenever a new code is created
+func (st *PgOTPStore) linkSignature(zid string, codeHash string) []byte {
+	mac := hmac.New(sha256.New, []byte(st.secret))
+	mac.Write([]byte("magic-link:" + zid + ":" + codeHash))
+	return mac.Sum(nil)
+}
+
+func (st *PgOTPStore) LinkToken(zid string, code string) string {
+	signature := st.linkSignature(zid, st.hashCode(code))
+	return zid + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (st *PgOTPStore) ValidateLinkAndConsume(ctx context.Context, token string) (zid string, valid bool, reason store.OTPValidate, err error) {
+	zid, encoded, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", false, store.OTPValidateMismatch, nil
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false, store.OTPValidateMismatch, nil
+	}
+
+	valid, reason, err = st.validateAndConsume(ctx, zid, func(otp *store.OTPEntry) bool {
+		return hmac.Equal(signature, st.linkSignature(zid, otp.CodeHash))
+	})
+	if reason == store.OTPValidateNotFoundOrExpired {
+		// the zid may be anything the client sent, so isn't reported unless it has a code
+		zid = ""
+	}
+	return zid, valid, reason, err
+}
+
+// validates zid's entry with matches, counting an attempt against it, and consumes it
+// if it matches
+func (st *PgOTPStore) validateAndConsume(ctx context.Context, zid string, matches func(otp *store.OTPEntry) bool) (valid bool, reason store.OTPValidate, err error) {
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
 	if err != nil {
 		return false, store.OTPValidateInternalError, err
 	}
@@ -206,7 +249,7 @@ func (st *PgOTPStore) ValidateAndConsume(ctx context.Context, zid string, code s
 		return false, store.OTPValidateAttemptsExceeded, nil
 	}
 
-	ok := st.hashCompare(code, otp.CodeHash)
+	ok := matches(otp)
 	otp.RetryAmount++
 
 	if ok {
@@ -235,7 +278,7 @@ func (st *PgOTPStore) ValidateAndConsume(ctx context.Context, zid string, code s
 }
 
 func (st *PgOTPStore) ConsumeIfExists(ctx context.Context, zid string) error {
-	tx, err := st.pool.Begin(ctx)
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -243,13 +286,13 @@ func (st *PgOTPStore) ConsumeIfExists(ctx context.Context, zid string) error {
 		_ = tx.Rollback(ctx)
 	}()
 
-	_, err = st.pool.Exec(ctx, `
+	_, err = connFor(ctx, st.pool).Exec(ctx, `
 		delete from otp where zid = $1;
 	`, zid)
 	if err != nil {
 		return err
 	}
-	_, err = st.pool.Exec(ctx, `
+	_, err = connFor(ctx, st.pool).Exec(ctx, `
 		delete from otp_ratelimit where zid = $1;
 	`, zid)
 	if err != nil {
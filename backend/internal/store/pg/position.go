@@ -0,0 +1,106 @@
+package pg
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type PgPositionStore struct {
+	// *pgx.Pool
+	pool PgxPoolIface
+}
+
+func NewPgPositionStore(pool PgxPoolIface) store.PositionStore {
+	return &PgPositionStore{
+		pool: pool,
+	}
+}
+
+func getPositions(ctx context.Context, tx query, electionId string) ([]store.Position, error) {
+	rows, err := tx.Query(ctx, `
+		select key, display_name, seats, description from positions
+		where election_id = $1
+		order by sort_order asc
+	`, electionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[store.Position])
+}
+
+// inserts positions for an election, keeping the order they are given in
+func insertPositions(ctx context.Context, tx pgx.Tx, electionId string, positions []store.Position) error {
+	_, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"positions"},
+		[]string{"election_id", "key", "display_name", "seats", "description", "sort_order"},
+		pgx.CopyFromSlice(len(positions), func(i int) ([]interface{}, error) {
+			position := positions[i]
+			return []interface{}{
+				electionId,
+				position.Key,
+				position.DisplayName,
+				position.Seats,
+				position.Description,
+				i,
+			}, nil
+		}),
+	)
+	return err
+}
+
+func (st *PgPositionStore) GetPositions(ctx context.Context, electionId string) ([]store.Position, error) {
+	// ErrElectionNotFound
+	if err := assertElectionExists(ctx, connFor(ctx, st.pool), electionId); err != nil {
+		return nil, err
+	}
+
+	return getPositions(ctx, connFor(ctx, st.pool), electionId)
+}
+
+func (st *PgPositionStore) SetPositions(ctx context.Context, electionId string, positions []store.Position) error {
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// lock the election so it can't leave CLOSED until the positions are replaced
+	var state store.ElectionState
+	err = tx.QueryRow(ctx, `
+		select state from elections
+		where election_id = $1
+		for update
+	`, electionId).Scan(&state)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return store.ErrElectionNotFound
+	} else if err != nil {
+		return err
+	}
+	if state != store.StateClosed {
+		return store.ErrPositionsElectionNotClosed
+	}
+
+	_, err = tx.Exec(ctx, `
+		delete from positions
+		where election_id = $1
+	`, electionId)
+	if err != nil {
+		return err
+	}
+
+	if err := insertPositions(ctx, tx, electionId, positions); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return nil
+}
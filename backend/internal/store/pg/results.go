@@ -0,0 +1,135 @@
+package pg
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/linuxunsw/vote/backend/internal/store"
+	"github.com/linuxunsw/vote/backend/internal/tally"
+)
+
+type PgResultsStore struct {
+	// *pgx.Pool
+	pool PgxPoolIface
+}
+
+func NewPgResultsStore(pool PgxPoolIface) store.ResultsStore {
+	return &PgResultsStore{
+		pool: pool,
+	}
+}
+
+type resultsNomination struct {
+	NominationId   string   `db:"nomination_id"`
+	CandidateName  string   `db:"candidate_name"`
+	ExecutiveRoles []string `db:"executive_roles"`
+}
+
+func (st *PgResultsStore) GetResults(ctx context.Context, electionID string) (*store.ElectionResults, error) {
+	// ErrElectionNotFound
+	if err := assertElectionExists(ctx, connFor(ctx, st.pool), electionID); err != nil {
+		return nil, err
+	}
+
+	positions, err := getPositions(ctx, connFor(ctx, st.pool), electionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// every position is counted, even if nobody ran for it
+	seats := make(map[string]int, len(positions))
+	candidates := make(map[string][]tally.Candidate, len(positions))
+	for _, position := range positions {
+		seats[position.Key] = position.Seats
+		candidates[position.Key] = []tally.Candidate{}
+	}
+
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select nomination_id, candidate_name, executive_roles
+		from nominations
+		where election_id = $1
+	`, electionID)
+	if err != nil {
+		return nil, err
+	}
+	nominations, err := pgx.CollectRows(rows, pgx.RowToStructByName[resultsNomination])
+	if err != nil {
+		return nil, err
+	}
+
+	// position -> candidates running for that position
+	for _, nomination := range nominations {
+		for _, role := range nomination.ExecutiveRoles {
+			candidates[role] = append(candidates[role], tally.Candidate{
+				NominationID:  nomination.NominationId,
+				CandidateName: nomination.CandidateName,
+			})
+		}
+	}
+
+	rows, err = connFor(ctx, st.pool).Query(ctx, `
+		select positions from ballots
+		where election_id = $1
+		union all
+		select positions from secret_ballots
+		where election_id = $1
+	`, electionID)
+	if err != nil {
+		return nil, err
+	}
+	ballots, err := pgx.CollectRows(rows, pgx.RowTo[map[string][]string])
+	if err != nil {
+		return nil, err
+	}
+
+	var eligibleVoters int
+	err = connFor(ctx, st.pool).QueryRow(ctx, `
+		select count(*) from election_member_list
+		where election_id = $1
+	`, electionID).Scan(&eligibleVoters)
+	if err != nil {
+		return nil, err
+	}
+
+	results := tally.Count(electionID, seats, candidates, ballots, eligibleVoters)
+	return &results, nil
+}
+
+func (st *PgResultsStore) GetBulletin(ctx context.Context, electionID string) ([]store.BulletinEntry, error) {
+	// ErrElectionNotFound
+	if err := assertElectionExists(ctx, connFor(ctx, st.pool), electionID); err != nil {
+		return nil, err
+	}
+
+	// ordered by hash so the order ballots were cast in isn't revealed
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select receipt_hash, positions from ballots
+		where election_id = $1
+		union all
+		select receipt_hash, positions from secret_ballots
+		where election_id = $1
+		order by receipt_hash nulls last
+	`, electionID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[store.BulletinEntry])
+}
+
+func (st *PgResultsStore) GetReceiptKey(ctx context.Context, electionID string) ([]byte, error) {
+	if _, err := uuid.Parse(electionID); err != nil {
+		return nil, store.ErrElectionNotFound
+	}
+
+	var key []byte
+	err := connFor(ctx, st.pool).QueryRow(ctx, `
+		select receipt_public_key from elections
+		where election_id = $1
+	`, electionID).Scan(&key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, store.ErrElectionNotFound
+	}
+	return key, err
+}
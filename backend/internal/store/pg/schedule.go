@@ -0,0 +1,143 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type PgScheduleStore struct {
+	// *pgx.Pool
+	pool PgxPoolIface
+
+	NowProvider func() time.Time
+}
+
+func NewPgScheduleStore(pool PgxPoolIface) store.ScheduleStore {
+	return &PgScheduleStore{
+		pool: pool,
+
+		NowProvider: time.Now,
+	}
+}
+
+// held by whichever API process is running scheduled transitions
+const schedulerLockKey = 0x73636864
+
+func (st *PgScheduleStore) SetSchedule(ctx context.Context, electionId string, entries []store.ScheduleEntry, createdBy string) error {
+	now := st.NowProvider()
+
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// ErrElectionNotFound
+	if err := assertElectionExists(ctx, tx, electionId); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		delete from election_schedules
+		where election_id = $1 and ran_at is null
+	`, electionId)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		_, err = tx.Exec(ctx, `
+			insert into election_schedules (election_id, state, run_at, created_by, created_at)
+			values ($1, $2, $3, $4, $5)
+			on conflict (election_id, state) do update
+			set run_at = excluded.run_at,
+				created_by = excluded.created_by,
+				created_at = excluded.created_at,
+				ran_at = null,
+				error = null
+		`, electionId, entry.State, entry.RunAt, createdBy, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (st *PgScheduleStore) GetSchedule(ctx context.Context, electionId string) ([]store.ScheduledTransition, error) {
+	// ErrElectionNotFound
+	if err := assertElectionExists(ctx, connFor(ctx, st.pool), electionId); err != nil {
+		return nil, err
+	}
+
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select election_id, state, run_at, created_by, created_at, ran_at, error
+		from election_schedules
+		where election_id = $1
+		order by run_at asc
+	`, electionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[store.ScheduledTransition])
+}
+
+func (st *PgScheduleStore) DueTransitions(ctx context.Context, electionId string, now time.Time) ([]store.ScheduledTransition, error) {
+	rows, err := connFor(ctx, st.pool).Query(ctx, `
+		select election_id, state, run_at, created_by, created_at, ran_at, error
+		from election_schedules
+		where election_id = $1 and ran_at is null and run_at <= $2
+		order by run_at asc
+	`, electionId, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[store.ScheduledTransition])
+}
+
+func (st *PgScheduleStore) MarkRan(ctx context.Context, electionId string, state store.ElectionState, ranAt time.Time, runErr error) error {
+	var errorMessage *string
+	if runErr != nil {
+		message := runErr.Error()
+		errorMessage = &message
+	}
+
+	_, err := connFor(ctx, st.pool).Exec(ctx, `
+		update election_schedules
+		set ran_at = $1, error = $2
+		where election_id = $3 and state = $4
+	`, ranAt, errorMessage, electionId, state)
+	return err
+}
+
+func (st *PgScheduleStore) WithSchedulerLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	// the lock is released when the transaction ends, even if this process dies
+	tx, err := connFor(ctx, st.pool).Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var locked bool
+	err = tx.QueryRow(ctx, `select pg_try_advisory_xact_lock($1)`, schedulerLockKey).Scan(&locked)
+	if err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+
+	return true, fn(ctx)
+}
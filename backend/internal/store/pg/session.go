@@ -0,0 +1,107 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+type PgSessionStore struct {
+	// *pgx.Pool
+	pool PgxPoolIface
+
+	NowProvider func() time.Time
+}
+
+func NewPgSessionStore(pool PgxPoolIface) store.SessionStore {
+	return &PgSessionStore{
+		pool: pool,
+
+		NowProvider: time.Now,
+	}
+}
+
+func (st *PgSessionStore) CreateSession(ctx context.Context, zid string, expiresAt time.Time) (string, error) {
+	sessionId := uuid.NewString()
+
+	_, err := connFor(ctx, st.pool).Exec(ctx, `
+		insert into sessions (session_id, zid, created_at, expires_at)
+		values ($1, $2, $3, $4)
+	`, sessionId, zid, st.NowProvider(), expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	return sessionId, nil
+}
+
+func (st *PgSessionStore) SessionActive(ctx context.Context, sessionId string) (bool, error) {
+	// not a uuid, so can't exist
+	if _, err := uuid.Parse(sessionId); err != nil {
+		return false, nil
+	}
+
+	var active bool
+	err := connFor(ctx, st.pool).QueryRow(ctx, `
+		select exists (
+			select 1 from sessions
+			where session_id = $1 and revoked_at is null and expires_at > $2
+		)
+	`, sessionId, st.NowProvider()).Scan(&active)
+	if err != nil {
+		return false, err
+	}
+
+	return active, nil
+}
+
+func (st *PgSessionStore) ExtendSession(ctx context.Context, sessionId string, expiresAt time.Time, maxLifetime time.Duration) (*store.Session, error) {
+	if _, err := uuid.Parse(sessionId); err != nil {
+		return nil, nil
+	}
+
+	session := store.Session{SessionID: sessionId}
+	err := connFor(ctx, st.pool).QueryRow(ctx, `
+		update sessions set expires_at = least($2, created_at + make_interval(secs => $4))
+		where session_id = $1 and revoked_at is null and expires_at > $3
+		returning zid, created_at, expires_at
+	`, sessionId, expiresAt, st.NowProvider(), maxLifetime.Seconds()).Scan(&session.Zid, &session.CreatedAt, &session.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (st *PgSessionStore) RevokeSession(ctx context.Context, sessionId string) error {
+	if _, err := uuid.Parse(sessionId); err != nil {
+		return nil
+	}
+
+	_, err := connFor(ctx, st.pool).Exec(ctx, `
+		update sessions set revoked_at = $2
+		where session_id = $1 and revoked_at is null
+	`, sessionId, st.NowProvider())
+	return err
+}
+
+func (st *PgSessionStore) RevokeAllSessions(ctx context.Context, zid string) (int, error) {
+	now := st.NowProvider()
+
+	tag, err := connFor(ctx, st.pool).Exec(ctx, `
+		update sessions set revoked_at = $2
+		where zid = $1 and revoked_at is null and expires_at > $2
+	`, zid, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,69 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+// postgres channel state changes are published on
+const stateChangeChannel = "election_state"
+
+// queues a notification that is only delivered to listeners if tx commits
+func notifyStateChange(ctx context.Context, tx pgx.Tx, electionId string, state store.ElectionState) error {
+	payload, err := json.Marshal(store.StateChange{
+		ElectionID: electionId,
+		State:      state,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `select pg_notify($1, $2)`, stateChangeChannel, string(payload))
+	return err
+}
+
+type PgStateListener struct {
+	// *pgx.Pool
+	pool PgxPoolIface
+}
+
+func NewPgStateListener(pool PgxPoolIface) store.StateListener {
+	return &PgStateListener{
+		pool: pool,
+	}
+}
+
+func (l *PgStateListener) ListenStateChanges(ctx context.Context, listening func(), fn func(store.StateChange)) error {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `listen `+pgx.Identifier{stateChangeChannel}.Sanitize())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// the connection goes back to the pool, it shouldn't keep receiving notifications.
+		// if ctx was cancelled while waiting the connection has been closed already
+		_, _ = conn.Exec(context.WithoutCancel(ctx), `unlisten *`)
+	}()
+	listening()
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var change store.StateChange
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			return err
+		}
+		fn(change)
+	}
+}
@@ -0,0 +1,34 @@
+package pg
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// what stores run statements on, either the pool or a transaction
+type conn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(context.Context, string, ...any) (pgconn.CommandTag, error)
+	QueryRow(context.Context, string, ...any) pgx.Row
+	Query(ctx context.Context, query string, args ...any) (pgx.Rows, error)
+}
+
+type txKey struct{}
+
+// a context whose statements run in tx
+func withTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// The transaction ctx was given by withTx, or pool if there isn't one. Stores run every
+// statement on this, so what they do takes part in a caller's transaction, e.g so an
+// action and its audit entry are committed together. Transactions a store begins on it
+// become savepoints.
+func connFor(ctx context.Context, pool PgxPoolIface) conn {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return pool
+}
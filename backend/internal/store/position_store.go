@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// A position that candidates can nominate for and voters can vote on in an election
+type Position struct {
+	Key         string `db:"key" json:"key" minLength:"1" maxLength:"32" pattern:"^[a-z][a-z0-9_]*$" example:"president" doc:"Identifier used for the position in nominations, ballots and results"`
+	DisplayName string `db:"display_name" json:"display_name" minLength:"1" maxLength:"100" example:"President"`
+	Seats       int    `db:"seats" json:"seats" minimum:"1" maximum:"50" example:"1" doc:"Number of candidates elected. Positions with more than one seat are counted using single transferable vote."`
+	Description string `db:"description" json:"description" required:"false" maxLength:"2000" example:"Leads the society and chairs executive meetings."`
+}
+
+// The positions an election is created with if none are given. seats overrides the
+// number of seats for a position by key, positions missing from seats have one.
+func DefaultPositions(seats map[string]int) []Position {
+	positions := []Position{
+		{Key: "president", DisplayName: "President"},
+		{Key: "secretary", DisplayName: "Secretary"},
+		{Key: "treasurer", DisplayName: "Treasurer"},
+		{Key: "arc_delegate", DisplayName: "Arc Delegate"},
+		{Key: "edi_officer", DisplayName: "EDI Officer"},
+		{Key: "grievance_officer", DisplayName: "Grievance Officer"},
+	}
+	for i := range positions {
+		positions[i].Seats = 1
+		if n, ok := seats[positions[i].Key]; ok && n > 0 {
+			positions[i].Seats = n
+		}
+	}
+	return positions
+}
+
+var ErrPositionsElectionNotClosed = errors.New("positions can only be changed while the election is closed")
+
+type PositionStore interface {
+	// Get the positions for an election, in the order they were defined.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	GetPositions(ctx context.Context, electionId string) ([]Position, error)
+
+	// Replace the positions for an election. Does not validate keys are unique.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	// Returns error ErrPositionsElectionNotClosed if the election is not in the CLOSED state.
+	SetPositions(ctx context.Context, electionId string, positions []Position) error
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+)
+
+// Votes are fractional when counting multi-seat positions, as surpluses are transferred
+// at a reduced value. Single-seat counts only ever hold whole votes.
+type CandidateTally struct {
+	NominationID  string  `json:"nomination_id" doc:"Public nomination ID"`
+	CandidateName string  `json:"candidate_name" example:"John Doe"`
+	Votes         float64 `json:"votes" doc:"Value of the ballots held by this candidate"`
+}
+
+// A surplus passed on from an elected candidate to the next preferences on their ballots
+type SurplusTransfer struct {
+	From          string  `json:"from" doc:"Public nomination ID of the elected candidate"`
+	Surplus       float64 `json:"surplus" doc:"Votes held above the quota"`
+	TransferValue float64 `json:"transfer_value" doc:"Fraction of each ballot's value that is transferred"`
+}
+
+// A single round (or stage) of a count
+type CountRound struct {
+	Round int `json:"round" example:"1"`
+	// sorted by votes, highest first. only includes candidates that haven't been eliminated
+	Tallies    []CandidateTally `json:"tallies" doc:"Votes held by each candidate that hasn't been eliminated in this round"`
+	Exhausted  float64          `json:"exhausted" doc:"Value of ballots with no preferences left for a continuing candidate"`
+	Elected    []string         `json:"elected,omitempty" doc:"Public nomination IDs elected at the end of this round"`
+	Eliminated []string         `json:"eliminated,omitempty" doc:"Public nomination IDs eliminated at the end of this round"`
+	Transfer   *SurplusTransfer `json:"transfer,omitempty" doc:"Surplus transferred at the end of this round"`
+}
+
+type PositionResult struct {
+	Position string `json:"position" example:"president"`
+	Seats    int    `json:"seats" doc:"Number of candidates elected to this position" example:"1"`
+	// only set for multi-seat positions
+	Quota float64 `json:"quota,omitempty" doc:"Droop quota needed to be elected. Only set for positions with more than one seat."`
+	// first preferences, sorted by votes, highest first. includes RON
+	Candidates []CandidateTally `json:"candidates" doc:"First preference votes for every candidate, including RON"`
+	Rounds     []CountRound     `json:"rounds" doc:"Each round of the count. Single-seat positions use instant-runoff, multi-seat positions use single transferable vote."`
+	// more than seats winners is only possible on a tie
+	Winners     []string `json:"winners" doc:"Public nomination IDs of the winning candidate(s), in order of election. Contains more IDs than seats if there is a tie. RON means a seat is left vacant and nominations are re-opened."`
+	Tied        bool     `json:"tied" doc:"Whether the count ended with candidates that could not be separated"`
+	TotalVotes  int      `json:"total_votes" doc:"Number of ballots that ranked a candidate or RON for this position"`
+	Abstentions int      `json:"abstentions" doc:"Number of ballots that abstained from this position. These are not part of the count."`
+}
+
+type Turnout struct {
+	BallotsCast    int     `json:"ballots_cast"`
+	EligibleVoters int     `json:"eligible_voters" doc:"Size of the election member list"`
+	Percentage     float64 `json:"percentage" doc:"Ballots cast as a percentage of eligible voters"`
+}
+
+type ElectionResults struct {
+	ElectionID string           `json:"election_id"`
+	Turnout    Turnout          `json:"turnout"`
+	Positions  []PositionResult `json:"positions"`
+}
+
+// A counted ballot, without anything identifying who cast it
+type BulletinEntry struct {
+	ReceiptHash []byte              `db:"receipt_hash"`
+	Positions   map[string][]string `db:"positions"`
+}
+
+type ResultsStore interface {
+	// Tally all ballots cast in the given election.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	GetResults(ctx context.Context, electionID string) (*ElectionResults, error)
+
+	// Get every ballot counted in the given election, ordered by receipt hash.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	GetBulletin(ctx context.Context, electionID string) ([]BulletinEntry, error)
+
+	// Get the public key the given election's receipts were signed with, or nil if no
+	// ballots have been cast in it.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	GetReceiptKey(ctx context.Context, electionID string) ([]byte, error)
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// A state transition an admin wants to happen at a given time
+type ScheduleEntry struct {
+	State ElectionState `json:"state" enum:"CLOSED,NOMINATIONS_OPEN,NOMINATIONS_CLOSED,VOTING_OPEN,VOTING_CLOSED,RESULTS,END" doc:"State to transition to"`
+	RunAt time.Time     `json:"run_at" format:"date-time" example:"2024-01-15T10:30:00Z" doc:"When to transition. Times in the past run on the scheduler's next check."`
+}
+
+// A scheduled state transition and the outcome of running it
+type ScheduledTransition struct {
+	ElectionID string        `db:"election_id" json:"election_id"`
+	State      ElectionState `db:"state" json:"state"`
+	RunAt      time.Time     `db:"run_at" json:"run_at" format:"date-time"`
+	CreatedBy  string        `db:"created_by" json:"created_by" example:"z1234567" doc:"zID of the admin who scheduled the transition"`
+	CreatedAt  time.Time     `db:"created_at" json:"created_at" format:"date-time"`
+	RanAt      *time.Time    `db:"ran_at" json:"ran_at,omitempty" format:"date-time" doc:"When the scheduler attempted the transition. Not set while it is pending."`
+	Error      *string       `db:"error" json:"error,omitempty" doc:"Why the transition failed, if it did"`
+}
+
+// The audit log actor for transitions made by the scheduler
+const AuditActorScheduler = "scheduler"
+
+type ScheduleStore interface {
+	// Replace the pending transitions for an election with entries, scheduled by the admin
+	// createdBy. Entries for a state that has already run are scheduled again. Does not
+	// validate states are unique.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	SetSchedule(ctx context.Context, electionId string, entries []ScheduleEntry, createdBy string) error
+
+	// Get every scheduled transition for an election, pending or not, ordered by run time.
+	// Returns error ErrElectionNotFound if the election referenced by ID does not exist.
+	GetSchedule(ctx context.Context, electionId string) ([]ScheduledTransition, error)
+
+	// Get the pending transitions for an election due at or before now, ordered by run time.
+	DueTransitions(ctx context.Context, electionId string, now time.Time) ([]ScheduledTransition, error)
+
+	// Record that the scheduler attempted a transition at ranAt, with the error it failed
+	// with or nil if it succeeded.
+	MarkRan(ctx context.Context, electionId string, state ElectionState, ranAt time.Time, runErr error) error
+
+	// Run fn while holding a lock shared by every API process, so scheduled transitions
+	// are only run by one of them. Returns false without running fn if another process
+	// holds the lock.
+	WithSchedulerLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error)
+}
@@ -0,0 +1,32 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type Session struct {
+	SessionID string
+	Zid       string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type SessionStore interface {
+	// Create a session for zid that expires at expiresAt. Returns the session ID, which
+	// is used as the JWT's jti.
+	CreateSession(ctx context.Context, zid string, expiresAt time.Time) (string, error)
+
+	// Whether the session exists and hasn't expired or been revoked.
+	SessionActive(ctx context.Context, sessionId string) (bool, error)
+
+	// Move an active session's expiry to expiresAt, but no later than maxLifetime after it
+	// was created. Returns nil if the session has expired or been revoked.
+	ExtendSession(ctx context.Context, sessionId string, expiresAt time.Time, maxLifetime time.Duration) (*Session, error)
+
+	// Revoke a session. Does nothing if it doesn't exist or is already revoked.
+	RevokeSession(ctx context.Context, sessionId string) error
+
+	// Revoke every active session for zid. Returns the number of sessions revoked.
+	RevokeAllSessions(ctx context.Context, zid string) (int, error)
+}
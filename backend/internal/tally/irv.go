@@ -0,0 +1,163 @@
+package tally
+
+import (
+	"sort"
+
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+// Counts preferential ballots for one position using instant-runoff voting.
+//
+// Each round, every ballot counts towards its highest ranked continuing candidate. A
+// candidate with more than half of the non-exhausted ballots is elected, otherwise the
+// candidate(s) with the fewest votes are eliminated and their ballots transfer to the
+// next preference. Ties for last place are broken by the most recent round in which the
+// tied candidates had different totals, and if they never did, all of them are eliminated
+// together. If every continuing candidate is tied, the count ends in a tie.
+//
+// Candidates with no votes are still included so that the result lists everyone who ran.
+// Preferences for nominations that are not in candidates are still counted, but will have
+// an empty name. Ballots that abstain from the position are not counted.
+func InstantRunoff(position string, candidates []Candidate, ballots []map[string][]string) store.PositionResult {
+	names := make(map[string]string, len(candidates))
+	continuing := make(map[string]struct{}, len(candidates))
+	for _, c := range candidates {
+		names[c.NominationID] = c.CandidateName
+		continuing[c.NominationID] = struct{}{}
+	}
+
+	rankings, abstentions := rankingsFor(position, ballots)
+	for _, preferences := range rankings {
+		for _, nominationId := range preferences {
+			continuing[nominationId] = struct{}{}
+		}
+	}
+
+	result := store.PositionResult{
+		Position:    position,
+		Seats:       1,
+		Rounds:      []store.CountRound{},
+		Winners:     []string{},
+		TotalVotes:  len(rankings),
+		Abstentions: abstentions,
+	}
+
+	// every round's totals, used to break ties for last place
+	var history []map[string]int
+
+	for len(continuing) > 0 {
+		counts := make(map[string]int, len(continuing))
+		for nominationId := range continuing {
+			counts[nominationId] = 0
+		}
+
+		exhausted := 0
+		for _, preferences := range rankings {
+			if nominationId, ok := highestContinuing(preferences, continuing); ok {
+				counts[nominationId]++
+			} else {
+				exhausted++
+			}
+		}
+		history = append(history, counts)
+
+		round := store.CountRound{
+			Round:     len(history),
+			Tallies:   talliesFrom(counts, names, 1),
+			Exhausted: float64(exhausted),
+		}
+		if round.Round == 1 {
+			result.Candidates = round.Tallies
+		}
+
+		active := len(rankings) - exhausted
+		if active == 0 {
+			// nobody has voted for a continuing candidate, so nobody can win
+			result.Rounds = append(result.Rounds, round)
+			break
+		}
+
+		leader := round.Tallies[0]
+		if int(leader.Votes)*2 > active || len(continuing) == 1 {
+			round.Elected = []string{leader.NominationID}
+			result.Rounds = append(result.Rounds, round)
+			result.Winners = round.Elected
+			break
+		}
+
+		lowest := breakTies(lowestOf(counts), history)
+		if len(lowest) == len(continuing) {
+			// everyone left is inseparable
+			round.Elected = lowest
+			result.Rounds = append(result.Rounds, round)
+			result.Winners = lowest
+			result.Tied = true
+			break
+		}
+
+		round.Eliminated = lowest
+		result.Rounds = append(result.Rounds, round)
+		for _, nominationId := range lowest {
+			delete(continuing, nominationId)
+		}
+	}
+
+	if result.Candidates == nil {
+		result.Candidates = []store.CandidateTally{}
+	}
+
+	return result
+}
+
+func highestContinuing(preferences []string, continuing map[string]struct{}) (string, bool) {
+	for _, nominationId := range preferences {
+		if _, ok := continuing[nominationId]; ok {
+			return nominationId, true
+		}
+	}
+	return "", false
+}
+
+// Converts counts into sorted tallies, where unit is the count that makes up a single vote
+func talliesFrom[T int | int64](counts map[string]T, names map[string]string, unit float64) []store.CandidateTally {
+	tallies := make([]store.CandidateTally, 0, len(counts))
+	for nominationId, votes := range counts {
+		tallies = append(tallies, store.CandidateTally{
+			NominationID:  nominationId,
+			CandidateName: names[nominationId],
+			Votes:         float64(votes) / unit,
+		})
+	}
+	sortTallies(tallies)
+	return tallies
+}
+
+// Returns the nomination IDs with the fewest votes, sorted by ID
+func lowestOf[T int | int64](counts map[string]T) []string {
+	var lowest []string
+	var min T
+	for nominationId, votes := range counts {
+		if lowest == nil || votes < min {
+			min = votes
+			lowest = []string{nominationId}
+		} else if votes == min {
+			lowest = append(lowest, nominationId)
+		}
+	}
+	sort.Strings(lowest)
+	return lowest
+}
+
+// Narrows down tied candidates by looking back through previous rounds, keeping only
+// those that had the fewest votes in the most recent round where they differed.
+func breakTies[T int | int64](tied []string, history []map[string]T) []string {
+	// the last entry in history is the current round, which is already tied
+	for i := len(history) - 2; i >= 0 && len(tied) > 1; i-- {
+		counts := make(map[string]T, len(tied))
+		for _, nominationId := range tied {
+			counts[nominationId] = history[i][nominationId]
+		}
+		tied = lowestOf(counts)
+	}
+	return tied
+}
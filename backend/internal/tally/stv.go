@@ -0,0 +1,236 @@
+package tally
+
+import (
+	"sort"
+
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+// STV counts use fixed point arithmetic so that results are reproducible, ballot values
+// are truncated to 5 decimal places after each transfer.
+const stvScale int64 = 100_000
+
+// A ballot in an STV count, along with the candidate currently holding it and its
+// remaining value. An empty holder means the ballot is exhausted.
+type stvBallot struct {
+	preferences []string
+	holder      string
+	value       int64
+}
+
+// Counts preferential ballots for a position with more than one seat using single
+// transferable vote, with the Droop quota and weighted inclusive Gregory transfers.
+//
+// Each round, continuing candidates that reach the quota are elected. If seats remain, the
+// largest untransferred surplus is passed on: every ballot held by the elected candidate
+// moves to its next continuing preference, at its current value multiplied by surplus /
+// total. If there is no surplus to transfer, the candidate(s) with the fewest votes are
+// excluded and their ballots move on at their current value. Ties for exclusion are broken
+// in the same way as InstantRunoff. Once the continuing candidates can only just fill the
+// remaining seats, they are all elected. If the candidates tied for exclusion cannot all be
+// excluded without leaving seats empty, the count ends in a tie.
+//
+// Candidates with no votes are still included so that the result lists everyone who ran.
+// Preferences for nominations that are not in candidates are still counted, but will have
+// an empty name. Ballots that abstain from the position are not counted.
+func SingleTransferableVote(position string, seats int, candidates []Candidate, ballots []map[string][]string) store.PositionResult {
+	names := make(map[string]string, len(candidates))
+	hopeful := make(map[string]struct{}, len(candidates))
+	for _, c := range candidates {
+		names[c.NominationID] = c.CandidateName
+		hopeful[c.NominationID] = struct{}{}
+	}
+
+	rankings, abstentions := rankingsFor(position, ballots)
+	piles := make([]*stvBallot, 0, len(rankings))
+	for _, preferences := range rankings {
+		for _, nominationId := range preferences {
+			hopeful[nominationId] = struct{}{}
+		}
+		piles = append(piles, &stvBallot{preferences: preferences, value: stvScale})
+	}
+	for _, b := range piles {
+		b.holder, _ = highestContinuing(b.preferences, hopeful)
+	}
+
+	valid := int64(len(piles))
+	quota := (valid/int64(seats+1) + 1) * stvScale
+
+	result := store.PositionResult{
+		Position:    position,
+		Seats:       seats,
+		Quota:       float64(quota) / float64(stvScale),
+		Rounds:      []store.CountRound{},
+		Winners:     []string{},
+		TotalVotes:  len(piles),
+		Abstentions: abstentions,
+	}
+
+	// elected candidates, and the value each holds: their total when elected until their
+	// surplus has been transferred, and the quota after
+	var elected []string
+	retained := make(map[string]int64)
+	// elected candidates whose surplus hasn't been transferred yet, in order of election
+	var pending []string
+
+	// every round's totals for hopeful candidates, used to break ties for exclusion
+	var history []map[string]int64
+
+	for {
+		counts := make(map[string]int64, len(hopeful)+len(elected))
+		for nominationId := range hopeful {
+			counts[nominationId] = 0
+		}
+		for _, nominationId := range elected {
+			counts[nominationId] = retained[nominationId]
+		}
+		for _, b := range piles {
+			if b.holder == "" {
+				continue
+			}
+			if _, ok := retained[b.holder]; ok {
+				// already counted in what the elected candidate holds
+				continue
+			}
+			counts[b.holder] += b.value
+		}
+
+		held := int64(0)
+		hopefulCounts := make(map[string]int64, len(hopeful))
+		for nominationId, votes := range counts {
+			held += votes
+			if _, ok := hopeful[nominationId]; ok {
+				hopefulCounts[nominationId] = votes
+			}
+		}
+		history = append(history, hopefulCounts)
+
+		round := store.CountRound{
+			Round:   len(history),
+			Tallies: talliesFrom(counts, names, float64(stvScale)),
+			// includes value lost to rounding during transfers
+			Exhausted: float64(valid*stvScale-held) / float64(stvScale),
+		}
+		if round.Round == 1 {
+			result.Candidates = round.Tallies
+		}
+
+		if valid == 0 {
+			// nobody has voted for this position, so nobody can win
+			result.Rounds = append(result.Rounds, round)
+			break
+		}
+
+		for _, nominationId := range byVotes(hopefulCounts) {
+			if hopefulCounts[nominationId] < quota {
+				break
+			}
+			round.Elected = append(round.Elected, nominationId)
+			elected = append(elected, nominationId)
+			retained[nominationId] = hopefulCounts[nominationId]
+			delete(hopeful, nominationId)
+			if hopefulCounts[nominationId] > quota {
+				pending = append(pending, nominationId)
+			}
+		}
+
+		remaining := seats - len(elected)
+		if remaining > 0 && len(hopeful) <= remaining {
+			// everyone left fills the remaining seats
+			for _, nominationId := range byVotes(hopefulCounts) {
+				if _, ok := hopeful[nominationId]; !ok {
+					continue
+				}
+				round.Elected = append(round.Elected, nominationId)
+				elected = append(elected, nominationId)
+			}
+			remaining = 0
+		}
+		if remaining == 0 {
+			result.Rounds = append(result.Rounds, round)
+			result.Winners = elected
+			break
+		}
+
+		if len(pending) > 0 {
+			// transfer the largest surplus first, the earliest elected wins a tie
+			largest := 0
+			for i, nominationId := range pending {
+				if counts[nominationId] > counts[pending[largest]] {
+					largest = i
+				}
+			}
+			from := pending[largest]
+			pending = append(pending[:largest], pending[largest+1:]...)
+
+			surplus := counts[from] - quota
+			transferValue := surplus * stvScale / counts[from]
+			for _, b := range piles {
+				if b.holder != from {
+					continue
+				}
+				b.value = b.value * transferValue / stvScale
+				b.holder, _ = highestContinuing(b.preferences, hopeful)
+			}
+			retained[from] = quota
+
+			round.Transfer = &store.SurplusTransfer{
+				From:          from,
+				Surplus:       float64(surplus) / float64(stvScale),
+				TransferValue: float64(transferValue) / float64(stvScale),
+			}
+			result.Rounds = append(result.Rounds, round)
+			continue
+		}
+
+		lowest := breakTies(lowestOf(hopefulCounts), history)
+		if len(hopeful)-len(lowest) < remaining {
+			// excluding them all would leave seats empty, and they are inseparable
+			for _, nominationId := range byVotes(hopefulCounts) {
+				if _, ok := hopeful[nominationId]; !ok {
+					continue
+				}
+				round.Elected = append(round.Elected, nominationId)
+				elected = append(elected, nominationId)
+			}
+			result.Rounds = append(result.Rounds, round)
+			result.Winners = elected
+			result.Tied = true
+			break
+		}
+
+		round.Eliminated = lowest
+		result.Rounds = append(result.Rounds, round)
+		excluded := make(map[string]struct{}, len(lowest))
+		for _, nominationId := range lowest {
+			excluded[nominationId] = struct{}{}
+			delete(hopeful, nominationId)
+		}
+		for _, b := range piles {
+			if _, ok := excluded[b.holder]; ok {
+				b.holder, _ = highestContinuing(b.preferences, hopeful)
+			}
+		}
+	}
+
+	if result.Candidates == nil {
+		result.Candidates = []store.CandidateTally{}
+	}
+
+	return result
+}
+
+// Returns the nomination IDs in counts, highest first, nomination ID keeps the order stable
+func byVotes(counts map[string]int64) []string {
+	ids := make([]string, 0, len(counts))
+	for nominationId := range counts {
+		ids = append(ids, nominationId)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if counts[ids[i]] != counts[ids[j]] {
+			return counts[ids[i]] > counts[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
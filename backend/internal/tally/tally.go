@@ -0,0 +1,100 @@
+// Package tally counts ballots into per-position results. It performs no I/O,
+// callers are expected to load ballots and candidates from a store.
+package tally
+
+import (
+	"sort"
+
+	"github.com/linuxunsw/vote/backend/internal/store"
+)
+
+// A nomination running for a position
+type Candidate struct {
+	NominationID  string
+	CandidateName string
+}
+
+// Re-open nominations, which runs for every position
+var ron = Candidate{NominationID: store.ChoiceRON, CandidateName: "Re-open nominations"}
+
+// Counts every position that has a candidate or received a vote, ordered by position name.
+// Positions with more than one seat are counted with SingleTransferableVote, positions
+// missing from seats have a single seat and are counted with InstantRunoff. RON is added
+// as a candidate for every position.
+func Count(electionID string, seats map[string]int, candidates map[string][]Candidate, ballots []map[string][]string, eligibleVoters int) store.ElectionResults {
+	positionSet := make(map[string]struct{}, len(candidates))
+	for position := range candidates {
+		positionSet[position] = struct{}{}
+	}
+	for _, ballot := range ballots {
+		for position := range ballot {
+			positionSet[position] = struct{}{}
+		}
+	}
+
+	positions := make([]string, 0, len(positionSet))
+	for position := range positionSet {
+		positions = append(positions, position)
+	}
+	sort.Strings(positions)
+
+	results := make([]store.PositionResult, 0, len(positions))
+	for _, position := range positions {
+		running := make([]Candidate, 0, len(candidates[position])+1)
+		running = append(running, candidates[position]...)
+		running = append(running, ron)
+
+		if seats[position] > 1 {
+			results = append(results, SingleTransferableVote(position, seats[position], running, ballots))
+		} else {
+			results = append(results, InstantRunoff(position, running, ballots))
+		}
+	}
+
+	return store.ElectionResults{
+		ElectionID: electionID,
+		Turnout:    NewTurnout(len(ballots), eligibleVoters),
+		Positions:  results,
+	}
+}
+
+// Returns the preferences of every ballot that voted for position, along with the
+// number of ballots that abstained from it
+func rankingsFor(position string, ballots []map[string][]string) ([][]string, int) {
+	rankings := make([][]string, 0, len(ballots))
+	abstentions := 0
+	for _, ballot := range ballots {
+		preferences := ballot[position]
+		if len(preferences) == 0 {
+			continue
+		}
+		if preferences[0] == store.ChoiceAbstain {
+			abstentions++
+			continue
+		}
+		rankings = append(rankings, preferences)
+	}
+	return rankings, abstentions
+}
+
+func NewTurnout(ballotsCast int, eligibleVoters int) store.Turnout {
+	percentage := 0.0
+	if eligibleVoters > 0 {
+		percentage = float64(ballotsCast) / float64(eligibleVoters) * 100
+	}
+	return store.Turnout{
+		BallotsCast:    ballotsCast,
+		EligibleVoters: eligibleVoters,
+		Percentage:     percentage,
+	}
+}
+
+// sorts tallies highest first, nomination ID keeps the order stable for equal counts
+func sortTallies(tallies []store.CandidateTally) {
+	sort.Slice(tallies, func(i, j int) bool {
+		if tallies[i].Votes != tallies[j].Votes {
+			return tallies[i].Votes > tallies[j].Votes
+		}
+		return tallies[i].NominationID < tallies[j].NominationID
+	})
+}
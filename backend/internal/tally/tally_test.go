@@ -0,0 +1,364 @@
+package tally
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInstantRunoff(t *testing.T) {
+	candidates := []Candidate{
+		{NominationID: "a", CandidateName: "Alice"},
+		{NominationID: "b", CandidateName: "Bob"},
+		{NominationID: "c", CandidateName: "Carol"},
+	}
+
+	testCases := []struct {
+		name            string
+		ballots         []map[string][]string
+		expectedWinners []string
+		expectedTied    bool
+		expectedRounds  int
+		expectedTotal   int
+	}{
+		{"No ballots", nil, []string{}, false, 1, 0},
+		{"Majority on first preferences", []map[string][]string{
+			{"president": {"a"}},
+			{"president": {"a", "b"}},
+			{"president": {"b"}},
+		}, []string{"a"}, false, 1, 3},
+		{"Transfer decides the winner", []map[string][]string{
+			{"president": {"a"}},
+			{"president": {"a"}},
+			{"president": {"b"}},
+			{"president": {"b"}},
+			{"president": {"c", "b"}},
+		}, []string{"b"}, false, 2, 5},
+		{"Exhausted ballots do not count towards a majority", []map[string][]string{
+			{"president": {"a"}},
+			{"president": {"a"}},
+			{"president": {"b"}},
+			{"president": {"b"}},
+			{"president": {"c"}},
+		}, []string{"a", "b"}, true, 2, 5},
+		{"Tie for last broken by an earlier round", []map[string][]string{
+			{"president": {"a"}},
+			{"president": {"a"}},
+			{"president": {"a"}},
+			{"president": {"b"}},
+			{"president": {"b"}},
+			{"president": {"c", "b"}},
+			{"president": {"c", "a"}},
+			{"president": {"d", "c"}},
+		}, []string{"a"}, false, 4, 8},
+		{"Ballots without a preference are skipped", []map[string][]string{
+			{"secretary": {"a"}},
+			{"president": {}},
+			{"president": {"c"}},
+		}, []string{"c"}, false, 1, 1},
+		{"Abstentions are not counted", []map[string][]string{
+			{"president": {"ABSTAIN"}},
+			{"president": {"ABSTAIN"}},
+			{"president": {"b"}},
+		}, []string{"b"}, false, 1, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := InstantRunoff("president", candidates, tc.ballots)
+			if !reflect.DeepEqual(result.Winners, tc.expectedWinners) {
+				t.Errorf("expected winners %v, got %v", tc.expectedWinners, result.Winners)
+			}
+			if result.Tied != tc.expectedTied {
+				t.Errorf("expected tied %v, got %v", tc.expectedTied, result.Tied)
+			}
+			if len(result.Rounds) != tc.expectedRounds {
+				t.Errorf("expected %d rounds, got %d: %+v", tc.expectedRounds, len(result.Rounds), result.Rounds)
+			}
+			if result.TotalVotes != tc.expectedTotal {
+				t.Errorf("expected total %d, got %d", tc.expectedTotal, result.TotalVotes)
+			}
+			if len(result.Candidates) < len(candidates) {
+				t.Errorf("expected all %d candidates in result, got %d", len(candidates), len(result.Candidates))
+			}
+		})
+	}
+}
+
+func TestInstantRunoffRounds(t *testing.T) {
+	candidates := []Candidate{
+		{NominationID: "a", CandidateName: "Alice"},
+		{NominationID: "b", CandidateName: "Bob"},
+		{NominationID: "c", CandidateName: "Carol"},
+	}
+	ballots := []map[string][]string{
+		{"president": {"a"}},
+		{"president": {"a"}},
+		{"president": {"b"}},
+		{"president": {"b"}},
+		{"president": {"c", "b"}},
+	}
+
+	result := InstantRunoff("president", candidates, ballots)
+
+	first := result.Rounds[0]
+	if !reflect.DeepEqual(first.Eliminated, []string{"c"}) {
+		t.Fatalf("expected c to be eliminated in round 1, got %v", first.Eliminated)
+	}
+	if len(first.Tallies) != 3 {
+		t.Fatalf("expected 3 continuing candidates in round 1, got %d", len(first.Tallies))
+	}
+
+	second := result.Rounds[1]
+	if len(second.Tallies) != 2 || second.Tallies[0].NominationID != "b" || second.Tallies[0].Votes != 3 {
+		t.Fatalf("expected b to lead round 2 with 3 votes, got %+v", second.Tallies)
+	}
+	if !reflect.DeepEqual(second.Elected, []string{"b"}) {
+		t.Fatalf("expected b to be elected in round 2, got %v", second.Elected)
+	}
+}
+
+func TestSingleTransferableVote(t *testing.T) {
+	candidates := []Candidate{
+		{NominationID: "a", CandidateName: "Alice"},
+		{NominationID: "b", CandidateName: "Bob"},
+		{NominationID: "c", CandidateName: "Carol"},
+	}
+
+	testCases := []struct {
+		name            string
+		seats           int
+		ballots         []map[string][]string
+		expectedWinners []string
+		expectedTied    bool
+		expectedRounds  int
+		expectedQuota   float64
+	}{
+		{"No ballots", 2, nil, []string{}, false, 1, 1},
+		{"Quota reached on first preferences", 2, []map[string][]string{
+			{"arc_delegate": {"a"}},
+			{"arc_delegate": {"a"}},
+			{"arc_delegate": {"b"}},
+			{"arc_delegate": {"b"}},
+			{"arc_delegate": {"c"}},
+		}, []string{"a", "b"}, false, 1, 2},
+		{"Continuing candidates fill the remaining seats", 3, []map[string][]string{
+			{"arc_delegate": {"a"}},
+			{"arc_delegate": {"b"}},
+		}, []string{"a", "b", "c"}, false, 1, 1},
+		{"Surplus exhausts and the rest are tied", 2, []map[string][]string{
+			{"arc_delegate": {"a"}},
+			{"arc_delegate": {"a"}},
+			{"arc_delegate": {"a"}},
+			{"arc_delegate": {"b"}},
+			{"arc_delegate": {"c"}},
+		}, []string{"a", "b", "c"}, true, 2, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := SingleTransferableVote("arc_delegate", tc.seats, candidates, tc.ballots)
+			if !reflect.DeepEqual(result.Winners, tc.expectedWinners) {
+				t.Errorf("expected winners %v, got %v", tc.expectedWinners, result.Winners)
+			}
+			if result.Tied != tc.expectedTied {
+				t.Errorf("expected tied %v, got %v", tc.expectedTied, result.Tied)
+			}
+			if len(result.Rounds) != tc.expectedRounds {
+				t.Errorf("expected %d rounds, got %d: %+v", tc.expectedRounds, len(result.Rounds), result.Rounds)
+			}
+			if result.Quota != tc.expectedQuota {
+				t.Errorf("expected quota %v, got %v", tc.expectedQuota, result.Quota)
+			}
+			if result.Seats != tc.seats {
+				t.Errorf("expected %d seats, got %d", tc.seats, result.Seats)
+			}
+		})
+	}
+}
+
+func TestSingleTransferableVoteRounds(t *testing.T) {
+	candidates := []Candidate{
+		{NominationID: "a", CandidateName: "Alice"},
+		{NominationID: "b", CandidateName: "Bob"},
+		{NominationID: "c", CandidateName: "Carol"},
+		{NominationID: "d", CandidateName: "Dave"},
+	}
+	ballots := []map[string][]string{
+		{"arc_delegate": {"a", "b"}},
+		{"arc_delegate": {"a", "b"}},
+		{"arc_delegate": {"a", "b"}},
+		{"arc_delegate": {"a", "b"}},
+		{"arc_delegate": {"a", "c"}},
+		{"arc_delegate": {"a", "c"}},
+		{"arc_delegate": {"c"}},
+		{"arc_delegate": {"c"}},
+		{"arc_delegate": {"d"}},
+	}
+
+	result := SingleTransferableVote("arc_delegate", 2, candidates, ballots)
+
+	if result.Quota != 4 {
+		t.Fatalf("expected a quota of 4, got %v", result.Quota)
+	}
+	if !reflect.DeepEqual(result.Winners, []string{"a", "c"}) {
+		t.Fatalf("expected winners [a c], got %v", result.Winners)
+	}
+	if len(result.Rounds) != 4 {
+		t.Fatalf("expected 4 rounds, got %d: %+v", len(result.Rounds), result.Rounds)
+	}
+
+	first := result.Rounds[0]
+	if !reflect.DeepEqual(first.Elected, []string{"a"}) {
+		t.Fatalf("expected a to be elected in round 1, got %v", first.Elected)
+	}
+	if first.Transfer == nil || first.Transfer.From != "a" || first.Transfer.Surplus != 2 || first.Transfer.TransferValue != 0.33333 {
+		t.Fatalf("expected a surplus of 2 from a at 0.33333, got %+v", first.Transfer)
+	}
+
+	// a keeps the quota, and the surplus is split between b and c at the transfer value
+	second := result.Rounds[1]
+	expected := map[string]float64{"a": 4, "b": 1.33332, "c": 2.66666, "d": 1}
+	for _, tally := range second.Tallies {
+		if tally.Votes != expected[tally.NominationID] {
+			t.Fatalf("expected %v votes for %s in round 2, got %v", expected[tally.NominationID], tally.NominationID, tally.Votes)
+		}
+	}
+	if !reflect.DeepEqual(second.Eliminated, []string{"d"}) {
+		t.Fatalf("expected d to be eliminated in round 2, got %v", second.Eliminated)
+	}
+
+	if !reflect.DeepEqual(result.Rounds[2].Eliminated, []string{"b"}) {
+		t.Fatalf("expected b to be eliminated in round 3, got %v", result.Rounds[2].Eliminated)
+	}
+	if !reflect.DeepEqual(result.Rounds[3].Elected, []string{"c"}) {
+		t.Fatalf("expected c to be elected in round 4, got %v", result.Rounds[3].Elected)
+	}
+}
+
+func TestCount(t *testing.T) {
+	candidates := map[string][]Candidate{
+		"arc_delegate": {{NominationID: "a", CandidateName: "Alice"}, {NominationID: "b", CandidateName: "Bob"}},
+		"president":    {{NominationID: "a", CandidateName: "Alice"}},
+		"secretary":    {{NominationID: "a", CandidateName: "Alice"}, {NominationID: "b", CandidateName: "Bob"}},
+	}
+	ballots := []map[string][]string{
+		{"president": {"a"}, "secretary": {"b", "a"}, "arc_delegate": {"b"}},
+		{"secretary": {"b"}, "arc_delegate": {"a", "b"}},
+	}
+
+	results := Count("election", map[string]int{"arc_delegate": 2}, candidates, ballots, 4)
+
+	if results.Turnout.BallotsCast != 2 || results.Turnout.EligibleVoters != 4 || results.Turnout.Percentage != 50 {
+		t.Fatalf("unexpected turnout %+v", results.Turnout)
+	}
+	if len(results.Positions) != 3 {
+		t.Fatalf("expected 3 positions, got %d", len(results.Positions))
+	}
+	if results.Positions[0].Position != "arc_delegate" || results.Positions[1].Position != "president" || results.Positions[2].Position != "secretary" {
+		t.Fatalf("expected positions in name order, got %q, %q and %q", results.Positions[0].Position, results.Positions[1].Position, results.Positions[2].Position)
+	}
+	if results.Positions[0].Seats != 2 || !reflect.DeepEqual(results.Positions[0].Winners, []string{"a", "b"}) {
+		t.Fatalf("expected arc_delegate winners a and b over 2 seats, got %v over %d", results.Positions[0].Winners, results.Positions[0].Seats)
+	}
+	if results.Positions[1].Seats != 1 {
+		t.Fatalf("expected president to have 1 seat, got %d", results.Positions[1].Seats)
+	}
+	if !reflect.DeepEqual(results.Positions[2].Winners, []string{"b"}) {
+		t.Fatalf("expected secretary winner b, got %v", results.Positions[2].Winners)
+	}
+}
+
+func TestCountAbstainAndRON(t *testing.T) {
+	candidates := map[string][]Candidate{
+		"president": {{NominationID: "a", CandidateName: "Alice"}},
+		"secretary": {{NominationID: "a", CandidateName: "Alice"}, {NominationID: "b", CandidateName: "Bob"}},
+	}
+	ballots := []map[string][]string{
+		{"president": {"RON"}, "secretary": {"ABSTAIN"}},
+		{"president": {"RON", "a"}, "secretary": {"a"}},
+		{"president": {"a"}, "secretary": {"ABSTAIN"}},
+	}
+
+	results := Count("election", map[string]int{"secretary": 2}, candidates, ballots, 3)
+
+	president := results.Positions[0]
+	if !reflect.DeepEqual(president.Winners, []string{"RON"}) {
+		t.Fatalf("expected RON to win president, got %v", president.Winners)
+	}
+	if president.Abstentions != 0 || president.TotalVotes != 3 {
+		t.Fatalf("expected 3 votes and no abstentions for president, got %d and %d", president.TotalVotes, president.Abstentions)
+	}
+	if len(president.Candidates) != 2 {
+		t.Fatalf("expected RON to be listed as a candidate, got %+v", president.Candidates)
+	}
+
+	secretary := results.Positions[1]
+	if secretary.Abstentions != 2 || secretary.TotalVotes != 1 {
+		t.Fatalf("expected 1 vote and 2 abstentions for secretary, got %d and %d", secretary.TotalVotes, secretary.Abstentions)
+	}
+}
+
+func TestSingleTransferableVoteElectedTogether(t *testing.T) {
+	candidates := []Candidate{
+		{NominationID: "a", CandidateName: "Alice"},
+		{NominationID: "b", CandidateName: "Bob"},
+		{NominationID: "c", CandidateName: "Carol"},
+		{NominationID: "d", CandidateName: "Dave"},
+	}
+	var ballots []map[string][]string
+	for range 8 {
+		ballots = append(ballots, map[string][]string{"arc_delegate": {"a", "c"}})
+		ballots = append(ballots, map[string][]string{"arc_delegate": {"b", "d"}})
+	}
+	for range 2 {
+		ballots = append(ballots, map[string][]string{"arc_delegate": {"c"}})
+		ballots = append(ballots, map[string][]string{"arc_delegate": {"d"}})
+	}
+
+	result := SingleTransferableVote("arc_delegate", 3, candidates, ballots)
+
+	if result.Quota != 6 {
+		t.Fatalf("expected a quota of 6, got %v", result.Quota)
+	}
+	if !reflect.DeepEqual(result.Winners, []string{"a", "b", "c"}) {
+		t.Fatalf("expected winners [a b c], got %v", result.Winners)
+	}
+	if len(result.Rounds) != 4 {
+		t.Fatalf("expected 4 rounds, got %d: %+v", len(result.Rounds), result.Rounds)
+	}
+	if !reflect.DeepEqual(result.Rounds[0].Elected, []string{"a", "b"}) {
+		t.Fatalf("expected a and b to be elected in round 1, got %v", result.Rounds[0].Elected)
+	}
+
+	// ballots held by an elected candidate are only counted in what they hold
+	expected := []map[string]float64{
+		{"a": 8, "b": 8, "c": 2, "d": 2},
+		{"a": 6, "b": 8, "c": 4, "d": 2},
+		{"a": 6, "b": 6, "c": 4, "d": 4},
+	}
+	for i, votes := range expected {
+		round := result.Rounds[i]
+		for _, tally := range round.Tallies {
+			if tally.Votes != votes[tally.NominationID] {
+				t.Fatalf("expected %v votes for %s in round %d, got %v", votes[tally.NominationID], tally.NominationID, i+1, tally.Votes)
+			}
+		}
+		if round.Exhausted != 0 {
+			t.Fatalf("expected nothing exhausted in round %d, got %v", i+1, round.Exhausted)
+		}
+	}
+
+	for i, from := range []string{"a", "b"} {
+		transfer := result.Rounds[i].Transfer
+		if transfer == nil || transfer.From != from || transfer.Surplus != 2 || transfer.TransferValue != 0.25 {
+			t.Fatalf("expected a surplus of 2 from %s at 0.25 in round %d, got %+v", from, i+1, transfer)
+		}
+	}
+
+	if !reflect.DeepEqual(result.Rounds[2].Eliminated, []string{"d"}) {
+		t.Fatalf("expected d to be eliminated in round 3, got %v", result.Rounds[2].Eliminated)
+	}
+	if result.Rounds[3].Exhausted != 4 {
+		t.Fatalf("expected d's 4 votes to exhaust in round 4, got %v", result.Rounds[3].Exhausted)
+	}
+}
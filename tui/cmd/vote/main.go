@@ -39,6 +39,8 @@ func loadConfig() {
 	viper.SetDefault("tui.local", false)
 	viper.SetDefault("society", "$ linux society")
 	viper.SetDefault("event", "event name")
+	viper.SetDefault("identity.pattern", "^z[0-9]{7}$")
+	viper.SetDefault("identity.placeholder", "z1234567")
 
 	// Config name, filetype and path
 	viper.SetConfigName("config")
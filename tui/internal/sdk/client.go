@@ -3,6 +3,7 @@ package sdk
 import (
 	"net/http"
 	"net/http/cookiejar"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/log"
@@ -30,7 +31,25 @@ type GenerateOTPSuccessMsg struct{}
 type SubmitOTPMsg struct {
 	OTP string
 }
-type SubmitOTPSuccessMsg struct{}
+type SubmitOTPSuccessMsg struct {
+	Expiry time.Time
+}
+
+// Sent when the session should be refreshed, Expiry is the expiry it was
+// scheduled for so stale refreshes can be ignored
+type RefreshSessionMsg struct {
+	Expiry time.Time
+}
+type RefreshSessionSuccessMsg struct {
+	Expiry    time.Time
+	MaxExpiry time.Time
+}
+
+// Refreshes happen in the background, so failures aren't shown to the user
+type RefreshSessionErrMsg struct {
+	Expiry time.Time
+	Error  error
+}
 
 type GetElectionStateSuccessMsg struct {
 	State string
@@ -40,11 +59,16 @@ type GetBallotSuccessMsg struct {
 	Ballot *PublicBallot
 }
 
+type GetPositionsSuccessMsg struct {
+	Positions []Position
+}
+
 type SubmitVoteMsg struct {
-	Votes map[string]string
+	Votes map[string][]string
 }
 type SubmitVoteSuccessMsg struct {
 	RefCode string
+	Receipt *VoteReceipt
 }
 
 // Submission is sent as a message to the root model
@@ -67,6 +91,11 @@ type PublicSubmitFormResultMsg struct {
 	Error   error
 }
 
+// Message sent to the voting submission page to show the vote's receipt
+type VoteReceiptMsg struct {
+	Receipt VoteReceipt
+}
+
 func CreateClient(logger *log.Logger, ip string) *ClientWithIP {
 	jar, _ := cookiejar.New(nil)
 	httpClient := &http.Client{
@@ -118,13 +147,21 @@ func SendPublicSubmitFormResult(refCode string, error error) tea.Cmd {
 	return func() tea.Msg { return msg }
 }
 
+func SendVoteReceipt(receipt VoteReceipt) tea.Cmd {
+	msg := VoteReceiptMsg{
+		Receipt: receipt,
+	}
+
+	return func() tea.Msg { return msg }
+}
+
 func SendResetForm() tea.Cmd {
 	msg := ResetFormMsg{}
 
 	return func() tea.Msg { return msg }
 }
 
-func SendSubmitVote(vote map[string]string) tea.Cmd {
+func SendSubmitVote(vote map[string][]string) tea.Cmd {
 	msg := SubmitVoteMsg{
 		Votes: vote,
 	}
@@ -104,11 +104,56 @@ func SubmitOTPCmd(c *ClientWithIP, zID string, otp string) tea.Cmd {
 		}
 
 		// Build success message
-		return SubmitOTPSuccessMsg{}
+		msg := SubmitOTPSuccessMsg{}
+		if resp.JSON200 != nil {
+			msg.Expiry = resp.JSON200.Expiry
+		}
+		return msg
 	}
 
 }
 
+// Sends request to refresh the session cookie, sends response back to root
+// model as RefreshSessionErrMsg or a success message. expiry is the session's
+// current expiry
+func RefreshSessionCmd(c *ClientWithIP, expiry time.Time) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+
+		resp, err := c.Client.RefreshSessionWithResponse(ctx, createIPRequestEditor(c.IP))
+		if err != nil {
+			return RefreshSessionErrMsg{
+				Expiry: expiry,
+				Error:  err,
+			}
+		}
+
+		if resp.StatusCode() == http.StatusUnauthorized {
+			return RefreshSessionErrMsg{
+				Expiry: expiry,
+				Error:  ErrUnauthorised,
+			}
+		}
+		if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
+			err := fmt.Errorf("unexpected status %d", resp.StatusCode())
+			if resp.ApplicationproblemJSONDefault != nil {
+				err = buildError(*resp.ApplicationproblemJSONDefault)
+			}
+
+			return RefreshSessionErrMsg{
+				Expiry: expiry,
+				Error:  err,
+			}
+		}
+
+		return RefreshSessionSuccessMsg{
+			Expiry:    resp.JSON200.Expiry,
+			MaxExpiry: resp.JSON200.MaxExpiry,
+		}
+	}
+}
+
 func GetElectionStateCmd(c *ClientWithIP) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
@@ -189,6 +234,51 @@ func GetBallotCmd(c *ClientWithIP) tea.Cmd {
 	}
 }
 
+func GetPositionsCmd(c *ClientWithIP) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+
+		resp, err := c.Client.GetPositionsWithResponse(ctx, createIPRequestEditor(c.IP))
+		if err != nil {
+			return ServerErrMsg{
+				RespID: "",
+				Error:  err,
+			}
+		}
+
+		// Add request ID as a reference code
+		respID := resp.HTTPResponse.Header.Get("X-Request-ID")
+		if resp.StatusCode() == http.StatusUnauthorized {
+			return ServerErrMsg{
+				StatusCode: resp.StatusCode(),
+				RespID:     respID,
+				Error:      ErrUnauthorised,
+			}
+		}
+		if resp.StatusCode() != http.StatusOK && resp.ApplicationproblemJSONDefault != nil {
+			err := buildError(*resp.ApplicationproblemJSONDefault)
+
+			return ServerErrMsg{
+				StatusCode: resp.StatusCode(),
+				RespID:     respID,
+				Error:      err,
+			}
+		}
+
+		var positions []Position
+		if resp.JSON200.Positions != nil {
+			positions = *resp.JSON200.Positions
+		}
+
+		// Build success message
+		return GetPositionsSuccessMsg{
+			Positions: positions,
+		}
+
+	}
+}
+
 // Sends request to submit a nomination, sends response back to root model as
 // ServerErrMsg or a success message
 func SubmitNominationCmd(c *ClientWithIP, data Submission) tea.Cmd {
@@ -196,17 +286,12 @@ func SubmitNominationCmd(c *ClientWithIP, data Submission) tea.Cmd {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 		defer cancel()
 
-		var execRoles []SubmitNominationExecutiveRoles
-		for _, role := range data.Roles {
-			execRoles = append(execRoles, SubmitNominationExecutiveRoles(role))
-		}
-
 		body := SubmitNomination{
 			CandidateName:      data.Name,
 			CandidateStatement: data.Statement,
 			ContactEmail:       types.Email(data.Email),
 			DiscordUsername:    data.Discord,
-			ExecutiveRoles:     &execRoles,
+			ExecutiveRoles:     &data.Roles,
 		}
 
 		// Prevents submitting an empty url (messes with server validation)
@@ -249,7 +334,7 @@ func SubmitNominationCmd(c *ClientWithIP, data Submission) tea.Cmd {
 	}
 }
 
-func SubmitVoteCmd(c *ClientWithIP, data map[string]string) tea.Cmd {
+func SubmitVoteCmd(c *ClientWithIP, data map[string][]string) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 		defer cancel()
@@ -275,7 +360,7 @@ func SubmitVoteCmd(c *ClientWithIP, data map[string]string) tea.Cmd {
 				Error:      ErrUnauthorised,
 			}
 		}
-		if resp.StatusCode() != http.StatusNoContent && resp.ApplicationproblemJSONDefault != nil {
+		if resp.StatusCode() != http.StatusOK && resp.ApplicationproblemJSONDefault != nil {
 			err := buildError(*resp.ApplicationproblemJSONDefault)
 
 			return ServerErrMsg{
@@ -288,6 +373,7 @@ func SubmitVoteCmd(c *ClientWithIP, data map[string]string) tea.Cmd {
 		// Build success message
 		return SubmitVoteSuccessMsg{
 			RefCode: respID,
+			Receipt: resp.JSON200,
 		}
 
 	}
@@ -34,24 +34,18 @@ const (
 	GetElectionStateResponseBodyStateVOTINGOPEN        GetElectionStateResponseBodyState = "VOTING_OPEN"
 )
 
-// Defines values for NominationExecutiveRoles.
+// Defines values for RefreshSessionResponseBodyRole.
 const (
-	NominationExecutiveRolesArcDelegate      NominationExecutiveRoles = "arc_delegate"
-	NominationExecutiveRolesEdiOfficer       NominationExecutiveRoles = "edi_officer"
-	NominationExecutiveRolesGrievanceOfficer NominationExecutiveRoles = "grievance_officer"
-	NominationExecutiveRolesPresident        NominationExecutiveRoles = "president"
-	NominationExecutiveRolesSecretary        NominationExecutiveRoles = "secretary"
-	NominationExecutiveRolesTreasurer        NominationExecutiveRoles = "treasurer"
+	RefreshSessionResponseBodyRoleELECTIONMANAGER  RefreshSessionResponseBodyRole = "ELECTION_MANAGER"
+	RefreshSessionResponseBodyRoleRETURNINGOFFICER RefreshSessionResponseBodyRole = "RETURNING_OFFICER"
+	RefreshSessionResponseBodyRoleSCRUTINEER       RefreshSessionResponseBodyRole = "SCRUTINEER"
 )
 
-// Defines values for SubmitNominationExecutiveRoles.
+// Defines values for SubmitOTPResponseBodyRole.
 const (
-	SubmitNominationExecutiveRolesArcDelegate      SubmitNominationExecutiveRoles = "arc_delegate"
-	SubmitNominationExecutiveRolesEdiOfficer       SubmitNominationExecutiveRoles = "edi_officer"
-	SubmitNominationExecutiveRolesGrievanceOfficer SubmitNominationExecutiveRoles = "grievance_officer"
-	SubmitNominationExecutiveRolesPresident        SubmitNominationExecutiveRoles = "president"
-	SubmitNominationExecutiveRolesSecretary        SubmitNominationExecutiveRoles = "secretary"
-	SubmitNominationExecutiveRolesTreasurer        SubmitNominationExecutiveRoles = "treasurer"
+	SubmitOTPResponseBodyRoleELECTIONMANAGER  SubmitOTPResponseBodyRole = "ELECTION_MANAGER"
+	SubmitOTPResponseBodyRoleRETURNINGOFFICER SubmitOTPResponseBodyRole = "RETURNING_OFFICER"
+	SubmitOTPResponseBodyRoleSCRUTINEER       SubmitOTPResponseBodyRole = "SCRUTINEER"
 )
 
 // Defines values for TransitionElectionStateBodyState.
@@ -65,6 +59,12 @@ const (
 	TransitionElectionStateBodyStateVOTINGOPEN        TransitionElectionStateBodyState = "VOTING_OPEN"
 )
 
+// BallotOption defines model for BallotOption.
+type BallotOption struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
 // CreateElectionInputBody defines model for CreateElectionInputBody.
 type CreateElectionInputBody struct {
 	// Schema A URL to the JSON Schema for this object.
@@ -72,6 +72,12 @@ type CreateElectionInputBody struct {
 
 	// Name Election name
 	Name string `json:"name"`
+
+	// Positions Positions that can be nominated for and voted on. Defaults to the standard executive roles if omitted. These can be changed until nominations open.
+	Positions *[]Position `json:"positions,omitempty"`
+
+	// SecretBallot Store ballots so they cannot be linked to the voter once voting closes. Votes can still be replaced while voting is open.
+	SecretBallot *bool `json:"secret_ballot,omitempty"`
 }
 
 // CreateElectionResponseBody defines model for CreateElectionResponseBody.
@@ -153,32 +159,55 @@ type GetElectionStateResponseBody struct {
 // GetElectionStateResponseBodyState defines model for GetElectionStateResponseBody.State.
 type GetElectionStateResponseBodyState string
 
+// GetPositionsResponseBody defines model for GetPositionsResponseBody.
+type GetPositionsResponseBody struct {
+	// Schema A URL to the JSON Schema for this object.
+	Schema *string `json:"$schema,omitempty"`
+
+	// ElectionId Election ID
+	ElectionId string `json:"election_id"`
+
+	// Positions Positions in the current election, in the order they should be shown
+	Positions *[]Position `json:"positions"`
+}
+
 // Nomination defines model for Nomination.
 type Nomination struct {
 	// Schema A URL to the JSON Schema for this object.
-	Schema             *string                     `json:"$schema,omitempty"`
-	CandidateName      string                      `json:"candidate_name"`
-	CandidateStatement string                      `json:"candidate_statement"`
-	CandidateZid       string                      `json:"candidate_zid"`
-	ContactEmail       string                      `json:"contact_email"`
-	CreatedAt          time.Time                   `json:"created_at"`
-	DiscordUsername    string                      `json:"discord_username"`
-	ElectionId         string                      `json:"election_id"`
-	ExecutiveRoles     *[]NominationExecutiveRoles `json:"executive_roles"`
-	NominationId       string                      `json:"nomination_id"`
-	UpdatedAt          time.Time                   `json:"updated_at"`
-	Url                *string                     `json:"url,omitempty"`
-}
-
-// NominationExecutiveRoles defines model for Nomination.ExecutiveRoles.
-type NominationExecutiveRoles string
+	Schema             *string   `json:"$schema,omitempty"`
+	CandidateName      string    `json:"candidate_name"`
+	CandidateStatement string    `json:"candidate_statement"`
+	CandidateZid       string    `json:"candidate_zid"`
+	ContactEmail       string    `json:"contact_email"`
+	CreatedAt          time.Time `json:"created_at"`
+	DiscordUsername    string    `json:"discord_username"`
+	ElectionId         string    `json:"election_id"`
+
+	// ExecutiveRoles Keys of the positions being nominated for
+	ExecutiveRoles *[]string `json:"executive_roles"`
+	NominationId   string    `json:"nomination_id"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Url            *string   `json:"url,omitempty"`
+}
+
+// Position defines model for Position.
+type Position struct {
+	Description *string `json:"description,omitempty"`
+	DisplayName string  `json:"display_name"`
+
+	// Key Identifier used for the position in nominations, ballots and results
+	Key string `json:"key"`
+
+	// Seats Number of candidates elected. Positions with more than one seat are counted using single transferable vote.
+	Seats int64 `json:"seats"`
+}
 
 // PublicBallot defines model for PublicBallot.
 type PublicBallot struct {
 	// Schema A URL to the JSON Schema for this object.
 	Schema *string `json:"$schema,omitempty"`
 
-	// Candidates Map of executive role to list of candidates running for that role
+	// Candidates Map of position key to list of candidates running for that position. Every position has an entry, even if nobody is running.
 	Candidates map[string]*[]PublicNomination `json:"candidates"`
 
 	// ElectionId Election ID
@@ -186,6 +215,12 @@ type PublicBallot struct {
 
 	// HasVoted Whether the current user has already voted in this election
 	HasVoted bool `json:"has_voted"`
+
+	// Options Choices offered for every position in addition to its candidates
+	Options *[]BallotOption `json:"options"`
+
+	// Positions Positions on the ballot, in the order they should be shown
+	Positions *[]Position `json:"positions"`
 }
 
 // PublicNomination defines model for PublicNomination.
@@ -203,20 +238,53 @@ type PublicNomination struct {
 	Url                *string   `json:"url,omitempty"`
 }
 
+// RefreshSessionResponseBody defines model for RefreshSessionResponseBody.
+type RefreshSessionResponseBody struct {
+	// Schema A URL to the JSON Schema for this object.
+	Schema *string `json:"$schema,omitempty"`
+
+	// Expiry Timestamp when your session expires.
+	Expiry time.Time `json:"expiry"`
+
+	// IsAdmin Admin status
+	IsAdmin bool `json:"is_admin"`
+
+	// MaxExpiry Timestamp after which your session can't be refreshed, and you must sign in again.
+	MaxExpiry time.Time `json:"max_expiry"`
+
+	// Role Admin role, which decides what admin operations are allowed. Only set for admins.
+	Role *RefreshSessionResponseBodyRole `json:"role,omitempty"`
+
+	// Zid User zID
+	Zid string `json:"zid"`
+}
+
+// RefreshSessionResponseBodyRole Admin role, which decides what admin operations are allowed. Only set for admins.
+type RefreshSessionResponseBodyRole string
+
+// StateChangeEvent defines model for StateChangeEvent.
+type StateChangeEvent struct {
+	// ElectionId Election ID. Not set if no election is running.
+	ElectionId *string                  `json:"election_id,omitempty"`
+	NewState   StateChangeEventNewState `json:"new_state"`
+}
+
+// StateChangeEventNewState defines model for StateChangeEvent.NewState.
+type StateChangeEventNewState string
+
 // SubmitNomination defines model for SubmitNomination.
 type SubmitNomination struct {
 	// Schema A URL to the JSON Schema for this object.
-	Schema             *string                           `json:"$schema,omitempty"`
-	CandidateName      string                            `json:"candidate_name"`
-	CandidateStatement string                            `json:"candidate_statement"`
-	ContactEmail       openapi_types.Email               `json:"contact_email"`
-	DiscordUsername    string                            `json:"discord_username"`
-	ExecutiveRoles     *[]SubmitNominationExecutiveRoles `json:"executive_roles"`
-	Url                *string                           `json:"url,omitempty"`
-}
+	Schema             *string             `json:"$schema,omitempty"`
+	CandidateName      string              `json:"candidate_name"`
+	CandidateStatement string              `json:"candidate_statement"`
+	ContactEmail       openapi_types.Email `json:"contact_email"`
+	DiscordUsername    string              `json:"discord_username"`
 
-// SubmitNominationExecutiveRoles defines model for SubmitNomination.ExecutiveRoles.
-type SubmitNominationExecutiveRoles string
+	// ExecutiveRoles Keys of the positions being nominated for
+	ExecutiveRoles *[]string `json:"executive_roles"`
+	Url            *string   `json:"url,omitempty"`
+}
 
 // SubmitNominationResponseBody defines model for SubmitNominationResponseBody.
 type SubmitNominationResponseBody struct {
@@ -250,17 +318,23 @@ type SubmitOTPResponseBody struct {
 	// IsAdmin Admin status
 	IsAdmin bool `json:"is_admin"`
 
+	// Role Admin role, which decides what admin operations are allowed. Only set for admins.
+	Role *SubmitOTPResponseBodyRole `json:"role,omitempty"`
+
 	// Zid User zID
 	Zid string `json:"zid"`
 }
 
+// SubmitOTPResponseBodyRole Admin role, which decides what admin operations are allowed. Only set for admins.
+type SubmitOTPResponseBodyRole string
+
 // SubmitVoteBody defines model for SubmitVoteBody.
 type SubmitVoteBody struct {
 	// Schema A URL to the JSON Schema for this object.
 	Schema *string `json:"$schema,omitempty"`
 
-	// Positions A map from categories to public nomination IDs. Find these by accessing your ballot.
-	Positions map[string]string `json:"positions"`
+	// Positions A map from categories to public nomination IDs ranked in order of preference, first preference first. Ranking is optional, a single ID is a valid vote. RON can be ranked like a candidate, and ABSTAIN must be the only choice for a position. Find these by accessing your ballot.
+	Positions map[string][]string `json:"positions"`
 }
 
 // TransitionElectionStateBody defines model for TransitionElectionStateBody.
@@ -281,9 +355,30 @@ type Vote struct {
 	Schema    *string   `json:"$schema,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 
-	// Positions A map from categories to public nomination IDs. Find these by accessing your ballot.
-	Positions map[string]string `json:"positions"`
-	UpdatedAt time.Time         `json:"updated_at"`
+	// Positions A map from categories to public nomination IDs ranked in order of preference, first preference first. Null in a secret ballot election once voting has closed, as the vote can no longer be linked to you.
+	Positions map[string][]string `json:"positions"`
+
+	// ReceiptHash Hex encoded receipt hash of the vote, as listed on the bulletin
+	ReceiptHash *string   `json:"receipt_hash,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// VoteReceipt defines model for VoteReceipt.
+type VoteReceipt struct {
+	// Schema A URL to the JSON Schema for this object.
+	Schema *string `json:"$schema,omitempty"`
+
+	// ElectionId Election ID
+	ElectionId string `json:"election_id"`
+
+	// Nonce Hex encoded random nonce mixed into the receipt hash. Keep this private.
+	Nonce string `json:"nonce"`
+
+	// ReceiptHash Hex encoded SHA-256 of the nonce followed by the JSON encoded positions, with object keys sorted
+	ReceiptHash string `json:"receipt_hash"`
+
+	// Signature Hex encoded ed25519 signature of "vote-receipt:<election_id>:<receipt_hash>", verifiable with the receipt public key
+	Signature string `json:"signature"`
 }
 
 // CreateElectionJSONRequestBody defines body for CreateElection for application/json ContentType.
@@ -417,9 +512,18 @@ type ClientInterface interface {
 
 	SubmitOtp(ctx context.Context, body SubmitOtpJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// GetPositions request
+	GetPositions(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// RefreshSession request
+	RefreshSession(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// GetElectionState request
 	GetElectionState(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// StreamElectionState request
+	StreamElectionState(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// AdminTransitionElectionStateWithBody request with any body
 	AdminTransitionElectionStateWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
 
@@ -605,6 +709,30 @@ func (c *Client) SubmitOtp(ctx context.Context, body SubmitOtpJSONRequestBody, r
 	return c.Client.Do(req)
 }
 
+func (c *Client) GetPositions(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetPositionsRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) RefreshSession(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewRefreshSessionRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 func (c *Client) GetElectionState(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
 	req, err := NewGetElectionStateRequest(c.Server)
 	if err != nil {
@@ -617,6 +745,18 @@ func (c *Client) GetElectionState(ctx context.Context, reqEditors ...RequestEdit
 	return c.Client.Do(req)
 }
 
+func (c *Client) StreamElectionState(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewStreamElectionStateRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 func (c *Client) AdminTransitionElectionStateWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
 	req, err := NewAdminTransitionElectionStateRequestWithBody(c.Server, contentType, body)
 	if err != nil {
@@ -1011,6 +1151,60 @@ func NewSubmitOtpRequestWithBody(server string, contentType string, body io.Read
 	return req, nil
 }
 
+// NewGetPositionsRequest generates requests for GetPositions
+func NewGetPositionsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/positions")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewRefreshSessionRequest generates requests for RefreshSession
+func NewRefreshSessionRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/refresh")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 // NewGetElectionStateRequest generates requests for GetElectionState
 func NewGetElectionStateRequest(server string) (*http.Request, error) {
 	var err error
@@ -1038,6 +1232,33 @@ func NewGetElectionStateRequest(server string) (*http.Request, error) {
 	return req, nil
 }
 
+// NewStreamElectionStateRequest generates requests for StreamElectionState
+func NewStreamElectionStateRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/state/stream")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 // NewAdminTransitionElectionStateRequest calls the generic AdminTransitionElectionState builder with application/json body
 func NewAdminTransitionElectionStateRequest(server string, body AdminTransitionElectionStateJSONRequestBody) (*http.Request, error) {
 	var bodyReader io.Reader
@@ -1252,6 +1473,12 @@ type ClientWithResponsesInterface interface {
 
 	SubmitOtpWithResponse(ctx context.Context, body SubmitOtpJSONRequestBody, reqEditors ...RequestEditorFn) (*SubmitOtpResponse, error)
 
+	// GetPositionsWithResponse request
+	GetPositionsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetPositionsResponse, error)
+
+	// RefreshSessionWithResponse request
+	RefreshSessionWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*RefreshSessionResponse, error)
+
 	// GetElectionStateWithResponse request
 	GetElectionStateWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetElectionStateResponse, error)
 
@@ -1476,6 +1703,52 @@ func (r SubmitOtpResponse) StatusCode() int {
 	return 0
 }
 
+type GetPositionsResponse struct {
+	Body                          []byte
+	HTTPResponse                  *http.Response
+	JSON200                       *GetPositionsResponseBody
+	ApplicationproblemJSONDefault *ErrorModel
+}
+
+// Status returns HTTPResponse.Status
+func (r GetPositionsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetPositionsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type RefreshSessionResponse struct {
+	Body                          []byte
+	HTTPResponse                  *http.Response
+	JSON200                       *RefreshSessionResponseBody
+	ApplicationproblemJSONDefault *ErrorModel
+}
+
+// Status returns HTTPResponse.Status
+func (r RefreshSessionResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r RefreshSessionResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 type GetElectionStateResponse struct {
 	Body                          []byte
 	HTTPResponse                  *http.Response
@@ -1569,6 +1842,7 @@ func (r GetVoteResponse) StatusCode() int {
 type SubmitVoteResponse struct {
 	Body                          []byte
 	HTTPResponse                  *http.Response
+	JSON200                       *VoteReceipt
 	ApplicationproblemJSONDefault *ErrorModel
 }
 
@@ -1709,6 +1983,24 @@ func (c *ClientWithResponses) SubmitOtpWithResponse(ctx context.Context, body Su
 	return ParseSubmitOtpResponse(rsp)
 }
 
+// GetPositionsWithResponse request returning *GetPositionsResponse
+func (c *ClientWithResponses) GetPositionsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetPositionsResponse, error) {
+	rsp, err := c.GetPositions(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetPositionsResponse(rsp)
+}
+
+// RefreshSessionWithResponse request returning *RefreshSessionResponse
+func (c *ClientWithResponses) RefreshSessionWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*RefreshSessionResponse, error) {
+	rsp, err := c.RefreshSession(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRefreshSessionResponse(rsp)
+}
+
 // GetElectionStateWithResponse request returning *GetElectionStateResponse
 func (c *ClientWithResponses) GetElectionStateWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetElectionStateResponse, error) {
 	rsp, err := c.GetElectionState(ctx, reqEditors...)
@@ -2046,6 +2338,72 @@ func ParseSubmitOtpResponse(rsp *http.Response) (*SubmitOtpResponse, error) {
 	return response, nil
 }
 
+// ParseGetPositionsResponse parses an HTTP response from a GetPositionsWithResponse call
+func ParseGetPositionsResponse(rsp *http.Response) (*GetPositionsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetPositionsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest GetPositionsResponseBody
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest ErrorModel
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.ApplicationproblemJSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseRefreshSessionResponse parses an HTTP response from a RefreshSessionWithResponse call
+func ParseRefreshSessionResponse(rsp *http.Response) (*RefreshSessionResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &RefreshSessionResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest RefreshSessionResponseBody
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest ErrorModel
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.ApplicationproblemJSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParseGetElectionStateResponse parses an HTTP response from a GetElectionStateWithResponse call
 func ParseGetElectionStateResponse(rsp *http.Response) (*GetElectionStateResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -2178,6 +2536,13 @@ func ParseSubmitVoteResponse(rsp *http.Response) (*SubmitVoteResponse, error) {
 	}
 
 	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest VoteReceipt
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
 		var dest ErrorModel
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
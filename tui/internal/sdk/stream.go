@@ -0,0 +1,139 @@
+// Contains the tea.Cmds which are used to follow the election state stream
+
+package sdk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// An open connection to the election state stream
+type StateStream struct {
+	states chan string
+	cancel context.CancelFunc
+
+	// why the stream ended, only set once states is closed
+	err error
+}
+
+// Sent to the root model once the stream is connected
+type StateStreamOpenedMsg struct {
+	Stream *StateStream
+}
+
+// Sent to the root model when the election state changes. The first message after
+// connecting is the current state.
+type StateChangeMsg struct {
+	Stream *StateStream
+	State  string
+}
+
+// Sent to the root model when the stream couldn't connect or has disconnected
+type StateStreamClosedMsg struct {
+	Stream *StateStream
+	Error  error
+}
+
+// Disconnects from the stream
+func (s *StateStream) Close() {
+	s.cancel()
+}
+
+// Reads events from the response body until it ends or the stream is closed
+func (s *StateStream) read(ctx context.Context, body io.ReadCloser) {
+	defer close(s.states)
+	defer func() {
+		_ = body.Close()
+	}()
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		var event StateChangeEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			s.err = err
+			return
+		}
+
+		select {
+		case s.states <- string(event.NewState):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	s.err = scanner.Err()
+	if s.err == nil {
+		s.err = io.EOF
+	}
+}
+
+// Connects to the election state stream, sends the stream back to the root model as
+// StateStreamOpenedMsg or StateStreamClosedMsg if it couldn't connect
+func SubscribeStateCmd(c *ClientWithIP) tea.Cmd {
+	return func() tea.Msg {
+		// no timeout, the stream stays open until closed
+		ctx, cancel := context.WithCancel(context.Background())
+
+		resp, err := c.Client.StreamElectionState(ctx, createIPRequestEditor(c.IP))
+		if err != nil {
+			cancel()
+			return StateStreamClosedMsg{
+				Error: err,
+			}
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			cancel()
+
+			err := fmt.Errorf("unable to stream election state: %s", resp.Status)
+			if resp.StatusCode == http.StatusUnauthorized {
+				err = ErrUnauthorised
+			}
+			return StateStreamClosedMsg{
+				Error: err,
+			}
+		}
+
+		stream := &StateStream{
+			states: make(chan string),
+			cancel: cancel,
+		}
+		go stream.read(ctx, resp.Body)
+
+		return StateStreamOpenedMsg{
+			Stream: stream,
+		}
+	}
+}
+
+// Waits for the next state change on the stream, sends it back to the root model as
+// StateChangeMsg or StateStreamClosedMsg once the stream ends
+func WaitForStateChangeCmd(s *StateStream) tea.Cmd {
+	return func() tea.Msg {
+		state, ok := <-s.states
+		if !ok {
+			return StateStreamClosedMsg{
+				Stream: s,
+				Error:  s.err,
+			}
+		}
+
+		return StateChangeMsg{
+			Stream: s,
+			State:  state,
+		}
+	}
+}
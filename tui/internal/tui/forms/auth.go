@@ -4,6 +4,7 @@ import (
 	"github.com/charmbracelet/huh"
 	"github.com/linuxunsw/vote/tui/internal/tui/styles"
 	"github.com/linuxunsw/vote/tui/internal/tui/validation"
+	"github.com/spf13/viper"
 )
 
 // Creates a new form to prompt the user for their zID
@@ -13,7 +14,7 @@ func ZID() *huh.Form {
 			huh.NewInput().
 				Key("zid").
 				Title("what's your zid?").
-				Placeholder("z1234567").
+				Placeholder(viper.GetString("identity.placeholder")).
 				Validate(validation.ZID),
 		),
 	).WithTheme(styles.FormTheme())
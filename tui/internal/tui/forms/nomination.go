@@ -1,13 +1,22 @@
 package forms
 
 import (
+	"strings"
+
 	"github.com/charmbracelet/huh"
+	"github.com/linuxunsw/vote/tui/internal/sdk"
 	"github.com/linuxunsw/vote/tui/internal/tui/styles"
 	"github.com/linuxunsw/vote/tui/internal/tui/validation"
 )
 
-// Creates a form for the user's nomination information
-func Nomination() *huh.Form {
+// Creates a form for the user's nomination information, offering each of
+// the election's positions as a role
+func Nomination(positions []sdk.Position) *huh.Form {
+	var roles []huh.Option[string]
+	for _, position := range positions {
+		roles = append(roles, huh.NewOption(strings.ToLower(position.DisplayName), position.Key))
+	}
+
 	return huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
@@ -25,14 +34,7 @@ func Nomination() *huh.Form {
 			huh.NewMultiSelect[string]().
 				Key("roles").
 				Title("roles you are nominating for").
-				Options(
-					huh.NewOption("president", "president"),
-					huh.NewOption("secretary", "secretary"),
-					huh.NewOption("treasurer", "treasurer"),
-					huh.NewOption("arc delegate", "arc_delegate"),
-					huh.NewOption("edi officer", "edi_officer"),
-					huh.NewOption("grievance officer", "grievance_officer"),
-				).
+				Options(roles...).
 				Validate(validation.Role),
 			huh.NewText().
 				Key("statement").
@@ -1,55 +1,59 @@
 package forms
 
 import (
+	"strings"
+
 	"github.com/charmbracelet/huh"
 	"github.com/linuxunsw/vote/tui/internal/sdk"
 	"github.com/linuxunsw/vote/tui/internal/tui/styles"
 )
 
+// Creates a form with a select for each position on the ballot, keyed by
+// the position's key
 func Voting(data sdk.PublicBallot, vote map[string]string) *huh.Form {
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Key(string(sdk.NominationExecutiveRolesPresident)).
-				Title("president").
-				Options(optionsForRole(data, sdk.NominationExecutiveRolesPresident)...),
-			huh.NewSelect[string]().
-				Key(string(sdk.NominationExecutiveRolesSecretary)).
-				Title("secretary").
-				Options(optionsForRole(data, sdk.NominationExecutiveRolesSecretary)...),
-			huh.NewSelect[string]().
-				Key(string(sdk.NominationExecutiveRolesTreasurer)).
-				Title("treasurer").
-				Options(optionsForRole(data, sdk.NominationExecutiveRolesTreasurer)...),
+	var fields []huh.Field
+	for _, position := range BallotPositions(data) {
+		fields = append(fields,
 			huh.NewSelect[string]().
-				Key(string(sdk.NominationExecutiveRolesArcDelegate)).
-				Title("arc delegate").
-				Options(optionsForRole(data, sdk.NominationExecutiveRolesArcDelegate)...),
-			huh.NewSelect[string]().
-				Key(string(sdk.NominationExecutiveRolesEdiOfficer)).
-				Title("edi officer").
-				Options(optionsForRole(data, sdk.NominationExecutiveRolesEdiOfficer)...),
-			huh.NewSelect[string]().
-				Key(string(sdk.NominationExecutiveRolesGrievanceOfficer)).
-				Title("grievance officer").
-				Options(optionsForRole(data, sdk.NominationExecutiveRolesGrievanceOfficer)...),
-		),
+				Key(position.Key).
+				Title(strings.ToLower(position.DisplayName)).
+				Options(optionsForRole(data, position.Key)...),
+		)
+	}
+
+	form := huh.NewForm(
+		huh.NewGroup(fields...),
 	).WithTheme(styles.FormTheme())
 
 	return form
 }
 
-func optionsForRole(data sdk.PublicBallot, role sdk.NominationExecutiveRoles) []huh.Option[string] {
+// Returns the positions on the ballot, in the order they should be shown
+func BallotPositions(data sdk.PublicBallot) []sdk.Position {
+	if data.Positions == nil {
+		return nil
+	}
+	return *data.Positions
+}
+
+// Returns the candidates running for role, followed by the choices every
+// position offers such as RON and abstain
+func optionsForRole(data sdk.PublicBallot, role string) []huh.Option[string] {
 	var opts []huh.Option[string]
-	candidates, ok := data.Candidates[string(role)]
 
-	if !ok {
-		return []huh.Option[string]{huh.NewOption("no option", "")}
+	if candidates, ok := data.Candidates[role]; ok && candidates != nil {
+		for _, candidate := range *candidates {
+			opts = append(opts, huh.NewOption(candidate.CandidateName, candidate.NominationId))
+		}
 	}
-
-	for _, candidate := range *candidates {
-		opts = append(opts, huh.NewOption(candidate.CandidateName, candidate.NominationId))
+	if data.Options != nil {
+		for _, option := range *data.Options {
+			opts = append(opts, huh.NewOption(option.Name, option.Id))
+		}
 	}
 
+	if len(opts) == 0 {
+		return []huh.Option[string]{huh.NewOption("no option", "")}
+	}
 	return opts
 }
@@ -10,7 +10,7 @@ import (
 )
 
 const (
-	closedMessage = "voting/nominations are currently closed, please come back later!"
+	closedMessage = "voting/nominations are currently closed, this page will update once they open!"
 	exitMessage   = "exit with ctrl+c"
 )
 
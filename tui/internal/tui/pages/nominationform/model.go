@@ -22,11 +22,11 @@ type formModel struct {
 	isSubmitted bool
 }
 
-// Creates model
-func New(logger *log.Logger) tea.Model {
+// Creates model, the form lets the user nominate for any of positions
+func New(logger *log.Logger, positions []sdk.Position) tea.Model {
 	model := &formModel{
 		logger:      logger,
-		form:        forms.Nomination(),
+		form:        forms.Nomination(positions),
 		isSubmitted: false,
 	}
 
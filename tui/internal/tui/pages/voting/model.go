@@ -18,6 +18,8 @@ type formModel struct {
 	cHeight int
 
 	form *huh.Form
+	// positions on the ballot, each has a field in the form
+	positions []sdk.Position
 
 	isSubmitted bool
 }
@@ -28,6 +30,7 @@ func New(logger *log.Logger, data sdk.PublicBallot) tea.Model {
 
 	model := &formModel{
 		logger:      logger,
+		positions:   forms.BallotPositions(data),
 		isSubmitted: false,
 	}
 
@@ -50,19 +53,14 @@ func (m *formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.form.State == huh.StateCompleted && !m.isSubmitted {
 		m.isSubmitted = true
 
-		positions := make(map[string]string)
+		// the form only asks for a first preference, which the server
+		// accepts as a complete preferential vote
+		positions := make(map[string][]string)
 
 		// if the choice was empty, skip
-		for key, val := range map[string]string{
-			"president":         m.form.GetString("president"),
-			"secretary":         m.form.GetString("secretary"),
-			"treasurer":         m.form.GetString("treasurer"),
-			"arc_delegate":      m.form.GetString("arc_delegate"),
-			"edi_officer":       m.form.GetString("edi_officer"),
-			"grievance_officer": m.form.GetString("grievance_officer"),
-		} {
-			if val != "" {
-				positions[key] = val
+		for _, position := range m.positions {
+			if val := m.form.GetString(position.Key); val != "" {
+				positions[position.Key] = []string{val}
 			}
 		}
 
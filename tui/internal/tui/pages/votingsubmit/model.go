@@ -13,6 +13,7 @@ import (
 const (
 	successMessage = "your vote was submitted successfully! \n\nyour reference code is %s."
 	errorMessage   = "something went wrong :( \n\nplease try again later. if you are still encountering issues, please contact a society executive with the following reference code: %s."
+	receiptMessage = "your receipt:\n\nhash: %s\nnonce: %s\nsignature: %s\n\nkeep this somewhere safe. once results are published, check your hash is on the bulletin next to your vote."
 	exitMessage    = "exit with ctrl+c"
 )
 
@@ -26,6 +27,7 @@ type submitModel struct {
 	// Submission details
 	refCode string
 	error   error
+	receipt *sdk.VoteReceipt
 }
 
 func New(logger *log.Logger) tea.Model {
@@ -53,6 +55,11 @@ func (m *submitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.refCode = msg.RefCode
 		m.error = msg.Error
 
+		return m, nil
+	case sdk.VoteReceiptMsg:
+		log.Debug("VoteReceiptMsg", "receiptHash", msg.Receipt.ReceiptHash)
+		m.receipt = &msg.Receipt
+
 		return m, nil
 	}
 
@@ -68,6 +75,9 @@ func (m *submitModel) View() string {
 		content = fmt.Sprintf(errorMessage, m.refCode)
 	} else {
 		content = fmt.Sprintf(successMessage, m.refCode)
+		if m.receipt != nil {
+			content += "\n\n" + fmt.Sprintf(receiptMessage, m.receipt.ReceiptHash, m.receipt.Nonce, m.receipt.Signature)
+		}
 	}
 
 	exit := styles.ExitMessageStyle.Render(exitMessage)
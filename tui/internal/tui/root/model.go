@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -29,6 +30,16 @@ import (
 
 const helpHeight = 1
 
+// how long to wait before reconnecting to the state stream
+const streamRetryInterval = 5 * time.Second
+
+// how long before the session expires to refresh it, and how long to wait
+// before trying again if that fails
+const (
+	refreshBefore        = 5 * time.Minute
+	refreshRetryInterval = 30 * time.Second
+)
+
 // Form data
 type formData struct {
 	zID        string
@@ -53,6 +64,13 @@ type rootModel struct {
 	isAuthenticated bool
 	error           error
 
+	// refreshed in the background so long forms aren't lost when it passes
+	sessionExpiry time.Time
+
+	// follows election state changes once authenticated
+	stream        *sdk.StateStream
+	electionState string
+
 	loadingSpinner spinner.Model
 	loading        bool
 
@@ -70,7 +88,6 @@ func New(user, ip string) tea.Model {
 	pageMap := map[pages.PageID]tea.Model{
 		pages.Auth:             auth.New(logger),
 		pages.AuthCode:         authcode.New(logger),
-		pages.NominationForm:   nominationform.New(logger),
 		pages.NominationSubmit: nominationsubmit.New(logger),
 		pages.Closed:           closed.New(logger),
 		pages.VotingSubmit:     votingsubmit.New(logger),
@@ -159,22 +176,113 @@ func (m *rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.log.Debug("SubmitOTPSuccessMsg")
 
 		m.isAuthenticated = true
+		m.sessionExpiry = msg.Expiry
+
+		return m, tea.Batch(
+			sdk.GetElectionStateCmd(m.client),
+			sdk.SubscribeStateCmd(m.client),
+			m.scheduleRefresh(),
+		)
+	case sdk.RefreshSessionMsg:
+		m.log.Debug("RefreshSessionMsg", "expiry", msg.Expiry)
+
+		// logged out, or already refreshed
+		if !m.isAuthenticated || !msg.Expiry.Equal(m.sessionExpiry) {
+			return m, nil
+		}
+		return m, sdk.RefreshSessionCmd(m.client, msg.Expiry)
+	case sdk.RefreshSessionSuccessMsg:
+		m.log.Debug("RefreshSessionSuccessMsg", "expiry", msg.Expiry, "max_expiry", msg.MaxExpiry)
+
+		if !m.isAuthenticated {
+			return m, nil
+		}
+		m.sessionExpiry = msg.Expiry
+
+		// refreshing again won't extend it, so the user will have to sign in again
+		if !msg.Expiry.Before(msg.MaxExpiry) {
+			m.log.Info("Session reached its max lifetime", "expiry", msg.Expiry)
+			return m, nil
+		}
+		return m, m.scheduleRefresh()
+	case sdk.RefreshSessionErrMsg:
+		m.log.Debug("RefreshSessionErrMsg", "error", msg.Error)
+
+		if !m.isAuthenticated || !msg.Expiry.Equal(m.sessionExpiry) {
+			return m, nil
+		}
+		// the session has ended, the next request will send the user back to sign in
+		if msg.Error == sdk.ErrUnauthorised {
+			return m, nil
+		}
+		if time.Now().Add(refreshRetryInterval).After(m.sessionExpiry) {
+			m.log.Warn("Failed to refresh session before it expired", "error", msg.Error)
+			return m, nil
+		}
+		m.log.Warn("Failed to refresh session, retrying", "error", msg.Error, "retry_in", refreshRetryInterval)
 
-		return m, sdk.GetElectionStateCmd(m.client)
+		expiry := m.sessionExpiry
+		return m, tea.Tick(refreshRetryInterval, func(time.Time) tea.Msg {
+			return sdk.RefreshSessionMsg{Expiry: expiry}
+		})
 	case sdk.GetElectionStateSuccessMsg:
 		m.log.Debug("GetElectionStateSuccessMsg", "state", msg.State)
 
 		m.loading = false
+		m.electionState = msg.State
 
-		// change form depending on state
-		if msg.State == string(sdk.GetElectionStateResponseBodyStateNOMINATIONSOPEN) {
-			return m, messages.SendPageChange(pages.NominationForm)
-		} else if msg.State == string(sdk.GetElectionStateResponseBodyStateVOTINGOPEN) {
-			m.loading = true
-			return m, sdk.GetBallotCmd(m.client)
-		} else {
-			return m, messages.SendPageChange(pages.Closed)
+		return m, m.showState(msg.State)
+	case sdk.StateStreamOpenedMsg:
+		m.log.Debug("StateStreamOpenedMsg")
+
+		// logged out while connecting
+		if !m.isAuthenticated {
+			msg.Stream.Close()
+			return m, nil
+		}
+
+		m.stream = msg.Stream
+		return m, sdk.WaitForStateChangeCmd(m.stream)
+	case sdk.StateChangeMsg:
+		m.log.Debug("StateChangeMsg", "state", msg.State)
+
+		if msg.Stream != m.stream {
+			return m, nil
+		}
+		wait := sdk.WaitForStateChangeCmd(m.stream)
+		if msg.State == m.electionState {
+			return m, wait
 		}
+		m.electionState = msg.State
+
+		// only move users who are waiting or part way through a form, not while a
+		// request is in flight or while they are reading a submission result
+		if m.loading || !followsState(m.current) {
+			return m, wait
+		}
+		return m, tea.Batch(wait, m.showState(msg.State))
+	case sdk.StateStreamClosedMsg:
+		m.log.Debug("StateStreamClosedMsg", "error", msg.Error)
+
+		if msg.Stream != m.stream {
+			return m, nil
+		}
+		m.stream = nil
+
+		if !m.isAuthenticated || msg.Error == sdk.ErrUnauthorised {
+			return m, nil
+		}
+		m.log.Warn("State stream disconnected, reconnecting", "error", msg.Error, "retry_in", streamRetryInterval)
+
+		client := m.client
+		return m, tea.Tick(streamRetryInterval, func(time.Time) tea.Msg {
+			return sdk.SubscribeStateCmd(client)()
+		})
+	case sdk.GetPositionsSuccessMsg:
+		m.loading = false
+		m.pages[pages.NominationForm] = nominationform.New(m.log, msg.Positions)
+		m.loaded[pages.NominationForm] = false
+		return m, messages.SendPageChange(pages.NominationForm)
 	case sdk.GetBallotSuccessMsg:
 		m.loading = false
 		m.pages[pages.VotingForm] = voting.New(m.log, *msg.Ballot)
@@ -184,10 +292,14 @@ func (m *rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, sdk.SubmitVoteCmd(m.client, msg.Votes)
 	case sdk.SubmitVoteSuccessMsg:
 		m.loading = false
-		return m, tea.Sequence(
+		cmds := []tea.Cmd{
 			messages.SendPageChange(pages.VotingSubmit),
 			sdk.SendPublicSubmitFormResult(msg.RefCode, nil),
-		)
+		}
+		if msg.Receipt != nil {
+			cmds = append(cmds, sdk.SendVoteReceipt(*msg.Receipt))
+		}
+		return m, tea.Sequence(cmds...)
 	case sdk.SubmitNominationSuccessMsg:
 		m.log.Debug("SubmitNominationSuccessMsg", "refCode", msg.RefCode)
 		m.loading = false
@@ -208,13 +320,17 @@ func (m *rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// reset pages
 			m.pages[pages.Auth] = auth.New(m.log)
 			m.pages[pages.AuthCode] = authcode.New(m.log)
-			m.pages[pages.NominationForm] = nominationform.New(m.log)
 			m.loaded[pages.Auth] = false
 			m.loaded[pages.AuthCode] = false
 			m.loaded[pages.NominationForm] = false
 			m.loaded[pages.VotingForm] = false
 
 			m.isAuthenticated = false
+			m.sessionExpiry = time.Time{}
+			if m.stream != nil {
+				m.stream.Close()
+				m.stream = nil
+			}
 			return m, messages.SendPageChange(pages.Auth)
 
 		}
@@ -343,6 +459,40 @@ func (m *rootModel) findContentSize() (w int, h int) {
 	return
 }
 
+// Shows the form for the given election state, or the closed page if there isn't one
+func (m *rootModel) showState(state string) tea.Cmd {
+	if state == string(sdk.GetElectionStateResponseBodyStateNOMINATIONSOPEN) {
+		m.loading = true
+		return sdk.GetPositionsCmd(m.client)
+	} else if state == string(sdk.GetElectionStateResponseBodyStateVOTINGOPEN) {
+		m.loading = true
+		return sdk.GetBallotCmd(m.client)
+	}
+	return messages.SendPageChange(pages.Closed)
+}
+
+// Schedules a refresh of the session shortly before it expires
+func (m *rootModel) scheduleRefresh() tea.Cmd {
+	expiry := m.sessionExpiry
+	if expiry.IsZero() {
+		return nil
+	}
+
+	wait := max(time.Until(expiry.Add(-refreshBefore)), 0)
+	return tea.Tick(wait, func(time.Time) tea.Msg {
+		return sdk.RefreshSessionMsg{Expiry: expiry}
+	})
+}
+
+// Whether the page should change when the election state does
+func followsState(pageID pages.PageID) bool {
+	switch pageID {
+	case pages.Closed, pages.NominationForm, pages.VotingForm:
+		return true
+	}
+	return false
+}
+
 // Switches current page given a pageID
 func (m *rootModel) movePage(pageID pages.PageID) tea.Cmd {
 	m.current = pageID
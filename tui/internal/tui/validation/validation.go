@@ -6,21 +6,28 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+
+	"github.com/spf13/viper"
 )
 
 var (
-	errEmail    = errors.New("please enter a valid email address")
-	errOTP      = errors.New("please enter a valid verification code")
-	errZID      = errors.New("please enter a valid zID")
-	errRoles    = errors.New("please select a role")
-	errURL      = errors.New("please enter a valid url (including the https://, etc.)")
-	errNonEmpty = errors.New("this field cannot be empty")
+	errEmail      = errors.New("please enter a valid email address")
+	errOTP        = errors.New("please enter a valid verification code")
+	errZID        = errors.New("please enter a valid zID")
+	errZIDPattern = errors.New("zIDs can't be checked, identity.pattern is invalid")
+	errRoles      = errors.New("please select a role")
+	errURL        = errors.New("please enter a valid url (including the https://, etc.)")
+	errNonEmpty   = errors.New("this field cannot be empty")
 )
 
 // Validates a zID
-// A zID must be in the following format: 'z5555555'
+// A zID must match the configured 'identity.pattern', by default: 'z5555555'
+// The pattern should be the same as the backend's IDENTITY_PATTERN
 func ZID(zID string) error {
-	var re = regexp.MustCompile("(?m)^z[0-9]{7}$")
+	re, err := regexp.Compile(viper.GetString("identity.pattern"))
+	if err != nil {
+		return errZIDPattern
+	}
 
 	if !re.MatchString(zID) {
 		return errZID